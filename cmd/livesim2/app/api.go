@@ -234,6 +234,123 @@ func createListSgaiSessionsHdlr(s *Server) func(ctx context.Context, input *stru
 	}
 }
 
+// CmcdSessionResponse is the OpenAPI response for a single CMCD session.
+type CmcdSessionResponse struct {
+	Body struct {
+		Session CmcdSession `json:"session" doc:"Aggregated CMCD data and recent payloads for the session"`
+	}
+}
+
+// CmcdSessionListResponse is the OpenAPI response listing active CMCD sessions (no reports).
+type CmcdSessionListResponse struct {
+	Body struct {
+		Sessions []CmcdSession `json:"sessions" doc:"Active sessions, most-recently-active first"`
+	}
+}
+
+type cmcdSidInput struct {
+	Sid string `path:"sid" maxLength:"256" example:"6e2fb550-c457-11e9-bb97-0800200c9a66" doc:"CMCD session id (sid key)"`
+}
+
+// CmcdClearResponse is the OpenAPI response for clearing CMCD session data.
+type CmcdClearResponse struct {
+	Body struct {
+		Cleared int `json:"cleared" doc:"Number of sessions removed"`
+	}
+}
+
+func createListCmcdSessionsHdlr(s *Server) func(ctx context.Context, input *struct{}) (*CmcdSessionListResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*CmcdSessionListResponse, error) {
+		resp := &CmcdSessionListResponse{}
+		if s.cmcdSessions != nil {
+			resp.Body.Sessions = s.cmcdSessions.List()
+		}
+		if resp.Body.Sessions == nil {
+			resp.Body.Sessions = []CmcdSession{}
+		}
+		return resp, nil
+	}
+}
+
+func createGetCmcdSessionHdlr(s *Server) func(ctx context.Context, input *cmcdSidInput) (*CmcdSessionResponse, error) {
+	return func(ctx context.Context, input *cmcdSidInput) (*CmcdSessionResponse, error) {
+		if s.cmcdSessions == nil {
+			return nil, huma.Error404NotFound("CMCD collection not enabled")
+		}
+		sess, ok := s.cmcdSessions.Get(input.Sid)
+		if !ok {
+			return nil, huma.Error404NotFound(fmt.Sprintf("no CMCD data for session %q", input.Sid))
+		}
+		resp := &CmcdSessionResponse{}
+		resp.Body.Session = *sess
+		return resp, nil
+	}
+}
+
+func createClearCmcdSessionsHdlr(s *Server) func(ctx context.Context, input *struct{}) (*CmcdClearResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*CmcdClearResponse, error) {
+		resp := &CmcdClearResponse{}
+		if s.cmcdSessions != nil {
+			resp.Body.Cleared = s.cmcdSessions.Clear()
+		}
+		return resp, nil
+	}
+}
+
+func createClearCmcdSessionHdlr(s *Server) func(ctx context.Context, input *cmcdSidInput) (*CmcdClearResponse, error) {
+	return func(ctx context.Context, input *cmcdSidInput) (*CmcdClearResponse, error) {
+		resp := &CmcdClearResponse{}
+		if s.cmcdSessions != nil && s.cmcdSessions.ClearSession(input.Sid) {
+			resp.Body.Cleared = 1
+		}
+		return resp, nil
+	}
+}
+
+// ClockResponse is the OpenAPI response with the state of the virtual clocks.
+type ClockResponse struct {
+	Body struct {
+		Clocks []ClockInfo `json:"clocks" doc:"The global clock first, then the per-stream clocks"`
+	}
+}
+
+// ClockChangeRequest is the OpenAPI request to change a virtual clock.
+type ClockChangeRequest struct {
+	Body ClockChange `json:"body"`
+}
+
+// ClockChangeResponse is the OpenAPI response for a changed virtual clock.
+type ClockChangeResponse struct {
+	Body ClockInfo `json:"body"`
+}
+
+func createGetClockHdlr() func(ctx context.Context, input *struct{}) (*ClockResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*ClockResponse, error) {
+		resp := &ClockResponse{}
+		resp.Body.Clocks = serverClock.Info()
+		return resp, nil
+	}
+}
+
+func createChangeClockHdlr() func(ctx context.Context, input *ClockChangeRequest) (*ClockChangeResponse, error) {
+	return func(ctx context.Context, input *ClockChangeRequest) (*ClockChangeResponse, error) {
+		info, err := serverClock.Apply(input.Body)
+		if err != nil {
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+		return &ClockChangeResponse{Body: info}, nil
+	}
+}
+
+func createResetClockHdlr() func(ctx context.Context, input *struct{}) (*ClockResponse, error) {
+	return func(ctx context.Context, input *struct{}) (*ClockResponse, error) {
+		serverClock.ResetAll()
+		resp := &ClockResponse{}
+		resp.Body.Clocks = serverClock.Info()
+		return resp, nil
+	}
+}
+
 // SteeringSessionResponse is the OpenAPI response for a single content-steering session.
 type SteeringSessionResponse struct {
 	Body struct {
@@ -449,7 +566,14 @@ func createRouteAPI(s *Server) func(r chi.Router) {
 		segment request counts and steering-poll timeline per session (/steering/sessions),
 		drive a CDN switch (/steering/sessions/{sid}/switch), and verify the client's
 		_DASH_pathway/_DASH_throughput steering messages (per-poll issues and a session
-		issueCount), as shown live on the /steering/session_status page.`
+		issueCount), as shown live on the /steering/session_status page.
+
+		The fourth use case is CMCD (CTA-5004): follow the Common Media Client Data sent on
+		MPD and segment requests, and POSTed as v2 event/response reports to /cmcd/report,
+		aggregated per CMCD session id (/cmcd/sessions).
+
+		When the server runs with --clockapi, the virtual clock (/clock) can be frozen, jumped
+		and accelerated, globally or per stream, to test long-running live behavior quickly.`
 
 		api := humachi.New(r, config)
 
@@ -661,5 +785,85 @@ func createRouteAPI(s *Server) func(r chi.Router) {
 			Description: "Remove a content-steering group's shared decision and all of its member sessions, to reset just that group.",
 			Tags:        []string{"ContentSteering"},
 		}, createClearSteeringGroupHdlr(s))
+
+		// Register GET /cmcd/sessions — list active CMCD sessions.
+		huma.Register(api, huma.Operation{
+			OperationID: "list-cmcd-sessions",
+			Method:      http.MethodGet,
+			Path:        "/cmcd/sessions",
+			Summary:     "List active CMCD sessions",
+			//nolint: lll
+			Description: "List the CMCD session ids (sid key) seen on MPD and segment requests or in POSTed v2 reports, most-recently-active first, with per-mode counts, the latest value per key, and a summary of numeric keys such as buffer length (bl) and measured throughput (mtp). Recent payloads are omitted; fetch a single session for those.",
+			Tags:        []string{"CMCD"},
+		}, createListCmcdSessionsHdlr(s))
+
+		// Register POST /cmcd/sessions/clear — wipe all CMCD sessions. POST (not DELETE) for
+		// the same no-CORS-preflight reason as the SGAI clear routes.
+		huma.Register(api, huma.Operation{
+			OperationID: "clear-cmcd-sessions",
+			Method:      http.MethodPost,
+			Path:        "/cmcd/sessions/clear",
+			Summary:     "Clear all CMCD session data",
+			Description: "Remove all aggregated CMCD session data to get a clean slate.",
+			Tags:        []string{"CMCD"},
+		}, createClearCmcdSessionsHdlr(s))
+
+		// Register POST /cmcd/sessions/{sid}/clear — wipe one session.
+		huma.Register(api, huma.Operation{
+			OperationID: "clear-cmcd-session",
+			Method:      http.MethodPost,
+			Path:        "/cmcd/sessions/{sid}/clear",
+			Summary:     "Clear one CMCD session",
+			Description: "Remove the aggregated CMCD data for a single session id.",
+			Tags:        []string{"CMCD"},
+		}, createClearCmcdSessionHdlr(s))
+
+		// Register GET /cmcd/sessions/{sid} — one session's aggregate + recent payloads.
+		huma.Register(api, huma.Operation{
+			OperationID: "get-cmcd-session",
+			Method:      http.MethodGet,
+			Path:        "/cmcd/sessions/{sid}",
+			Summary:     "Get CMCD data for a session",
+			//nolint: lll
+			Description: "Get the aggregated CMCD data for a session id: per-mode counts (request, event, response), the latest value per key, min/max/mean/last of the numeric keys, and the most recent payloads with the requested object.",
+			Tags:        []string{"CMCD"},
+			Errors:      []int{404},
+		}, createGetCmcdSessionHdlr(s))
+
+		if !s.Cfg.ClockAPI {
+			return
+		}
+
+		// Register GET /clock — the state of the virtual clocks.
+		huma.Register(api, huma.Operation{
+			OperationID: "get-clock",
+			Method:      http.MethodGet,
+			Path:        "/clock",
+			Summary:     "Get the virtual clocks",
+			Description: "Get the current virtual time, speed and frozen state of the global clock and of all per-stream clocks.",
+			Tags:        []string{"Clock"},
+		}, createGetClockHdlr())
+
+		// Register POST /clock — freeze, jump, set or speed up the global or a stream clock.
+		huma.Register(api, huma.Operation{
+			OperationID: "change-clock",
+			Method:      http.MethodPost,
+			Path:        "/clock",
+			Summary:     "Change a virtual clock",
+			//nolint: lll
+			Description: "Change the global clock (no stream) or the clock of one stream (asset path, e.g. testpic_2s): reset, set to a time, jump forward or back, run at N× speed, or freeze/resume. The clock is used for all MPD and segment requests without an explicit nowMS/nowDate query.",
+			Tags:        []string{"Clock"},
+			Errors:      []int{400},
+		}, createChangeClockHdlr())
+
+		// Register POST /clock/reset — all clocks follow the wall clock again.
+		huma.Register(api, huma.Operation{
+			OperationID: "reset-clock",
+			Method:      http.MethodPost,
+			Path:        "/clock/reset",
+			Summary:     "Reset all virtual clocks",
+			Description: "Remove all virtual clock changes so that the server follows the wall clock again.",
+			Tags:        []string{"Clock"},
+		}, createResetClockHdlr())
 	}
 }
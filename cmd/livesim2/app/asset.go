@@ -20,6 +20,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Dash-Industry-Forum/livesim2/internal"
 	m "github.com/Eyevinn/dash-mpd/mpd"
@@ -40,10 +41,14 @@ func newAssetMgr(vodFS fs.FS, repDataDir string, writeRepData bool, writeMissing
 
 type assetMgr struct {
 	vodFS               fs.FS
-	assets              map[string]*asset // the key is the asset path
+	assets              map[string]*asset   // the key is the asset path
+	channels            map[string]*channel // the key is the channel path
 	repDataDir          string
 	writeRepData        bool
 	writeMissingRepData bool
+	// genMu protects genAssets, the generated assets (gen_) created on request
+	genMu     sync.Mutex
+	genAssets map[string]*asset
 }
 
 // findAsset finds the asset by matching the uri with all assets paths.
@@ -53,7 +58,7 @@ func (am *assetMgr) findAsset(uri string) (*asset, bool) {
 			return am.assets[assetPath], true
 		}
 	}
-	return nil, false
+	return am.findGenAsset(uri)
 }
 
 // addAsset adds or retrieves an asset.
@@ -94,7 +99,7 @@ func (am *assetMgr) discoverAssets(logger *slog.Logger) error {
 		}
 		logger.Info("Asset consolidated", "loopDurMS", a.LoopDurMS)
 	}
-	return nil
+	return am.loadChannels(logger)
 }
 
 func (am *assetMgr) loadAsset(logger *slog.Logger, mpdPath string) error {
@@ -385,6 +390,20 @@ func (rp *RepData) loadFromJSON(logger *slog.Logger, vodFS fs.FS, repDataDir, as
 }
 
 func (rp *RepData) addRegExpAndInit(logger *slog.Logger, vodFS fs.FS, assetPath string) error {
+	if err := rp.addRegExp(); err != nil {
+		return err
+	}
+	if rp.ContentType != "image" {
+		err := rp.readInit(logger, vodFS, assetPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRegExp sets the regular expression matching the media segments.
+func (rp *RepData) addRegExp() error {
 	switch {
 	case strings.Contains(rp.MediaURI, "$Number$"):
 		rexStr := strings.ReplaceAll(rp.MediaURI, "$Number$", `(\d+)`)
@@ -397,13 +416,6 @@ func (rp *RepData) addRegExpAndInit(logger *slog.Logger, vodFS fs.FS, assetPath
 	default:
 		return fmt.Errorf("neither $Number$, nor $Time$ found in media")
 	}
-
-	if rp.ContentType != "image" {
-		err := rp.readInit(logger, vodFS, assetPath)
-		if err != nil {
-			return err
-		}
-	}
 	return nil
 }
 
@@ -459,6 +471,12 @@ type asset struct {
 	LoopDurMS    int                         `json:"loopDurationMS"`
 	Reps         map[string]*RepData         `json:"representations"`
 	refRep       *RepData                    `json:"-"` // First video or audio representation
+	// variantMu protects the lazily built segdurs_ data below
+	variantMu      sync.Mutex
+	syncTimes      []uint64          // decode times of the sync samples of refRep
+	segDurVariants map[string]*asset // variant assets with regrouped segments
+	maxSegDurMS    int               // set for variant assets, where the VoD maxSegmentDuration does not hold
+	gen            *genVideo         // set for generated assets (gen_) without VoD content
 }
 
 func newAsset(assetPath string) *asset {
@@ -914,6 +932,7 @@ type RepData struct {
 	initSeg                *mp4.InitSegment `json:"-"`
 	initBytes              []byte           `json:"-"`
 	encData                *repEncData      `json:"-"`
+	srcSegments            []Segment        `json:"-"` // VoD segments when Segments are regrouped (segdurs_)
 }
 
 type repEncData struct {
@@ -1005,6 +1024,12 @@ func (r *RepData) readInit(logger *slog.Logger, vodFS fs.FS, assetPath string) e
 	if err != nil {
 		return fmt.Errorf("read initURI %q: %w", r.InitURI, err)
 	}
+	return r.setInit(logger, rawInit, assetPath)
+}
+
+// setInit sets the init segment data of the representation from rawInit.
+func (r *RepData) setInit(logger *slog.Logger, rawInit []byte, assetPath string) error {
+	var err error
 	r.initSeg, err = getInitSeg(rawInit)
 	if err != nil {
 		return fmt.Errorf("decode init: %w", err)
@@ -44,6 +44,13 @@ func calcAudioSegRecipe(refNr uint32, refStart, refEnd, refTotalDur, refTimescal
 	return audioRecipe{rd, refNr, audioStart, audioEnd, audioInStart, audioInStart + (audioEnd - audioStart), 0}
 }
 
+// shiftTo moves the output times of the recipe to start at startTime.
+// The input sample intervals, including any wrap, are unchanged.
+func (r *audioRecipe) shiftTo(startTime uint64) {
+	r.endTime = startTime + r.endTime - r.startTime
+	r.startTime = startTime
+}
+
 // calcAudioTimeFromRef returns audioTime right at or within one frameDur from refTime.
 // A frame is one mp4 sample, such as an AAC frame which is normally 1024 audio samples.
 func calcAudioTimeFromRef(refTime, refTimescale, audioFrameDur, audioTimescale uint64) uint64 {
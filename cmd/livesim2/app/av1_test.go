@@ -29,7 +29,7 @@ func genAV1Segment(t *testing.T, vodFS fs.FS, am *assetMgr, nr int) (segOut, *Re
 
 	cfg := NewResponseConfig()
 	media := strings.ReplaceAll("av1/$NrOrTime$.m4s", "$NrOrTime$", strconv.Itoa(nr))
-	so, err := genLiveSegment(slog.Default(), vodFS, asset, cfg, media, 100_000, false)
+	so, err := genLiveSegment(slog.Default(), vodFS, asset, cfg, nil, media, 100_000, false)
 	require.NoError(t, err)
 	require.NotNil(t, so.seg)
 	require.NotEmpty(t, so.seg.Fragments)
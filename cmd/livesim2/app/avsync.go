@@ -0,0 +1,204 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+)
+
+// Audio/video synchronization offset. With avsync_, the audio timestamps are shifted
+// relative to the video, so that audio is presented OffsetMS later (or earlier if negative)
+// than the video it was encoded with. The shift is applied to the audio tfdt and the audio
+// SegmentTimeline, so the MPD and the segments stay consistent. A negative offset is lifted
+// by an audio presentationTimeOffset so that no media time becomes negative.
+// A drift adds to the offset over time, in steps of whole audio frames, as an encoder
+// that drops or repeats an audio frame now and then.
+
+const (
+	maxAVSyncOffsetMS    = 10_000
+	maxAVSyncDriftMSPerH = 60_000
+)
+
+// AVSyncConfig is the audio offset relative to video.
+type AVSyncConfig struct {
+	OffsetMS       int `json:"offsetMS"`
+	DriftMSPerHour int `json:"driftMSPerHour,omitempty"`
+}
+
+// CreateAVSyncConfig parses <ms>[;drift=<ms per hour>].
+func CreateAVSyncConfig(val string) (*AVSyncConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty avsync config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("avsync config %q has extra spaces", val)
+	}
+	parts := strings.Split(val, ";")
+	offset, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("avsync offset %q: %w", parts[0], err)
+	}
+	if offset < -maxAVSyncOffsetMS || offset > maxAVSyncOffsetMS {
+		return nil, fmt.Errorf("avsync offset %dms must be in [-%d, %d]ms", offset, maxAVSyncOffsetMS, maxAVSyncOffsetMS)
+	}
+	cfg := &AVSyncConfig{OffsetMS: offset}
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("avsync param %q must be key=val", kv)
+		}
+		switch key {
+		case "drift":
+			drift, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("avsync drift %q: %w", v, err)
+			}
+			if drift < -maxAVSyncDriftMSPerH || drift > maxAVSyncDriftMSPerH {
+				return nil, fmt.Errorf("avsync drift %dms/h must be in [-%d, %d]ms/h",
+					drift, maxAVSyncDriftMSPerH, maxAVSyncDriftMSPerH)
+			}
+			cfg.DriftMSPerHour = drift
+		default:
+			return nil, fmt.Errorf("unknown avsync param %q", key)
+		}
+	}
+	return cfg, nil
+}
+
+// ParseAVSyncConfig parses an avsync option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseAVSyncConfig(key, val string) *AVSyncConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreateAVSyncConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// baseTicks returns the constant part of the offset in timescale ticks.
+func (c *AVSyncConfig) baseTicks(timescale uint32) int64 {
+	return int64(math.Round(float64(c.OffsetMS) * float64(timescale) / 1000))
+}
+
+// ptoTicks returns the audio presentationTimeOffset needed to keep the shifted times non-negative.
+func (c *AVSyncConfig) ptoTicks(timescale uint32) uint64 {
+	if base := c.baseTicks(timescale); base < 0 {
+		return uint64(-base)
+	}
+	return 0
+}
+
+// offsetTicks returns the offset for audio at contentTime (relative to availabilityStartTime).
+// The drift part is rounded to whole audio frames.
+func (c *AVSyncConfig) offsetTicks(contentTime uint64, timescale, frameDur uint32) int64 {
+	offset := c.baseTicks(timescale)
+	if c.DriftMSPerHour != 0 {
+		driftTicks := float64(c.DriftMSPerHour) * float64(contentTime) / 3_600_000
+		offset += int64(math.Round(driftTicks/float64(frameDur))) * int64(frameDur)
+	}
+	return offset
+}
+
+// shiftedTime returns the audio media time for contentTime, including the presentationTimeOffset.
+func (c *AVSyncConfig) shiftedTime(contentTime uint64, timescale, frameDur uint32) uint64 {
+	offset := c.offsetTicks(contentTime, timescale, frameDur) + int64(c.ptoTicks(timescale))
+	return uint64(int64(contentTime) + offset)
+}
+
+// contentTime maps a shifted audio media time back to the unshifted time.
+// Since the drift is at most 1/60 of the elapsed time, the iteration quickly finds the fixed point.
+func (c *AVSyncConfig) contentTime(shiftedTime uint64, timescale, frameDur uint32) (uint64, error) {
+	t := int64(shiftedTime) - int64(c.ptoTicks(timescale))
+	ct := t - c.baseTicks(timescale)
+	for range 16 {
+		if ct < 0 {
+			break
+		}
+		next := t - c.offsetTicks(uint64(ct), timescale, frameDur)
+		if next == ct {
+			break
+		}
+		ct = next
+	}
+	if ct < 0 {
+		return 0, fmt.Errorf("avsync: time %d before start", shiftedTime)
+	}
+	return uint64(ct), nil
+}
+
+// shiftEntries shifts the audio timeline entries. A changing drift offset results in
+// an S element with an explicit t value.
+func (c *AVSyncConfig) shiftEntries(se segEntries, frameDur uint32) segEntries {
+	if len(se.entries) == 0 {
+		return se
+	}
+	entries := make([]*m.S, 0, len(se.entries))
+	var t, nextT uint64
+	for _, e := range se.entries {
+		if e.T != nil {
+			t = *e.T
+		}
+		for range e.R + 1 {
+			st := c.shiftedTime(t, se.mediaTimescale, frameDur)
+			n := len(entries)
+			switch {
+			case n > 0 && st == nextT && entries[n-1].D == e.D && sameK(entries[n-1].K, e.K):
+				entries[n-1].R++
+			case n > 0 && st == nextT:
+				s := *e
+				s.T, s.R = nil, 0
+				entries = append(entries, &s)
+			default:
+				s := *e
+				s.T, s.R = Ptr(st), 0
+				entries = append(entries, &s)
+			}
+			nextT = st + e.D
+			t += e.D
+		}
+	}
+	se.entries = entries
+	return se
+}
+
+// sameK returns true if two S@k values are equal.
+func sameK(k1, k2 *uint64) bool {
+	if k1 == nil || k2 == nil {
+		return k1 == k2
+	}
+	return *k1 == *k2
+}
+
+// audioFrameDur returns the duration of an audio frame (mp4 sample) of rep.
+func audioFrameDur(rep *RepData) uint32 {
+	if rep.ConstantSampleDuration != nil && *rep.ConstantSampleDuration != 0 {
+		return *rep.ConstantSampleDuration
+	}
+	return rep.sampleDur()
+}
+
+// checkAVSyncAsset checks that the audio of a can be shifted relative to its video.
+func checkAVSyncAsset(a *asset) error {
+	if a.refRep.ContentType != "video" {
+		return fmt.Errorf("avsync needs a video reference representation")
+	}
+	for _, rep := range a.Reps {
+		if rep.ContentType != "audio" {
+			continue
+		}
+		if rep.PreEncrypted || rep.EditListOffset > 0 || audioFrameDur(rep) == 0 {
+			return fmt.Errorf("avsync does not support audio representation %s", rep.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateAVSyncConfig(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    *AVSyncConfig
+		wantErr string
+	}{
+		{val: "40", want: &AVSyncConfig{OffsetMS: 40}},
+		{val: "-120;drift=600", want: &AVSyncConfig{OffsetMS: -120, DriftMSPerHour: 600}},
+		{val: "0;drift=-60", want: &AVSyncConfig{OffsetMS: 0, DriftMSPerHour: -60}},
+		{val: "", wantErr: "empty avsync config"},
+		{val: "x", wantErr: "avsync offset"},
+		{val: "20000", wantErr: "must be in"},
+		{val: "40;drift=100000", wantErr: "must be in"},
+		{val: "40;drift=y", wantErr: "avsync drift"},
+		{val: "40;seed=1", wantErr: "unknown avsync param"},
+		{val: "40;drift", wantErr: "must be key=val"},
+		{val: "40; drift=1", wantErr: "extra spaces"},
+	}
+	for _, c := range cases {
+		got, err := CreateAVSyncConfig(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestAVSyncTimes(t *testing.T) {
+	c := AVSyncConfig{OffsetMS: 30}
+	assert.Equal(t, uint64(0), c.ptoTicks(48000))
+	assert.Equal(t, uint64(4801952), c.shiftedTime(4800512, 48000, 1024))
+	c = AVSyncConfig{OffsetMS: -30}
+	assert.Equal(t, uint64(1440), c.ptoTicks(48000))
+	assert.Equal(t, uint64(4800512), c.shiftedTime(4800512, 48000, 1024), "lifted by the PTO")
+
+	// 3600ms/h is 4.69 frames after 100s, rounded to 5 frames.
+	c = AVSyncConfig{DriftMSPerHour: 3600}
+	assert.Equal(t, uint64(4800512+5*1024), c.shiftedTime(4800512, 48000, 1024))
+	ct, err := c.contentTime(4800512+5*1024, 48000, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4800512), ct)
+	c = AVSyncConfig{OffsetMS: -30, DriftMSPerHour: -60_000}
+	for _, ct := range []uint64{0, 96256, 4800512, 172_800_000_512} {
+		st := c.shiftedTime(ct, 48000, 1024)
+		got, err := c.contentTime(st, 48000, 1024)
+		require.NoError(t, err)
+		assert.Equal(t, ct, got)
+	}
+
+	// Drifting timelines get explicit t values where the offset changes.
+	c = AVSyncConfig{DriftMSPerHour: 60_000}
+	se := segEntries{entries: []*m.S{{T: Ptr(uint64(0)), D: 96256, R: 9}}, mediaTimescale: 48000}
+	out := c.shiftEntries(se, 1024)
+	assert.Greater(t, len(out.entries), 1)
+	var times []uint64
+	var st uint64
+	for _, s := range out.entries {
+		if s.T != nil {
+			st = *s.T
+		}
+		for range s.R + 1 {
+			times = append(times, st)
+			st += s.D
+		}
+	}
+	require.Len(t, times, 10)
+	for i, st := range times {
+		assert.Equal(t, c.shiftedTime(uint64(i)*96256, 48000, 1024), st)
+	}
+}
+
+func TestAVSyncStream(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	audioTimeline := func(t *testing.T, url string) (*m.SegmentTemplateType, uint64) {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		aAS := mpd.Periods[0].AdaptationSets[0]
+		require.Equal(t, "audio", string(aAS.ContentType))
+		stl := aAS.SegmentTemplate.SegmentTimeline
+		return aAS.SegmentTemplate, *stl.S[0].T
+	}
+	_, t0 := audioTimeline(t, "/livesim2/segtimeline_1/testpic_2s/Manifest.mpd?nowMS=100000")
+	stpl, t0Shift := audioTimeline(t, "/livesim2/segtimeline_1/avsync_30/testpic_2s/Manifest.mpd?nowMS=100000")
+	assert.Equal(t, t0+1440, t0Shift)
+	assert.Nil(t, stpl.PresentationTimeOffset)
+	stpl, t0Shift = audioTimeline(t, "/livesim2/segtimeline_1/avsync_-30/testpic_2s/Manifest.mpd?nowMS=100000")
+	assert.Equal(t, t0, t0Shift)
+	assert.Equal(t, uint64(1440), *stpl.PresentationTimeOffset)
+
+	cases := []struct {
+		url      string
+		wantTfdt uint64
+	}{
+		{fmt.Sprintf("/livesim2/segtimeline_1/avsync_30/testpic_2s/A48/%d.m4s?nowMS=100000", t0+1440), t0 + 1440},
+		{"/livesim2/segtimeline_1/avsync_30/testpic_2s/A48/4801952.m4s?nowMS=103000", 4801952},
+		{"/livesim2/segtimelinenr_1/avsync_30/testpic_2s/A48/50.m4s?nowMS=103000", 4801952},
+		{"/livesim2/segtimeline_1/avsync_-30/testpic_2s/A48/4800512.m4s?nowMS=103000", 4800512},
+		{"/livesim2/segtimeline_1/avsync_30/testpic_2s/V300/9000000.m4s?nowMS=103000", 9000000},
+	}
+	for _, c := range cases {
+		resp, body := testFullRequest(t, ts, "GET", c.url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, c.url)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Len(t, f.Segments, 1)
+		assert.Equal(t, c.wantTfdt, f.Segments[0].Fragments[0].Moof.Traf.Tfdt.BaseMediaDecodeTime(), c.url)
+	}
+
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/avsync_30/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "needs SegmentTimeline")
+}
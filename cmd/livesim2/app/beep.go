@@ -0,0 +1,199 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/bits"
+	"github.com/Eyevinn/mp4ff/mp4"
+)
+
+// Beep audio (beep_) is a generated mono LPCM audio track (ISO/IEC 23003-5 ipcm) in an
+// AdaptationSet of its own, with a 100ms tone starting at every full wall-clock second
+// and silence in between. Together with a video clock, such as that of the gen_ assets,
+// the beeps make it possible to measure AV sync and latency automatically. The segments
+// follow the video segments as other audio does, so the frame-aligned segment times and
+// numbers are calculated as in calcAudioSegRecipe, and an avsync_ offset applies to them.
+
+const (
+	BeepRepID          = "beep"
+	beepASId           = 500
+	beepTimescale      = 48000
+	beepFrameSamples   = 1024 // PCM samples per MP4 sample
+	beepSampleBytes    = 2    // 16-bit big-endian samples
+	beepDurSamples     = beepTimescale / 10
+	beepAmplitude      = 0.5 * math.MaxInt16
+	beepMinHz          = 100
+	beepMaxHz          = 10000
+	beepCodecs         = "ipcm"
+	beepBitsPerSecond  = beepTimescale * beepSampleBytes * 8
+	channelConfigCICP  = "urn:mpeg:mpegB:cicp:ChannelConfiguration"
+	beepChannelsCICP   = 1 // mono, also the chnl definedLayout
+	beepPcmCFormatBE   = 0 // format_flags of pcmC for big-endian samples
+	beepStreamChannels = 1 // chnl stream_structure channelStructured
+)
+
+// checkBeepHz checks that the tone has a whole number of periods per beep.
+func checkBeepHz(hz int) error {
+	if hz < beepMinHz || hz > beepMaxHz || hz%10 != 0 {
+		return fmt.Errorf("beep frequency %dHz must be a multiple of 10 in [%d, %d]", hz, beepMinHz, beepMaxHz)
+	}
+	return nil
+}
+
+// beepRep returns the Representation data of the beep track, for the audio segment recipe.
+func beepRep() *RepData {
+	return &RepData{
+		ID:                     BeepRepID,
+		ContentType:            "audio",
+		Codecs:                 beepCodecs,
+		MpdTimescale:           beepTimescale,
+		MediaTimescale:         beepTimescale,
+		ConstantSampleDuration: Ptr(uint32(beepFrameSamples)),
+	}
+}
+
+// createBeepInitSegment returns the init segment of the beep track.
+func createBeepInitSegment() (*mp4.InitSegment, error) {
+	init := mp4.CreateEmptyInit()
+	trak := init.AddEmptyTrack(beepTimescale, "audio", "und")
+	ase := mp4.CreateAudioSampleEntryBox(beepCodecs, 1, 8*beepSampleBytes, beepTimescale, nil)
+	// pcmC and chnl are not known by mp4ff, so they are built from their bytes.
+	pcmC := []byte{0, 0, 0, 14, 'p', 'c', 'm', 'C', 0, 0, 0, 0, beepPcmCFormatBE, 8 * beepSampleBytes}
+	chnl := []byte{0, 0, 0, 22, 'c', 'h', 'n', 'l', 0, 0, 0, 0, beepStreamChannels, beepChannelsCICP,
+		0, 0, 0, 0, 0, 0, 0, 0} // omitted_channels_map
+	for _, data := range [][]byte{pcmC, chnl} {
+		box, err := mp4.DecodeBoxSR(0, bits.NewFixedSliceReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", data[4:8], err)
+		}
+		ase.AddChild(box)
+	}
+	trak.Mdia.Minf.Stbl.Stsd.AddChild(ase)
+	init.Moov.Mvex.Trex.DefaultSampleDuration = beepFrameSamples
+	return init, nil
+}
+
+// beepSampleData returns the PCM data of the MP4 sample starting at media time t. The beeps
+// start at every full second, since the media time 0 is at a full wall-clock second.
+func beepSampleData(hz int, t uint64) []byte {
+	data := make([]byte, beepSampleBytes*beepFrameSamples)
+	for i := range beepFrameSamples {
+		pos := (t + uint64(i)) % beepTimescale
+		if pos >= beepDurSamples {
+			continue
+		}
+		v := int16(beepAmplitude * math.Sin(2*math.Pi*float64(hz)*float64(pos)/beepTimescale))
+		binary.BigEndian.PutUint16(data[beepSampleBytes*i:], uint16(v))
+	}
+	return data
+}
+
+// createBeepMediaSegment returns the beep segment nr from startTime to endTime.
+func createBeepMediaSegment(hz int, nr uint32, startTime, endTime uint64) (*mp4.MediaSegment, error) {
+	seg := mp4.NewMediaSegment()
+	frag, err := mp4.CreateFragment(nr, 1)
+	if err != nil {
+		return nil, err
+	}
+	seg.AddFragment(frag)
+	for t := startTime; t < endTime; t += beepFrameSamples {
+		data := beepSampleData(hz, t)
+		frag.AddFullSample(mp4.FullSample{
+			Sample:     mp4.Sample{Flags: mp4.SyncSampleFlags, Dur: beepFrameSamples, Size: uint32(len(data))},
+			DecodeTime: t,
+			Data:       data,
+		})
+	}
+	return seg, nil
+}
+
+// beepSegmentPart returns the segment (init or number) of a beep track URL.
+func beepSegmentPart(cfg *ResponseConfig, segmentPart string) (string, bool) {
+	if cfg.BeepHz == nil {
+		return "", false
+	}
+	seg, ok := strings.CutPrefix(segmentPart, BeepRepID+"/")
+	if !ok {
+		return "", false
+	}
+	if seg == "init.mp4" {
+		return "init", true
+	}
+	return strings.CutSuffix(seg, ".m4s")
+}
+
+// writeBeepSegment returns true and tries to write a beep track segment if the URL matches.
+func writeBeepSegment(w http.ResponseWriter, cfg *ResponseConfig, a *asset, segmentPart string, nowMS int,
+	isLast bool) (bool, error) {
+	seg, ok := beepSegmentPart(cfg, segmentPart)
+	if !ok {
+		return false, nil
+	}
+	var box encodableBox
+	if seg == "init" {
+		init, err := createBeepInitSegment()
+		if err != nil {
+			return true, fmt.Errorf("createBeepInitSegment: %w", err)
+		}
+		box = init
+	} else {
+		nr, err := strconv.ParseUint(seg, 10, 32)
+		if err != nil {
+			return true, fmt.Errorf("bad seg nr %s: %w", seg, errNotFound)
+		}
+		if uint32(nr) < cfg.getStartNr() {
+			return true, errNotFound
+		}
+		ref, err := findSegMetaFromNr(a, a.refRep, uint32(nr), cfg, nowMS)
+		if err != nil {
+			return true, fmt.Errorf("findSegMetaFromNr: %w", err)
+		}
+		rep := beepRep()
+		recipe := calcAudioSegRecipe(ref.newNr, ref.newTime, ref.newTime+uint64(ref.newDur),
+			uint64(a.refRep.duration()), uint64(ref.timescale), rep)
+		if cfg.AVSync != nil {
+			recipe.shiftTo(cfg.AVSync.shiftedTime(recipe.startTime, beepTimescale, beepFrameSamples))
+		}
+		mediaSeg, err := createBeepMediaSegment(*cfg.BeepHz, recipe.segNr, recipe.startTime, recipe.endTime)
+		if err != nil {
+			return true, fmt.Errorf("createBeepMediaSegment: %w", err)
+		}
+		if isLast {
+			mediaSeg.Styp.AddCompatibleBrands([]string{"lmsg"})
+		}
+		box = mediaSeg
+	}
+	return true, writeBox(w, "audio/mp4", box)
+}
+
+// addBeepAdaptationSet adds the AdaptationSet of the beep track.
+func addBeepAdaptationSet(cfg *ResponseConfig, a *asset, period *m.Period) {
+	as := m.NewAdaptationSetWithParams("audio", "audio/mp4", true, 1)
+	as.Id = Ptr(uint32(beepASId))
+	as.Codecs = beepCodecs
+	as.Lang = "und"
+	as.AudioChannelConfigurations = append(as.AudioChannelConfigurations, &m.DescriptorType{
+		SchemeIdUri: channelConfigCICP,
+		Value:       strconv.Itoa(beepChannelsCICP),
+	})
+	st := m.NewSegmentTemplate()
+	st.Initialization = "$RepresentationID$/init.mp4"
+	st.Media = "$RepresentationID$/$Number$.m4s"
+	st.SetTimescale(beepTimescale)
+	st.Duration = Ptr(uint32(a.SegmentDurMS * beepTimescale / 1000))
+	st.StartNumber = Ptr(cfg.getStartNr())
+	as.SegmentTemplate = st
+	rep := m.NewAudioRepresentation(BeepRepID, "", "", beepBitsPerSecond, beepTimescale)
+	as.AppendRepresentation(rep)
+	period.AppendAdaptationSet(as)
+}
@@ -0,0 +1,93 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestBeepSampleData(t *testing.T) {
+	// A beep starts at every full second
+	data := beepSampleData(1000, 3*beepTimescale)
+	require.Len(t, data, beepSampleBytes*beepFrameSamples)
+	assert.Equal(t, int16(0), int16(binary.BigEndian.Uint16(data[0:])))
+	// A quarter period of 1000Hz is 12 samples
+	assert.Equal(t, int16(16383), int16(binary.BigEndian.Uint16(data[beepSampleBytes*12:])))
+	// After 100ms, there is silence
+	assert.Equal(t, make([]byte, len(data)), beepSampleData(1000, 3*beepTimescale+beepDurSamples))
+	assert.Error(t, checkBeepHz(1005))
+	assert.Error(t, checkBeepHz(20000))
+}
+
+func TestBeep(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	for _, asset := range []string{"testpic_2s", "gen_360p25"} {
+		url := "/livesim2/beep_1000/" + asset + "/"
+		resp, body := testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		p := mpd.Periods[0]
+		bAS := p.AdaptationSets[len(p.AdaptationSets)-1]
+		assert.Equal(t, uint32(beepASId), *bAS.Id)
+		assert.Equal(t, "audio", string(bAS.ContentType))
+		assert.Equal(t, beepCodecs, bAS.Codecs)
+		assert.Equal(t, "$RepresentationID$/$Number$.m4s", bAS.SegmentTemplate.Media)
+		require.Len(t, bAS.Representations, 1)
+		assert.Equal(t, BeepRepID, bAS.Representations[0].Id)
+
+		resp, body = testFullRequest(t, ts, "GET", url+"beep/init.mp4?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		require.NotNil(t, f.Init)
+		assert.Equal(t, uint32(beepTimescale), f.Init.Moov.Trak.Mdia.Mdhd.Timescale)
+		assert.Equal(t, beepCodecs, f.Init.Moov.Trak.Mdia.Minf.Stbl.Stsd.Children[0].Type())
+
+		resp, body = testFullRequest(t, ts, "GET", url+"beep/20.m4s?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		f, err = mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Len(t, f.Segments, 1)
+		frag := f.Segments[0].Fragments[0]
+		assert.Equal(t, uint32(20), frag.Moof.Mfhd.SequenceNumber)
+		// The segment starts at 40s, and ends at the first frame start at or after 42s.
+		assert.Equal(t, uint64(40*beepTimescale), frag.Moof.Traf.Tfdt.BaseMediaDecodeTime())
+		samples, err := frag.GetFullSamples(nil)
+		require.NoError(t, err)
+		require.Len(t, samples, (42*beepTimescale+beepFrameSamples-1)/beepFrameSamples-40*beepTimescale/beepFrameSamples)
+		assert.Equal(t, beepSampleData(1000, 40*beepTimescale), samples[0].Data)
+
+		// Segments in the future are too early
+		resp, _ = testFullRequest(t, ts, "GET", url+"beep/20.m4s?nowMS=30000", nil)
+		assert.Equal(t, http.StatusTooEarly, resp.StatusCode, url)
+	}
+
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/beep_1005/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
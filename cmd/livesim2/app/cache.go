@@ -0,0 +1,43 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import "sync"
+
+// boundedCache is a concurrency-safe map with at most max entries. When full, the oldest
+// entry is evicted first.
+type boundedCache[V any] struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]V
+	keys    []string
+}
+
+func newBoundedCache[V any](max int) *boundedCache[V] {
+	return &boundedCache[V]{max: max, entries: make(map[string]V)}
+}
+
+// get returns the value of key, if present.
+func (c *boundedCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+// add sets the value of key, unless it is already present.
+func (c *boundedCache[V]) add(key string, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if len(c.keys) == c.max {
+		delete(c.entries, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+	c.entries[key] = v
+	c.keys = append(c.keys, key)
+}
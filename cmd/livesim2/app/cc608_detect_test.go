@@ -113,11 +113,11 @@ func TestGenLiveSegmentCC608RejectCaptioned(t *testing.T) {
 
 	cfg := NewResponseConfig()
 	cfg.CC608 = &CC608Config{Channel: "CC1", Lang: "eng"}
-	_, err := genLiveSegment(logger, vodFS, asset, cfg, "V300_with_cc1_and_cc3/40.m4s", nowMS, false)
+	_, err := genLiveSegment(logger, vodFS, asset, cfg, nil, "V300_with_cc1_and_cc3/40.m4s", nowMS, false)
 	require.ErrorIs(t, err, errCC608AlreadyCaptioned)
 
 	// Plain rep of the same asset is injected, not rejected.
-	_, err = genLiveSegment(logger, vodFS, asset, cfg, "V300/40.m4s", nowMS, false)
+	_, err = genLiveSegment(logger, vodFS, asset, cfg, nil, "V300/40.m4s", nowMS, false)
 	require.NoError(t, err)
 }
 
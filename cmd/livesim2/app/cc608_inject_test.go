@@ -190,7 +190,7 @@ func TestGenLiveSegmentCC608(t *testing.T) {
 	const nowMS = 100_000
 	const nr = 40
 
-	so, err := genLiveSegment(logger, vodFS, asset, cfg, fmt.Sprintf("V300/%d.m4s", nr), nowMS, false)
+	so, err := genLiveSegment(logger, vodFS, asset, cfg, nil, fmt.Sprintf("V300/%d.m4s", nr), nowMS, false)
 	require.NoError(t, err)
 	require.Equal(t, "video/mp4", so.meta.rep.SegmentType())
 
@@ -247,7 +247,7 @@ func TestGenLiveSegmentCC608HEVC(t *testing.T) {
 	const nowMS = 100_000
 	const nr = 40 // 2s segments -> segment 40 starts at 80s = 00:01:20
 
-	so, err := genLiveSegment(logger, vodFS, asset, cfg, fmt.Sprintf("video_%d.m4s", nr), nowMS, false)
+	so, err := genLiveSegment(logger, vodFS, asset, cfg, nil, fmt.Sprintf("video_%d.m4s", nr), nowMS, false)
 	require.NoError(t, err)
 	require.Equal(t, "video/mp4", so.meta.rep.SegmentType())
 	codec, ok := cc608CodecFor(so.meta.rep.Codecs)
@@ -346,7 +346,7 @@ func TestGenLiveSegmentCC608_2997fps(t *testing.T) {
 	const nowMS = 100_000
 	const nr = 40
 
-	so, err := genLiveSegment(logger, vodFS, asset, cfg, fmt.Sprintf("video/avc1/seg-%d.m4s", nr), nowMS, false)
+	so, err := genLiveSegment(logger, vodFS, asset, cfg, nil, fmt.Sprintf("video/avc1/seg-%d.m4s", nr), nowMS, false)
 	require.NoError(t, err)
 	require.Equal(t, "video/mp4", so.meta.rep.SegmentType())
 	require.EqualValues(t, 30000, so.meta.rep.MediaTimescale)
@@ -391,9 +391,9 @@ func TestGenLiveSegmentCC608AudioUnchanged(t *testing.T) {
 	withCC := NewResponseConfig()
 	withCC.CC608 = &CC608Config{Channel: "CC1", Lang: "eng"}
 
-	soPlain, err := genLiveSegment(logger, vodFS, asset, plain, media, nowMS, false)
+	soPlain, err := genLiveSegment(logger, vodFS, asset, plain, nil, media, nowMS, false)
 	require.NoError(t, err)
-	soCC, err := genLiveSegment(logger, vodFS, asset, withCC, media, nowMS, false)
+	soCC, err := genLiveSegment(logger, vodFS, asset, withCC, nil, media, nowMS, false)
 	require.NoError(t, err)
 	require.Equal(t, "audio/mp4", soCC.meta.rep.SegmentType())
 	require.Equal(t, soPlain.seg.Size(), soCC.seg.Size(), "audio segment must be unaffected by timecc608")
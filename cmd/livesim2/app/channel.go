@@ -0,0 +1,303 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/drm"
+	m "github.com/Eyevinn/dash-mpd/mpd"
+)
+
+// Linear channels. A channel is a JSON playlist in the channels directory of the vodroot,
+// channels/<name>.json, that schedules different assets one after the other:
+//
+//	{"entries": [{"asset": "testpic_2s", "durS": 600}, {"asset": "bbb_hevc_ac3_8s", "durS": 120}]}
+//
+// The schedule loops by wall-clock from availabilityStartTime. Every scheduled entry becomes a
+// Period, generated as a live stream of its asset with availabilityStartTime at the Period
+// start, so each Period has the codecs, timescales and AdaptationSets of its asset.
+// The Periods get absolute BaseURLs with a chp_<k> path token, where k counts the Periods
+// since availabilityStartTime, so that segment requests can be mapped to the right asset.
+// The channel is requested as /livesim2/<options>/channels/<name>/<any>.mpd.
+
+const (
+	channelsDir        = "channels"
+	channelTokenPrefix = "chp_"
+)
+
+// channelEntry is one asset in the schedule of a channel.
+type channelEntry struct {
+	AssetPath string `json:"asset"`
+	MPDName   string `json:"mpd,omitempty"` // needed if the asset has more than one MPD
+	DurS      int    `json:"durS"`
+	a         *asset
+}
+
+// channel is a looping schedule of assets.
+type channel struct {
+	Path    string          `json:"-"` // channels/<name>
+	Entries []*channelEntry `json:"entries"`
+	cycleS  int
+}
+
+// loadChannels reads the channel playlists and resolves their assets.
+// Must be called after the assets have been consolidated.
+func (am *assetMgr) loadChannels(logger *slog.Logger) error {
+	am.channels = make(map[string]*channel)
+	files, err := fs.ReadDir(am.vodFS, channelsDir)
+	if err != nil {
+		return nil // no channels
+	}
+	for _, f := range files {
+		if f.IsDir() || path.Ext(f.Name()) != ".json" {
+			continue
+		}
+		p := path.Join(channelsDir, f.Name())
+		ch, err := am.loadChannel(p)
+		if err != nil {
+			logger.Warn("Channel loading problem. Skipping", "channel", p, "err", err.Error())
+			continue
+		}
+		am.channels[ch.Path] = ch
+		logger.Info("Channel loaded", "channel", ch.Path, "cycleS", ch.cycleS)
+	}
+	return nil
+}
+
+// loadChannel reads and checks a channel playlist.
+func (am *assetMgr) loadChannel(filePath string) (*channel, error) {
+	data, err := fs.ReadFile(am.vodFS, filePath)
+	if err != nil {
+		return nil, err
+	}
+	ch := &channel{Path: strings.TrimSuffix(filePath, ".json")}
+	if err := json.Unmarshal(data, ch); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	if len(ch.Entries) == 0 {
+		return nil, fmt.Errorf("no entries")
+	}
+	for i, e := range ch.Entries {
+		a, ok := am.assets[e.AssetPath]
+		if !ok {
+			return nil, fmt.Errorf("entry %d: unknown asset %q", i, e.AssetPath)
+		}
+		if e.MPDName == "" {
+			if len(a.MPDs) != 1 {
+				return nil, fmt.Errorf("entry %d: asset %q has %d MPDs, mpd must be set", i, e.AssetPath, len(a.MPDs))
+			}
+			for name := range a.MPDs {
+				e.MPDName = name
+			}
+		}
+		if _, ok := a.MPDs[e.MPDName]; !ok {
+			return nil, fmt.Errorf("entry %d: asset %q has no MPD %q", i, e.AssetPath, e.MPDName)
+		}
+		if e.DurS <= 0 || (e.DurS*1000)%a.SegmentDurMS != 0 {
+			return nil, fmt.Errorf("entry %d: durS %d must be a positive multiple of the segment duration %dms",
+				i, e.DurS, a.SegmentDurMS)
+		}
+		e.a = a
+		ch.cycleS += e.DurS
+	}
+	return ch, nil
+}
+
+// findChannel finds the channel of uri and returns the rest of uri after the channel path.
+func (am *assetMgr) findChannel(uri string) (ch *channel, rest string, ok bool) {
+	for chPath, ch := range am.channels {
+		if strings.HasPrefix(uri, chPath+"/") {
+			return ch, uri[len(chPath):], true
+		}
+	}
+	return nil, "", false
+}
+
+// period returns the entry of Period k and its start and end in seconds after availabilityStartTime.
+func (ch *channel) period(k int) (e *channelEntry, startS, endS int) {
+	n := len(ch.Entries)
+	startS = k / n * ch.cycleS
+	for _, e := range ch.Entries[:k%n] {
+		startS += e.DurS
+	}
+	e = ch.Entries[k%n]
+	return e, startS, startS + e.DurS
+}
+
+// periodAt returns the number of the Period running at tS seconds after availabilityStartTime.
+func (ch *channel) periodAt(tS int) int {
+	n := len(ch.Entries)
+	k := tS / ch.cycleS * n
+	rest := tS % ch.cycleS
+	for _, e := range ch.Entries {
+		if rest < e.DurS {
+			break
+		}
+		rest -= e.DurS
+		k++
+	}
+	return k
+}
+
+// channelIncompatible lists the options that cannot be combined with channels.
+var channelIncompatible = []struct {
+	option string
+	isSet  func(cfg *ResponseConfig) bool
+}{
+	{"periods", func(c *ResponseConfig) bool { return c.PeriodsPerHour != nil }},
+	{"xlink", func(c *ResponseConfig) bool { return c.XlinkPeriodsPerHour != nil }},
+	{"etp", func(c *ResponseConfig) bool { return c.EtpPeriodsPerHour != nil }},
+	{"insertad", func(c *ResponseConfig) bool { return c.InsertAdFlag }},
+	{"sgai", func(c *ResponseConfig) bool { return c.SGAI != nil }},
+	{"ssai", func(c *ResponseConfig) bool { return c.SSAI != nil }},
+	{"restart", func(c *ResponseConfig) bool { return c.Restart != nil }},
+	{"segdurs", func(c *ResponseConfig) bool { return c.SegDurs != nil }},
+	{"patch", func(c *ResponseConfig) bool { return c.PatchTTL > 0 }},
+	{"scte35mode mpd/both", func(c *ResponseConfig) bool {
+		return c.SCTE35Mode == scte35ModeMPD || c.SCTE35Mode == scte35ModeBoth
+	}},
+	{"events", func(c *ResponseConfig) bool { return len(c.Events) > 0 }},
+	{"id3", func(c *ResponseConfig) bool { return c.ID3 != "" }},
+	{"mpdevents", func(c *ResponseConfig) bool { return c.MPDEventsFlag }},
+	{"trickplay", func(c *ResponseConfig) bool { return c.TrickPlayFlag }},
+	{"timethumbs", func(c *ResponseConfig) bool { return c.TimeThumbs != nil }},
+	{"beep", func(c *ResponseConfig) bool { return c.BeepHz != nil }},
+	{"ladder", func(c *ResponseConfig) bool { return len(c.Ladder) > 0 }},
+	{"repchange", func(c *ResponseConfig) bool { return c.RepChange != nil }},
+	{"as", func(c *ResponseConfig) bool { return len(c.ASFilter) > 0 }},
+	{"reps", func(c *ResponseConfig) bool { return len(c.RepsFilter) > 0 }},
+}
+
+// checkChannelCfg checks that the configuration can be used for the channel.
+func checkChannelCfg(ch *channel, cfg *ResponseConfig) error {
+	for _, inc := range channelIncompatible {
+		if inc.isSet(cfg) {
+			return fmt.Errorf("channels cannot be combined with %s", inc.option)
+		}
+	}
+	if cfg.AVSync != nil {
+		for _, e := range ch.Entries {
+			if err := checkAVSyncAsset(e.a); err != nil {
+				return fmt.Errorf("asset %s: %w", e.AssetPath, err)
+			}
+		}
+	}
+	return nil
+}
+
+// channelPeriodCfg returns a configuration that generates Period k as a stream of its own,
+// and the time to generate it at (clamped to the Period end). ok is false if the Period has
+// not started or is outside the time-shift window at nowMS.
+func channelPeriodCfg(ch *channel, cfg *ResponseConfig, k, nowMS int) (e *channelEntry, pCfg *ResponseConfig, pNowMS int, ok bool) {
+	if k < 0 {
+		return nil, nil, 0, false
+	}
+	e, startS, endS := ch.period(k)
+	pCfg, pNowMS, ok = periodStreamCfg(cfg, startS, endS, nowMS)
+	if !ok {
+		return nil, nil, 0, false
+	}
+	pCfg.ChannelPeriod = nil
+	return e, pCfg, pNowMS, true
+}
+
+// channelSegmentCfg returns the entry, configuration and time for a segment request.
+// The segment belongs to the Period in the chp_ path token or, without token, the current one.
+func channelSegmentCfg(ch *channel, cfg *ResponseConfig, nowMS int) (*channelEntry, *ResponseConfig, int, bool) {
+	k := ch.periodAt(nowMS/1000 - cfg.StartTimeS)
+	if cfg.ChannelPeriod != nil {
+		if *cfg.ChannelPeriod > k {
+			return nil, nil, 0, false
+		}
+		k = *cfg.ChannelPeriod
+	}
+	return channelPeriodCfg(ch, cfg, k, nowMS)
+}
+
+// periodsMerger merges the MPD-level properties of MPDs whose Periods are combined.
+type periodsMerger struct {
+	profiles      []string
+	maxSegDur     m.Duration
+	minBufferTime m.Duration
+}
+
+func (pm *periodsMerger) add(pMPD *m.MPD) {
+	for _, prof := range strings.Split(string(pMPD.Profiles), ",") {
+		if prof = strings.TrimSpace(prof); prof != "" && !slices.Contains(pm.profiles, prof) {
+			pm.profiles = append(pm.profiles, prof)
+		}
+	}
+	if pMPD.MaxSegmentDuration != nil {
+		pm.maxSegDur = max(pm.maxSegDur, *pMPD.MaxSegmentDuration)
+	}
+	if pMPD.MinBufferTime != nil {
+		pm.minBufferTime = max(pm.minBufferTime, *pMPD.MinBufferTime)
+	}
+}
+
+// apply sets the merged profiles, maxSegmentDuration, and minBufferTime on mpd.
+func (pm *periodsMerger) apply(mpd *m.MPD) {
+	mpd.Profiles = m.ListOfProfilesType(strings.Join(pm.profiles, ","))
+	if pm.maxSegDur > 0 {
+		mpd.MaxSegmentDuration = Ptr(pm.maxSegDur)
+	}
+	if pm.minBufferTime > 0 {
+		mpd.MinBufferTime = Ptr(pm.minBufferTime)
+	}
+}
+
+// channelLiveMPD generates the multi-period MPD of a channel.
+func channelLiveMPD(ch *channel, cfg *ResponseConfig, drmCfg *drm.DrmConfig, nowMS int) (*m.MPD, error) {
+	last := ch.periodAt(nowMS/1000 - cfg.StartTimeS)
+	first := last
+	if cfg.TimeShiftBufferDepthS != nil {
+		windowStartS := nowMS/1000 - *cfg.TimeShiftBufferDepthS - cfg.StartTimeS
+		first = max(0, min(last, ch.periodAt(max(0, windowStartS))))
+	}
+	var mpd *m.MPD
+	var periods []*m.Period
+	var merged periodsMerger
+	for k := first; k <= last; k++ {
+		e, pCfg, pNowMS, ok := channelPeriodCfg(ch, cfg, k, nowMS)
+		if !ok {
+			continue
+		}
+		pMPD, err := LiveMPD(e.a, e.MPDName, pCfg, drmCfg, pNowMS)
+		if err != nil {
+			return nil, fmt.Errorf("period %d (%s): %w", k, e.AssetPath, err)
+		}
+		mpd = pMPD
+		merged.add(pMPD)
+		p := pMPD.Periods[0]
+		p.Id = fmt.Sprintf("P%d", k)
+		p.Start = Ptr(m.Duration(int64(pCfg.StartTimeS-cfg.StartTimeS) * 1_000_000_000))
+		p.BaseURLs = []*m.BaseURLType{m.NewBaseURL(tokenBaseURL(cfg, fmt.Sprintf("%s%d", channelTokenPrefix, k)))}
+		periods = append(periods, p)
+	}
+	if mpd == nil {
+		return nil, fmt.Errorf("no channel period at %dms", nowMS)
+	}
+	mpd.AvailabilityStartTime = m.ConvertToDateTime(float64(cfg.StartTimeS))
+	mpd.Periods = periods
+	merged.apply(mpd)
+	if cfg.liveMPDType() == segmentNumber {
+		var err error
+		mpd.PublishTime, err = lastPeriodStartTime(mpd)
+		if err != nil {
+			return nil, fmt.Errorf("lastPeriodStartTime: %w", err)
+		}
+	}
+	if cfg.AddLocationFlag {
+		mpd.Location = []*m.LocationType{{Value: locationURL(cfg)}}
+	}
+	return mpd, nil
+}
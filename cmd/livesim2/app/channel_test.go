@@ -0,0 +1,118 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestChannelSchedule(t *testing.T) {
+	ch := &channel{
+		Entries: []*channelEntry{{DurS: 60}, {DurS: 16}, {DurS: 20}},
+		cycleS:  96,
+	}
+	cases := []struct {
+		tS, wantK, wantStartS, wantEndS int
+	}{
+		{0, 0, 0, 60},
+		{59, 0, 0, 60},
+		{60, 1, 60, 76},
+		{95, 2, 76, 96},
+		{96, 3, 96, 156},
+		{200, 6, 192, 252},
+		{260, 7, 252, 268},
+	}
+	for _, c := range cases {
+		k := ch.periodAt(c.tS)
+		assert.Equal(t, c.wantK, k, c.tS)
+		e, startS, endS := ch.period(k)
+		assert.Equal(t, ch.Entries[k%3], e)
+		assert.Equal(t, []int{c.wantStartS, c.wantEndS}, []int{startS, endS}, c.tS)
+	}
+}
+
+func TestCheckChannelCfg(t *testing.T) {
+	ch := &channel{Entries: []*channelEntry{{DurS: 60}}, cycleS: 60}
+	cfg := NewResponseConfig()
+	assert.NoError(t, checkChannelCfg(ch, cfg))
+	cfg.BeepHz = Ptr(1000)
+	assert.EqualError(t, checkChannelCfg(ch, cfg), "channels cannot be combined with beep")
+	cfg = NewResponseConfig()
+	cfg.SCTE35Mode = scte35ModeBoth
+	assert.EqualError(t, checkChannelCfg(ch, cfg), "channels cannot be combined with scte35mode mpd/both")
+	cfg.SCTE35Mode = scte35ModeInband
+	assert.NoError(t, checkChannelCfg(ch, cfg))
+}
+
+func TestChannelStream(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	// demo: testpic_2s 60s, bbb_hevc_ac3_8s 16s, testpic_2s_av1 20s.
+	resp, body := testFullRequest(t, ts, "GET", "/livesim2/channels/demo/Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	mpd, err := m.ReadFromString(string(body))
+	require.NoError(t, err)
+	assert.Equal(t, m.DateTime("1970-01-01T00:00:00Z"), mpd.AvailabilityStartTime)
+	require.Len(t, mpd.Periods, 4)
+	wantStarts := []int64{0, 60, 76, 96}
+	wantVideoCodecs := []string{"avc1", "hev1", "av01", "avc1"}
+	for i, p := range mpd.Periods {
+		assert.Equal(t, m.Duration(wantStarts[i]*1_000_000_000), *p.Start)
+		require.Len(t, p.BaseURLs, 1)
+		assert.True(t, strings.HasSuffix(string(p.BaseURLs[0].Value), "/livesim2/chp_"+p.Id[1:]+"/channels/demo/"))
+		var codecs string
+		for _, as := range p.AdaptationSets {
+			if as.ContentType == "video" {
+				codecs = as.Representations[0].Codecs
+				if codecs == "" {
+					codecs = as.Codecs
+				}
+			}
+		}
+		assert.True(t, strings.HasPrefix(codecs, wantVideoCodecs[i]), "period %d codecs %s", i, codecs)
+	}
+
+	cases := []struct {
+		url      string
+		wantCode int
+	}{
+		{"/livesim2/chp_0/channels/demo/V300/29.m4s?nowMS=100000", http.StatusOK},
+		{"/livesim2/chp_0/channels/demo/V300/5.m4s?nowMS=100000", http.StatusGone},
+		{"/livesim2/chp_0/channels/demo/V300/30.m4s?nowMS=100000", http.StatusTooEarly},
+		{"/livesim2/chp_1/channels/demo/video_7.m4s?nowMS=100000", http.StatusOK},
+		{"/livesim2/chp_1/channels/demo/audio_7.m4s?nowMS=100000", http.StatusOK},
+		{"/livesim2/chp_1/channels/demo/video_init.mp4?nowMS=100000", http.StatusOK},
+		{"/livesim2/chp_3/channels/demo/V300/1.m4s?nowMS=100000", http.StatusOK},
+		{"/livesim2/chp_3/channels/demo/V300/2.m4s?nowMS=100000", http.StatusTooEarly},
+		{"/livesim2/channels/demo/V300/1.m4s?nowMS=100000", http.StatusOK},
+		{"/livesim2/chp_4/channels/demo/video_1.m4s?nowMS=100000", http.StatusNotFound},
+		{"/livesim2/channels/unknown/Manifest.mpd?nowMS=100000", http.StatusNotFound},
+		{"/livesim2/periods_60/channels/demo/Manifest.mpd?nowMS=100000", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		resp, _ := testFullRequest(t, ts, "GET", c.url, nil)
+		assert.Equal(t, c.wantCode, resp.StatusCode, c.url)
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+// CMCD (Common Media Client Data, CTA-5004 and CTA-5004-B v2) collection.
+//
+// Players send CMCD either as a `CMCD` query parameter or spread over the four
+// CMCD-Request, CMCD-Object, CMCD-Status and CMCD-Session headers. The payload is a
+// Structured Field dictionary (RFC 8941) of short keys, e.g.
+// `bl=21300,br=3200,mtp=25400,ot=v,sid="6e2fb550-c457-11e9-bb97-0800200c9a66"`.
+// CMCD v2 adds an event mode (key `e`) and a response mode (rc/ttfb/ttlb/url) where the
+// data is POSTed as reports to a collector; /cmcd/report is such a collector.
+
+const (
+	cmcdQueryKey = "CMCD"
+	// cmcdMaxReportBody bounds the body of a POSTed report batch.
+	cmcdMaxReportBody = 64 * 1024
+)
+
+// cmcdHeaders are the CMCD header shards in the order they are merged.
+var cmcdHeaders = []string{"CMCD-Request", "CMCD-Object", "CMCD-Status", "CMCD-Session"}
+
+// CmcdMode tells how a CMCD payload reached the server.
+type CmcdMode string
+
+const (
+	CmcdModeRequest  CmcdMode = "request"  // carried on an MPD or segment request
+	CmcdModeEvent    CmcdMode = "event"    // v2 event-mode report (has key e)
+	CmcdModeResponse CmcdMode = "response" // v2 response-mode report
+)
+
+// parseCMCD parses a CMCD payload into a key-value map. String values are unquoted,
+// booleans become "true"/"false" (a bare key is true), and inner lists such as
+// `(3000 5000)` are kept verbatim including the parentheses. Later keys override
+// earlier ones.
+func parseCMCD(payload string) (map[string]string, error) {
+	out := make(map[string]string)
+	members, err := splitCMCDMembers(payload)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		key, val, hasVal := strings.Cut(member, "=")
+		key = strings.TrimSpace(key)
+		if !isCMCDKey(key) {
+			return nil, fmt.Errorf("bad CMCD key %q", key)
+		}
+		if !hasVal {
+			out[key] = "true"
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch {
+		case val == "?1":
+			out[key] = "true"
+		case val == "?0":
+			out[key] = "false"
+		case strings.HasPrefix(val, `"`):
+			s, err := unquoteCMCDString(val)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", key, err)
+			}
+			out[key] = s
+		case val == "":
+			return nil, fmt.Errorf("key %s: empty value", key)
+		default:
+			out[key] = val
+		}
+	}
+	return out, nil
+}
+
+// splitCMCDMembers splits a payload on the commas that are outside strings and inner lists.
+func splitCMCDMembers(payload string) ([]string, error) {
+	var members []string
+	inString, escaped := false, false
+	depth, start := 0, 0
+	for i := 0; i < len(payload); i++ {
+		c := payload[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced ')' at %d", i)
+			}
+		case c == ',' && depth == 0:
+			members = append(members, payload[start:i])
+			start = i + 1
+		}
+	}
+	if inString {
+		return nil, fmt.Errorf("unterminated string")
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unterminated inner list")
+	}
+	return append(members, payload[start:]), nil
+}
+
+// isCMCDKey checks the key against the Structured Field key grammar (lcalpha or *
+// followed by lcalpha, digits, _, -, . or *). Custom keys such as com.example-mykey pass.
+func isCMCDKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c == '*':
+		case i > 0 && (c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// unquoteCMCDString unquotes a Structured Field string with \" and \\ escapes.
+func unquoteCMCDString(val string) (string, error) {
+	if len(val) < 2 || val[len(val)-1] != '"' {
+		return "", fmt.Errorf("unterminated string %s", val)
+	}
+	var sb strings.Builder
+	inner := val[1 : len(val)-1]
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		if c == '\\' {
+			if i+1 == len(inner) || (inner[i+1] != '"' && inner[i+1] != '\\') {
+				return "", fmt.Errorf("bad escape in %s", val)
+			}
+			i++
+			c = inner[i]
+		} else if c == '"' {
+			return "", fmt.Errorf("unescaped quote in %s", val)
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), nil
+}
+
+// cmcdFromRequest collects the CMCD data of a request from the CMCD query parameter and
+// the four CMCD headers. It returns nil (and no error) if the request carries no CMCD.
+func cmcdFromRequest(r *http.Request) (map[string]string, error) {
+	var data map[string]string
+	merge := func(payload string) error {
+		kv, err := parseCMCD(payload)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			data = make(map[string]string, len(kv))
+		}
+		for k, v := range kv {
+			data[k] = v
+		}
+		return nil
+	}
+	if q := r.URL.Query().Get(cmcdQueryKey); q != "" {
+		if err := merge(q); err != nil {
+			return nil, fmt.Errorf("query: %w", err)
+		}
+	}
+	for _, h := range cmcdHeaders {
+		if v := r.Header.Get(h); v != "" {
+			if err := merge(v); err != nil {
+				return nil, fmt.Errorf("%s: %w", h, err)
+			}
+		}
+	}
+	return data, nil
+}
+
+// cmcdReportMode classifies a POSTed v2 report: event mode if it has the e key,
+// otherwise response mode.
+func cmcdReportMode(data map[string]string) CmcdMode {
+	if _, ok := data["e"]; ok {
+		return CmcdModeEvent
+	}
+	return CmcdModeResponse
+}
+
+// cmcdInts returns the integer values of a CMCD value. A plain integer gives one value,
+// an inner list such as `(21300;v 12000;a)` gives one per item (parameters dropped).
+// Non-integer items are skipped.
+func cmcdInts(val string) []int {
+	val = strings.TrimSpace(val)
+	if strings.HasPrefix(val, "(") {
+		val = strings.TrimSuffix(strings.TrimPrefix(val, "("), ")")
+	}
+	var out []int
+	for _, item := range strings.Fields(val) {
+		item, _, _ = strings.Cut(item, ";")
+		if n, err := strconv.Atoi(item); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// cmcdSessionID returns the session a CMCD payload belongs to: the CMCD sid key with
+// the sessionId/sid query as fallback, as used by SGAI and content steering.
+func cmcdSessionID(data map[string]string, r *http.Request) string {
+	if sid := data["sid"]; sid != "" {
+		return sid
+	}
+	return steeringSessionID(r)
+}
+
+// recordCMCD parses and records the CMCD data of an MPD or segment request. A malformed
+// payload is logged but does not fail the request.
+func (s *Server) recordCMCD(log *slog.Logger, r *http.Request, object string) {
+	if s.cmcdSessions == nil {
+		return
+	}
+	data, err := cmcdFromRequest(r)
+	if err != nil {
+		log.Warn("bad CMCD", "err", err)
+		return
+	}
+	if data == nil {
+		return
+	}
+	s.cmcdSessions.Record(cmcdSessionID(data, r), CmcdModeRequest, object, data)
+	prometheusMW.observeCMCD(CmcdModeRequest, data)
+}
+
+// cmcdReportHandlerFunc is a CMCD v2 collector for event- and response-mode reports.
+// The body holds one CMCD payload per line (several reports may be batched); a payload
+// carried in the CMCD query or headers of the report request itself is also accepted.
+// Returns 204 if at least one report was recorded and 400 otherwise.
+func (s *Server) cmcdReportHandlerFunc(w http.ResponseWriter, r *http.Request) {
+	log := logging.SubLoggerWithRequestID(slog.Default(), r)
+	var reports []map[string]string
+	data, err := cmcdFromRequest(r)
+	if err != nil {
+		log.Warn("bad CMCD report", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if data != nil {
+		reports = append(reports, data)
+	}
+	if r.Body != nil {
+		scanner := bufio.NewScanner(io.LimitReader(r.Body, cmcdMaxReportBody))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			line = strings.TrimPrefix(line, cmcdQueryKey+"=")
+			if line == "" {
+				continue
+			}
+			kv, err := parseCMCD(line)
+			if err != nil {
+				log.Warn("bad CMCD report", "err", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			reports = append(reports, kv)
+		}
+		if err := scanner.Err(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if len(reports) == 0 {
+		http.Error(w, "no CMCD data", http.StatusBadRequest)
+		return
+	}
+	for _, rep := range reports {
+		mode := cmcdReportMode(rep)
+		if s.cmcdSessions != nil {
+			s.cmcdSessions.Record(cmcdSessionID(rep, r), mode, rep["url"], rep)
+		}
+		prometheusMW.observeCMCD(mode, rep)
+	}
+	log.Debug("cmcd reports", "count", len(reports))
+	w.WriteHeader(http.StatusNoContent)
+}
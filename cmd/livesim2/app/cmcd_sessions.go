@@ -0,0 +1,242 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// CMCD session tracking. Aggregates the CMCD data received per session id (the CMCD sid
+// key) so that a player's reported state — buffer length, throughput, bitrate, play
+// state — can be followed via the API. Bounded and time-limited like SgaiSessionMgr,
+// since the session id is client-supplied.
+
+const (
+	cmcdDefaultMaxSessions          = 2000
+	cmcdDefaultMaxReportsPerSession = 200
+	cmcdDefaultSessionTTL           = 30 * time.Minute
+)
+
+// cmcdStatKeys are the numeric CMCD keys summarized per session.
+var cmcdStatKeys = []string{"bl", "br", "dl", "mtp", "tb", "ttfb", "ttlb"}
+
+// CmcdReport is a single recorded CMCD payload.
+type CmcdReport struct {
+	Time   time.Time         `json:"time" doc:"When the payload was received (server time)"`
+	Mode   CmcdMode          `json:"mode" doc:"How it was received: request, event or response"`
+	Object string            `json:"object,omitempty" doc:"Requested object (request mode) or reported url"`
+	Data   map[string]string `json:"data" doc:"CMCD keys and values (strings unquoted, inner lists verbatim)"`
+}
+
+// CmcdStat summarizes the integer values reported for one CMCD key.
+type CmcdStat struct {
+	Count int     `json:"count" doc:"Number of values"`
+	Min   int     `json:"min" doc:"Smallest value"`
+	Max   int     `json:"max" doc:"Largest value"`
+	Last  int     `json:"last" doc:"Most recent value"`
+	Mean  float64 `json:"mean" doc:"Mean value"`
+}
+
+func (c *CmcdStat) add(v int) {
+	if c.Count == 0 || v < c.Min {
+		c.Min = v
+	}
+	if c.Count == 0 || v > c.Max {
+		c.Max = v
+	}
+	c.Mean = (c.Mean*float64(c.Count) + float64(v)) / float64(c.Count+1)
+	c.Count++
+	c.Last = v
+}
+
+// CmcdSession is the aggregated CMCD state for one session id.
+type CmcdSession struct {
+	Sid         string               `json:"sid" doc:"Session id"`
+	Cid         string               `json:"cid,omitempty" doc:"Most recent content id"`
+	CreatedAt   time.Time            `json:"createdAt" doc:"When the session was first seen"`
+	LastSeen    time.Time            `json:"lastSeen" doc:"When the session was last active"`
+	RequestCnt  int                  `json:"requestCount" doc:"Number of requests carrying CMCD"`
+	EventCnt    int                  `json:"eventCount" doc:"Number of v2 event-mode reports"`
+	ResponseCnt int                  `json:"responseCount" doc:"Number of v2 response-mode reports"`
+	Latest      map[string]string    `json:"latest" doc:"Latest value per CMCD key"`
+	Stats       map[string]*CmcdStat `json:"stats" doc:"Summary of numeric keys (bl, br, dl, mtp, tb, ttfb, ttlb)"`
+	Reports     []CmcdReport         `json:"reports" doc:"Recent CMCD payloads (oldest first)"`
+}
+
+// CmcdSessionMgr is a bounded, time-limited store of CMCD session data.
+type CmcdSessionMgr struct {
+	mu          sync.RWMutex
+	sessions    map[string]*CmcdSession
+	maxSessions int
+	maxReports  int
+	ttl         time.Duration
+	now         func() time.Time // injectable for tests
+}
+
+// NewCmcdSessionMgr creates a session manager with the default bounds.
+func NewCmcdSessionMgr() *CmcdSessionMgr {
+	return &CmcdSessionMgr{
+		sessions:    make(map[string]*CmcdSession),
+		maxSessions: cmcdDefaultMaxSessions,
+		maxReports:  cmcdDefaultMaxReportsPerSession,
+		ttl:         cmcdDefaultSessionTTL,
+		now:         time.Now,
+	}
+}
+
+// Record adds a CMCD payload to the session sid ("anon" if empty).
+func (m *CmcdSessionMgr) Record(sid string, mode CmcdMode, object string, data map[string]string) {
+	if sid == "" {
+		sid = "anon"
+	}
+	ts := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[sid]
+	if !ok {
+		s = &CmcdSession{Sid: sid, CreatedAt: ts, Latest: make(map[string]string),
+			Stats: make(map[string]*CmcdStat)}
+		m.sessions[sid] = s
+	}
+	s.LastSeen = ts
+	switch mode {
+	case CmcdModeEvent:
+		s.EventCnt++
+	case CmcdModeResponse:
+		s.ResponseCnt++
+	default:
+		s.RequestCnt++
+	}
+	if cid := data["cid"]; cid != "" {
+		s.Cid = cid
+	}
+	maps.Copy(s.Latest, data)
+	for _, key := range cmcdStatKeys {
+		val, ok := data[key]
+		if !ok {
+			continue
+		}
+		for _, v := range cmcdInts(val) {
+			st := s.Stats[key]
+			if st == nil {
+				st = &CmcdStat{}
+				s.Stats[key] = st
+			}
+			st.add(v)
+		}
+	}
+	s.Reports = append(s.Reports, CmcdReport{Time: ts, Mode: mode, Object: object, Data: maps.Clone(data)})
+	if m.maxReports > 0 && len(s.Reports) > m.maxReports {
+		s.Reports = s.Reports[len(s.Reports)-m.maxReports:]
+	}
+	m.evictLocked(ts)
+}
+
+// Get returns a deep copy of the session for sid, dropping it if it has expired.
+func (m *CmcdSessionMgr) Get(sid string) (*CmcdSession, bool) {
+	ts := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[sid]
+	if !ok {
+		return nil, false
+	}
+	if m.ttl > 0 && ts.Sub(s.LastSeen) > m.ttl {
+		delete(m.sessions, sid)
+		return nil, false
+	}
+	return s.clone(true), true
+}
+
+// Clear removes all recorded sessions and returns the number removed.
+func (m *CmcdSessionMgr) Clear() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.sessions)
+	m.sessions = make(map[string]*CmcdSession)
+	return n
+}
+
+// ClearSession removes a single session by id, returning true if it existed.
+func (m *CmcdSessionMgr) ClearSession(sid string) bool {
+	if sid == "" {
+		sid = "anon"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[sid]; ok {
+		delete(m.sessions, sid)
+		return true
+	}
+	return false
+}
+
+// List returns summaries (no report timelines) of the live sessions, most-recent first.
+func (m *CmcdSessionMgr) List() []CmcdSession {
+	ts := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evictLocked(ts)
+	out := make([]CmcdSession, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		out = append(out, *s.clone(false))
+	}
+	// most-recently-active first (simple insertion sort; the set is small)
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && out[j].LastSeen.After(out[j-1].LastSeen); j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out
+}
+
+// evictLocked drops expired sessions and enforces the maxSessions cap (oldest LastSeen
+// first). Caller must hold mu.
+func (m *CmcdSessionMgr) evictLocked(ts time.Time) {
+	if m.ttl > 0 {
+		for sid, s := range m.sessions {
+			if ts.Sub(s.LastSeen) > m.ttl {
+				delete(m.sessions, sid)
+			}
+		}
+	}
+	if m.maxSessions <= 0 {
+		return
+	}
+	for len(m.sessions) > m.maxSessions {
+		var oldestSid string
+		var oldest time.Time
+		first := true
+		for sid, s := range m.sessions {
+			if first || s.LastSeen.Before(oldest) {
+				oldestSid, oldest, first = sid, s.LastSeen, false
+			}
+		}
+		delete(m.sessions, oldestSid)
+	}
+}
+
+// clone deep-copies a session so it can be read outside the lock. The report timeline
+// is only included if withReports is set.
+func (s *CmcdSession) clone(withReports bool) *CmcdSession {
+	c := *s
+	c.Latest = maps.Clone(s.Latest)
+	c.Stats = make(map[string]*CmcdStat, len(s.Stats))
+	for k, v := range s.Stats {
+		st := *v
+		c.Stats[k] = &st
+	}
+	c.Reports = nil
+	if withReports {
+		c.Reports = make([]CmcdReport, len(s.Reports))
+		for i, r := range s.Reports {
+			r.Data = maps.Clone(r.Data)
+			c.Reports[i] = r
+		}
+	}
+	return &c
+}
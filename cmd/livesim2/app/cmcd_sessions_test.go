@@ -0,0 +1,81 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmcdSessionAggregation(t *testing.T) {
+	m := NewCmcdSessionMgr()
+	now, adv := fixedClock(time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC))
+	m.now = now
+
+	m.Record("s1", CmcdModeRequest, "a/1.m4s", map[string]string{"bl": "1000", "mtp": "8000", "cid": "c1"})
+	adv(time.Second)
+	m.Record("s1", CmcdModeRequest, "a/2.m4s", map[string]string{"bl": "(3000;v 2000;a)"})
+	m.Record("", CmcdModeEvent, "", map[string]string{"e": "ps"})
+
+	s, ok := m.Get("s1")
+	require.True(t, ok)
+	assert.Equal(t, "c1", s.Cid)
+	assert.Equal(t, 2, s.RequestCnt)
+	bl := s.Stats["bl"]
+	require.NotNil(t, bl)
+	assert.Equal(t, CmcdStat{Count: 3, Min: 1000, Max: 3000, Last: 2000, Mean: 2000}, *bl)
+	assert.Equal(t, "(3000;v 2000;a)", s.Latest["bl"])
+	require.Len(t, s.Reports, 2)
+
+	// Get returns a copy.
+	s.Stats["bl"].Count = 99
+	s.Reports[0].Data["bl"] = "MUT"
+	s2, _ := m.Get("s1")
+	assert.Equal(t, 3, s2.Stats["bl"].Count)
+	assert.Equal(t, "1000", s2.Reports[0].Data["bl"])
+
+	anon, ok := m.Get("anon")
+	require.True(t, ok)
+	assert.Equal(t, 1, anon.EventCnt)
+
+	list := m.List()
+	require.Len(t, list, 2)
+	assert.Nil(t, list[0].Reports, "list omits the reports")
+
+	assert.True(t, m.ClearSession("s1"))
+	assert.False(t, m.ClearSession("s1"))
+	assert.Equal(t, 1, m.Clear())
+}
+
+func TestCmcdSessionBounds(t *testing.T) {
+	m := NewCmcdSessionMgr()
+	now, adv := fixedClock(time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC))
+	m.now = now
+	m.maxSessions = 3
+	m.maxReports = 2
+
+	for i := range 5 {
+		m.Record(fmt.Sprintf("s%d", i), CmcdModeRequest, "", map[string]string{"bl": "1"})
+		adv(time.Second)
+	}
+	assert.Len(t, m.List(), 3, "capped at maxSessions")
+	_, ok := m.Get("s0")
+	assert.False(t, ok, "oldest evicted")
+
+	for range 4 {
+		m.Record("s4", CmcdModeRequest, "", map[string]string{"bl": "1"})
+	}
+	s, _ := m.Get("s4")
+	assert.Len(t, s.Reports, 2, "capped at maxReports")
+	assert.Equal(t, 5, s.Stats["bl"].Count, "stats still count all")
+
+	adv(cmcdDefaultSessionTTL + time.Second)
+	_, ok = m.Get("s4")
+	assert.False(t, ok, "expired")
+}
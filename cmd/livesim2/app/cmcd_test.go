@@ -0,0 +1,155 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCMCD(t *testing.T) {
+	cases := []struct {
+		desc    string
+		payload string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			desc:    "v1 query payload",
+			payload: `bl=21300,br=3200,bs,d=4004,mtp=25400,ot=v,sid="6e2fb550-c457-11e9",su=?0`,
+			want: map[string]string{"bl": "21300", "br": "3200", "bs": "true", "d": "4004",
+				"mtp": "25400", "ot": "v", "sid": "6e2fb550-c457-11e9", "su": "false"},
+		},
+		{
+			desc:    "escapes and comma in string",
+			payload: `cid="a,b\"c\\d", pr=1.25`,
+			want:    map[string]string{"cid": `a,b"c\d`, "pr": "1.25"},
+		},
+		{
+			desc:    "v2 inner list and custom key",
+			payload: `bl=(21300;v 12000;a),com.example-x=?1`,
+			want:    map[string]string{"bl": "(21300;v 12000;a)", "com.example-x": "true"},
+		},
+		{desc: "empty", payload: "", want: map[string]string{}},
+		{desc: "uppercase key", payload: "BL=2", wantErr: true},
+		{desc: "unterminated string", payload: `sid="abc`, wantErr: true},
+		{desc: "unbalanced list", payload: `bl=(1 2`, wantErr: true},
+		{desc: "empty value", payload: `bl=`, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			got, err := parseCMCD(c.payload)
+			if c.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestCmcdInts(t *testing.T) {
+	assert.Equal(t, []int{21300}, cmcdInts("21300"))
+	assert.Equal(t, []int{21300, 12000}, cmcdInts("(21300;v 12000;a)"))
+	assert.Nil(t, cmcdInts("v"))
+	assert.Nil(t, cmcdInts(""))
+}
+
+func TestObserveCMCDObjectType(t *testing.T) {
+	prometheusMW.observeCMCD(CmcdModeRequest, map[string]string{"ot": "v", "bl": "1000", "mtp": "2000"})
+	prometheusMW.observeCMCD(CmcdModeRequest, map[string]string{"ot": "x-unknown", "bl": "1000", "mtp": "2000"})
+	for _, hv := range []*prometheus.HistogramVec{prometheusMW.cmcdBL, prometheusMW.cmcdMTP} {
+		assert.False(t, hv.DeleteLabelValues("x-unknown"))
+		assert.True(t, hv.DeleteLabelValues("other"))
+		assert.True(t, hv.DeleteLabelValues("v"))
+	}
+}
+
+func TestCmcdFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/livesim2/testpic_2s/V300/1.m4s?CMCD="+
+		url.QueryEscape(`bl=1000,sid="s1"`), nil)
+	r.Header.Set("CMCD-Request", "bl=2000,mtp=5000")
+	r.Header.Set("CMCD-Session", `sid="s2",cid="c"`)
+	data, err := cmcdFromRequest(r)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"bl": "2000", "mtp": "5000", "sid": "s2", "cid": "c"}, data,
+		"headers are merged after the query")
+
+	r = httptest.NewRequest("GET", "/livesim2/testpic_2s/Manifest.mpd", nil)
+	data, err = cmcdFromRequest(r)
+	require.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestCmcdCollection(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	cmcdQuery := "?CMCD=" + url.QueryEscape(`bl=4000,mtp=12000,ot=m,sid="alice"`)
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/testpic_2s/Manifest.mpd"+cmcdQuery+"&nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req, err := http.NewRequest("GET", ts.URL+"/livesim2/testpic_2s/V300/49.m4s?nowMS=100000", nil)
+	require.NoError(t, err)
+	req.Header.Set("CMCD-Request", "bl=6000,mtp=20000")
+	req.Header.Set("CMCD-Object", "ot=v,br=300")
+	req.Header.Set("CMCD-Session", `sid="alice"`)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// A batch of v2 reports: one event and one response report.
+	body := "e=ps,sta=p,sid=\"alice\"\nrc=200,ttfb=40,url=\"/x.m4s\",sid=\"alice\"\n"
+	resp, _ = testFullRequest(t, ts, "POST", "/cmcd/report", strings.NewReader(body))
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = testFullRequest(t, ts, "POST", "/cmcd/report", strings.NewReader("BAD"))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = testFullRequest(t, ts, "POST", "/cmcd/report", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	sess, ok := server.cmcdSessions.Get("alice")
+	require.True(t, ok)
+	assert.Equal(t, 2, sess.RequestCnt)
+	assert.Equal(t, 1, sess.EventCnt)
+	assert.Equal(t, 1, sess.ResponseCnt)
+	assert.Equal(t, "p", sess.Latest["sta"])
+	require.Contains(t, sess.Stats, "bl")
+	assert.Equal(t, 2, sess.Stats["bl"].Count)
+	assert.Equal(t, 4000, sess.Stats["bl"].Min)
+	assert.Equal(t, 6000, sess.Stats["bl"].Max)
+	require.Len(t, sess.Reports, 4)
+	assert.Equal(t, "testpic_2s/V300/49.m4s", sess.Reports[1].Object)
+	assert.Equal(t, "/x.m4s", sess.Reports[3].Object)
+
+	resp, body2 := testFullRequest(t, ts, "GET", "/api/cmcd/sessions/alice", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body2), `"requestCount":2`)
+	resp, _ = testFullRequest(t, ts, "GET", "/api/cmcd/sessions/bob", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, body2 = testFullRequest(t, ts, "POST", "/api/cmcd/sessions/clear", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body2), `"cleared":1`)
+}
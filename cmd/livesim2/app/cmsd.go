@@ -0,0 +1,363 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CMSD (Common Media Server Data, CTA-5006) response headers.
+//
+// With the cmsd_ URL option, MPD and segment responses carry a CMSD-Static header
+// (object type, streaming format, stream type, duration, availability time, held time and
+// a next-object hint) and a CMSD-Dynamic header with the server's view of the network
+// (estimated throughput, max suggested bitrate, rtt and response delay). The dynamic values
+// follow wall-clock schedules, and the response delay and throughput follow the traffic_
+// states and the optional bandwidth limit, so that the advertised network matches the
+// emulated one.
+
+const (
+	cmsdDefaultID = "livesim2" // default intermediary identifier in CMSD-Dynamic
+	cmsdVersion   = 1
+)
+
+// cmsdStep is one value of a cmsdSchedule, valid for durS seconds.
+type cmsdStep struct {
+	Val  int `json:"Val"`
+	DurS int `json:"DurS"`
+}
+
+// cmsdSchedule is a wall-clock cycle of values. A single step is a constant value.
+type cmsdSchedule []cmsdStep
+
+// createCMSDSchedule parses <val>[/<s>][,<val>/<s>...], e.g. 20000/30,5000/30.
+func createCMSDSchedule(val string) (cmsdSchedule, error) {
+	var sched cmsdSchedule
+	parts := strings.Split(val, ",")
+	for _, p := range parts {
+		v, d, hasDur := strings.Cut(p, "/")
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("bad value %q: must be a non-negative integer", v)
+		}
+		dur := 1
+		if hasDur {
+			dur, err = strconv.Atoi(d)
+			if err != nil || dur <= 0 {
+				return nil, fmt.Errorf("bad duration %q: must be a positive integer", d)
+			}
+		} else if len(parts) > 1 {
+			return nil, fmt.Errorf("%q: duration needed in a schedule with several values", p)
+		}
+		sched = append(sched, cmsdStep{Val: n, DurS: dur})
+	}
+	return sched, nil
+}
+
+// valueAt returns the schedule value at nowS (cycling), or 0 for an empty schedule.
+func (c cmsdSchedule) valueAt(nowS int) int {
+	cycle := 0
+	for _, st := range c {
+		cycle += st.DurS
+	}
+	if cycle == 0 {
+		return 0
+	}
+	rest := nowS % cycle
+	for _, st := range c {
+		rest -= st.DurS
+		if rest < 0 {
+			return st.Val
+		}
+	}
+	return 0
+}
+
+// CMSDConfig configures CMSD response headers.
+type CMSDConfig struct {
+	Etp   cmsdSchedule `json:"Etp"`             // estimated throughput in kbps (0 = not advertised)
+	MB    cmsdSchedule `json:"MB,omitempty"`    // max suggested bitrate in kbps
+	RTT   cmsdSchedule `json:"RTT,omitempty"`   // round-trip time in ms
+	Held  cmsdSchedule `json:"Held,omitempty"`  // hold segment responses this many ms (signaled as ht)
+	Limit bool         `json:"Limit,omitempty"` // limit segment response rate to the advertised etp
+	NOR   bool         `json:"NOR"`             // add next-object-response hint
+	ID    string       `json:"ID"`              // intermediary identifier in CMSD-Dynamic
+}
+
+// CreateCMSDConfig parses the value of a "cmsd" URL option.
+//
+// Grammar: <etp>[;key=val;...] where <etp> and the mb, rtt and held values are either a
+// constant or a wall-clock cycle <val>/<s>,<val>/<s>,...
+// keys: mb=<kbps>, rtt=<ms>, held=<ms>, limit=<0|1> (default 0), nor=<0|1> (default 1),
+//
+//	id=<name> (intermediary identifier, default livesim2).
+//
+// Examples:
+//
+//	5000                        => etp=5000 on all responses
+//	20000/30,2000/30;limit=1    => 30 s at 20 Mbps, 30 s at 2 Mbps, responses paced accordingly
+//	8000;mb=3000;rtt=40;held=0/50,500/10 => segments held 500 ms in the last 10 s of each minute
+func CreateCMSDConfig(val string) (*CMSDConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty cmsd config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("cmsd config %q has extra spaces", val)
+	}
+	cfg := &CMSDConfig{NOR: true, ID: cmsdDefaultID}
+	parts := strings.Split(val, ";")
+	var err error
+	cfg.Etp, err = createCMSDSchedule(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("cmsd etp: %w", err)
+	}
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("cmsd param %q must be key=val", kv)
+		}
+		switch key {
+		case "mb":
+			cfg.MB, err = createCMSDSchedule(v)
+		case "rtt":
+			cfg.RTT, err = createCMSDSchedule(v)
+		case "held":
+			cfg.Held, err = createCMSDSchedule(v)
+		case "limit", "nor":
+			var flag bool
+			switch v {
+			case "1", "true":
+				flag = true
+			case "0", "false":
+				flag = false
+			default:
+				return nil, fmt.Errorf("cmsd %s %q: must be 0 or 1", key, v)
+			}
+			if key == "limit" {
+				cfg.Limit = flag
+			} else {
+				cfg.NOR = flag
+			}
+		case "id":
+			if !isValidServiceLocation(v) {
+				return nil, fmt.Errorf("cmsd id %q: must be non-empty and use only [A-Za-z0-9._-]", v)
+			}
+			cfg.ID = v
+		default:
+			return nil, fmt.Errorf("unknown cmsd param %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cmsd %s: %w", key, err)
+		}
+	}
+	if cfg.Limit && !slices.ContainsFunc(cfg.Etp, func(st cmsdStep) bool { return st.Val > 0 }) {
+		return nil, fmt.Errorf("cmsd limit=1 needs a non-zero etp")
+	}
+	return cfg, nil
+}
+
+// ParseCMSDConfig parses a cmsd option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseCMSDConfig(key, val string) *CMSDConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreateCMSDConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// cmsdObjectType returns the CMSD/CMCD object type token for a representation content type.
+func cmsdObjectType(rep *RepData) string {
+	switch rep.ContentType {
+	case "video":
+		return "v"
+	case "audio":
+		return "a"
+	case "text":
+		return "tt"
+	default:
+		return "o"
+	}
+}
+
+// cmsdHeaders holds the CMSD header values of one response.
+type cmsdHeaders struct {
+	static  []string
+	dynamic []string
+}
+
+// set writes the CMSD-Static and CMSD-Dynamic headers.
+func (h cmsdHeaders) set(w http.ResponseWriter, id string) {
+	w.Header().Set("CMSD-Static", strings.Join(h.static, ","))
+	dyn := strconv.Quote(id)
+	if len(h.dynamic) > 0 {
+		dyn += ";" + strings.Join(h.dynamic, ";")
+	}
+	w.Header().Set("CMSD-Dynamic", dyn)
+}
+
+// dynamicCMSD returns the CMSD-Dynamic parameters at nowMS given the traffic state.
+// A slow or hanging response is signaled as a response delay (rd), and a hang as etp=0.
+func (c *CMSDConfig) dynamicCMSD(nowMS int, state lossState) []string {
+	nowS := nowMS / 1000
+	var out []string
+	if etp := c.Etp.valueAt(nowS); state == lossHang {
+		out = append(out, "etp=0")
+	} else if etp > 0 {
+		out = append(out, fmt.Sprintf("etp=%d", etp))
+	}
+	if mb := c.MB.valueAt(nowS); mb > 0 {
+		out = append(out, fmt.Sprintf("mb=%d", mb))
+	}
+	switch state {
+	case lossSlow:
+		out = append(out, fmt.Sprintf("rd=%d", lossSlowTime.Milliseconds()))
+	case lossHang:
+		out = append(out, fmt.Sprintf("rd=%d", lossHangTime.Milliseconds()))
+	}
+	if rtt := c.RTT.valueAt(nowS); rtt > 0 {
+		out = append(out, fmt.Sprintf("rtt=%d", rtt))
+	}
+	return out
+}
+
+// heldMS returns how long a segment response is held at nowMS.
+func (c *CMSDConfig) heldMS(nowMS int) int {
+	return c.Held.valueAt(nowMS / 1000)
+}
+
+// mpdCMSD returns the CMSD headers for an MPD response.
+func mpdCMSD(cfg *ResponseConfig, nowMS int) cmsdHeaders {
+	return cmsdHeaders{
+		static:  []string{"ot=m", "sf=d", "st=l", fmt.Sprintf("v=%d", cmsdVersion)},
+		dynamic: cfg.CMSD.dynamicCMSD(nowMS, lossNo),
+	}
+}
+
+// segmentCMSD returns the CMSD headers for a segment response. The duration, availability
+// time and next-object hint are added when the segment can be resolved; an init segment is
+// signaled as object type i.
+func segmentCMSD(cfg *ResponseConfig, a *asset, segmentPart string, nowMS int, state lossState) cmsdHeaders {
+	h := cmsdHeaders{dynamic: cfg.CMSD.dynamicCMSD(nowMS, state)}
+	ot := "o"
+	for _, rep := range a.Reps {
+		if segmentPart == rep.InitURI {
+			ot = "i"
+			break
+		}
+	}
+	var extra []string
+	if ot != "i" {
+		if rep, segID, err := findRepAndSegmentID(a, segmentPart); err == nil {
+			ot = cmsdObjectType(rep)
+			if isImage(segmentPart) {
+				ot = "o"
+			}
+			extra = segmentCMSDTiming(cfg, a, rep, segID, segmentPart, nowMS)
+		}
+	}
+	h.static = append(h.static, "ot="+ot, "sf=d", "st=l")
+	h.static = append(h.static, extra...)
+	if held := cfg.CMSD.heldMS(nowMS); held > 0 {
+		h.static = append(h.static, fmt.Sprintf("ht=%d", held))
+	}
+	h.static = append(h.static, fmt.Sprintf("v=%d", cmsdVersion))
+	return h
+}
+
+// segmentCMSDTiming returns the at (availability time), d (duration) and nor (next object)
+// CMSD-Static keys of a media segment. Nothing is returned if the segment is not available.
+func segmentCMSDTiming(cfg *ResponseConfig, a *asset, rep *RepData, segID uint64, segmentPart string,
+	nowMS int) []string {
+	sm, err := findSegMeta(a, cfg, segmentPart, nowMS)
+	if err != nil || sm.timescale == 0 {
+		return nil
+	}
+	ts := uint64(sm.timescale)
+	var out []string
+	availMS := int64(cfg.StartTimeS)*1000 + int64((sm.newTime+uint64(sm.newDur))*1000/ts)
+	if ato := cfg.getAvailabilityTimeOffsetS(); ato != math.Inf(1) {
+		availMS -= int64(ato * 1000)
+		out = append(out, fmt.Sprintf("at=%d", availMS))
+	}
+	out = append(out, fmt.Sprintf("d=%d", uint64(sm.newDur)*1000/ts))
+	if !cfg.CMSD.NOR {
+		return out
+	}
+	var next string
+	switch cfg.getRepType(segmentPart) {
+	case segmentNumber, timeLineNumber:
+		next = replaceTimeOrNr(rep.MediaURI, int(segID)+1)
+	case timeLineTime:
+		if rep != sm.rep {
+			return out // audio follows the reference track; its next time is not known here
+		}
+		next = replaceTimeOrNr(rep.MediaURI, int(segID)+int(sm.newDur))
+	}
+	if next != "" {
+		out = append(out, fmt.Sprintf("nor=%q", path.Base(next)))
+	}
+	return out
+}
+
+// throttledWriter paces the body of a response to a given rate, to emulate a bandwidth limit.
+type throttledWriter struct {
+	http.ResponseWriter
+	bytesPerS float64
+	start     time.Time
+	written   int
+}
+
+// newThrottledWriter returns w paced to kbps, or w itself if kbps is not positive.
+func newThrottledWriter(w http.ResponseWriter, kbps int) http.ResponseWriter {
+	if kbps <= 0 {
+		return w
+	}
+	return &throttledWriter{ResponseWriter: w, bytesPerS: float64(kbps) * 1000 / 8}
+}
+
+// throttleChunkSize bounds each write so that pacing is smooth.
+const throttleChunkSize = 16 * 1024
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	n := 0
+	for n < len(p) {
+		end := min(n+throttleChunkSize, len(p))
+		m, err := t.ResponseWriter.Write(p[n:end])
+		n += m
+		t.written += m
+		if err != nil {
+			return n, err
+		}
+		due := time.Duration(float64(t.written) / t.bytesPerS * float64(time.Second))
+		if wait := due - time.Since(t.start); wait > 0 {
+			if f, ok := t.ResponseWriter.(http.Flusher); ok {
+				f.Flush()
+			}
+			time.Sleep(wait)
+		}
+	}
+	return n, nil
+}
+
+// Flush passes on to the underlying writer (needed for chunked low-latency responses).
+func (t *throttledWriter) Flush() {
+	if f, ok := t.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCMSDConfig(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    *CMSDConfig
+		wantErr string
+	}{
+		{
+			val:  "5000",
+			want: &CMSDConfig{Etp: cmsdSchedule{{5000, 1}}, NOR: true, ID: "livesim2"},
+		},
+		{
+			val: "20000/30,2000/30;limit=1;mb=3000;rtt=40;held=0/50,500/10;nor=0;id=edge1",
+			want: &CMSDConfig{Etp: cmsdSchedule{{20000, 30}, {2000, 30}}, MB: cmsdSchedule{{3000, 1}},
+				RTT: cmsdSchedule{{40, 1}}, Held: cmsdSchedule{{0, 50}, {500, 10}}, Limit: true, ID: "edge1"},
+		},
+		{val: "", wantErr: "empty cmsd config"},
+		{val: "fast", wantErr: "cmsd etp: bad value"},
+		{val: "100,200/5", wantErr: "duration needed"},
+		{val: "100;limit=2", wantErr: "must be 0 or 1"},
+		{val: "0;limit=1", wantErr: "needs a non-zero etp"},
+		{val: "100;foo=1", wantErr: "unknown cmsd param"},
+		{val: "100;id=a/b", wantErr: "cmsd id"},
+		{val: "100; rtt=5", wantErr: "extra spaces"},
+	}
+	for _, c := range cases {
+		got, err := CreateCMSDConfig(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestCMSDScheduleAndDynamic(t *testing.T) {
+	cfg, err := CreateCMSDConfig("20000/30,2000/30;mb=3000;rtt=40")
+	require.NoError(t, err)
+	assert.Equal(t, 20000, cfg.Etp.valueAt(29))
+	assert.Equal(t, 2000, cfg.Etp.valueAt(30))
+	assert.Equal(t, 20000, cfg.Etp.valueAt(60))
+	assert.Equal(t, []string{"etp=2000", "mb=3000", "rtt=40"}, cfg.dynamicCMSD(45_000, lossNo))
+	assert.Equal(t, []string{"etp=20000", "mb=3000", "rd=2000", "rtt=40"}, cfg.dynamicCMSD(0, lossSlow))
+	assert.Equal(t, []string{"etp=0", "mb=3000", "rd=10000", "rtt=40"}, cfg.dynamicCMSD(0, lossHang))
+}
+
+func TestCMSDHeaders(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	cases := []struct {
+		desc        string
+		url         string
+		wantStatic  string
+		wantDynamic string
+	}{
+		{
+			desc:        "mpd",
+			url:         "/livesim2/cmsd_5000;rtt=30/testpic_2s/Manifest.mpd?nowMS=100000",
+			wantStatic:  "ot=m,sf=d,st=l,v=1",
+			wantDynamic: `"livesim2";etp=5000;rtt=30`,
+		},
+		{
+			desc:        "video segment by number",
+			url:         "/livesim2/cmsd_5000/testpic_2s/V300/49.m4s?nowMS=100000",
+			wantStatic:  `ot=v,sf=d,st=l,at=100000,d=2000,nor="50.m4s",v=1`,
+			wantDynamic: `"livesim2";etp=5000`,
+		},
+		{
+			desc:        "video segment by time",
+			url:         "/livesim2/segtimeline_1/cmsd_5000;held=100/testpic_2s/V300/8100000.m4s?nowMS=100000",
+			wantStatic:  `ot=v,sf=d,st=l,at=92000,d=2000,nor="8280000.m4s",ht=100,v=1`,
+			wantDynamic: `"livesim2";etp=5000`,
+		},
+		{
+			desc:        "init segment",
+			url:         "/livesim2/cmsd_5000;id=edge/testpic_2s/V300/init.mp4?nowMS=100000",
+			wantStatic:  "ot=i,sf=d,st=l,v=1",
+			wantDynamic: `"edge";etp=5000`,
+		},
+		{
+			desc:        "traffic down",
+			url:         "/livesim2/traffic_d60/cmsd_5000/testpic_2s/bu0/V300/49.m4s?nowMS=100000",
+			wantStatic:  `ot=v,sf=d,st=l,at=100000,d=2000,nor="50.m4s",v=1`,
+			wantDynamic: `"livesim2";etp=5000`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			resp, _ := testFullRequest(t, ts, "GET", c.url, nil)
+			assert.Equal(t, c.wantStatic, resp.Header.Get("CMSD-Static"))
+			assert.Equal(t, c.wantDynamic, resp.Header.Get("CMSD-Dynamic"))
+		})
+	}
+}
+
+func TestThrottledWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newThrottledWriter(rec, 640) // 80 kB/s
+	_, isThrottled := w.(*throttledWriter)
+	require.True(t, isThrottled)
+	_, ok := w.(http.Flusher)
+	require.True(t, ok, "must stay a Flusher for chunked responses")
+	start := time.Now()
+	n, err := w.Write(make([]byte, 8000))
+	require.NoError(t, err)
+	assert.Equal(t, 8000, n)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Equal(t, rec, newThrottledWriter(rec, 0), "no limit")
+}
@@ -68,6 +68,10 @@ type ServerConfig struct {
 	PlayURL    string         `json:"playurl"`
 	DrmCfgFile string         `json:"drmcfgfile"`
 	DrmCfg     *drm.DrmConfig `json:"drmcfg"`
+	// ClockAPI enables the virtual-clock admin API (/api/clock) that moves the server time
+	ClockAPI bool `json:"clockapi"`
+	// NtpPort is the UDP port of the built-in NTP/SNTP server used by timesrv_ (0 disables it)
+	NtpPort int `json:"ntpport"`
 }
 
 var DefaultConfig = ServerConfig{
@@ -137,6 +141,8 @@ func LoadConfig(args []string, cwd string) (*ServerConfig, error) {
 			"If empty, auto-detected from the request (honors X-Forwarded-Proto)")
 	f.String("playurl", k.String("playurl"), "URL template to play mpd. %s will be replaced by MPD URL")
 	f.String("drmcfgfile", k.String("drmcfgfile"), "DRM config file path")
+	f.Bool("clockapi", k.Bool("clockapi"), "enable the virtual-clock admin API /api/clock (not for public servers)")
+	f.Int("ntpport", k.Int("ntpport"), "UDP port of the built-in NTP/SNTP server for timesrv_ UTCTiming (0 is off)")
 
 	if err := f.Parse(args[1:]); err != nil {
 		return nil, fmt.Errorf("command line parse: %w", err)
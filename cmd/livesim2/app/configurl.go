@@ -19,6 +19,7 @@ type liveMPDType int
 
 const (
 	MAX_TIME_SHIFT_BUFFER_DEPTH_S = 48 * 3600
+	maxDriftPPM                   = 100_000 // 10%; 29.97 fps content labeled as 30 fps is -999ppm
 )
 
 const (
@@ -97,57 +98,82 @@ const (
 )
 
 type ResponseConfig struct {
-	URLParts                     []string          `json:"-"`
-	URLContentIdx                int               `json:"-"`
-	UTCTimingMethods             []UTCTimingMethod `json:"UTCTimingMethods,omitempty"`
-	PeriodDurations              []int             `json:"PeriodDurations,omitempty"`
-	StartTimeS                   int               `json:"StartTimeS"`
-	StopTimeS                    *int              `json:"StopTimeS,omitempty"`
-	TimeOffsetS                  *float64          `json:"TimeOffsetS,omitempty"`
-	InitSegAvailOffsetS          *int              `json:"InitSegAvailOffsetS,omitempty"`
-	TimeShiftBufferDepthS        *int              `json:"TimeShiftBufferDepthS,omitempty"`
-	MinimumUpdatePeriodS         *int              `json:"MinimumUpdatePeriodS,omitempty"`
-	PeriodsPerHour               *int              `json:"PeriodsPerHour,omitempty"`
-	XlinkPeriods                 bool              `json:"XlinkPeriods,omitempty"`
-	XlinkPeriodsPerHour          *int              `json:"XlinkPeriodsPerHour,omitempty"`
-	PeriodId                     string            `json:"PeriodId,omitempty"`
-	EtpPeriodsPerHour            *int              `json:"EtpPeriodsPerHour,omitempty"`
-	EtpDuration                  *int              `json:"EtpDuration,omitempty"`
-	PeriodOffset                 *int              `json:"PeriodOffset,omitempty"`
-	SCTE35PerMinute              *int              `json:"SCTE35PerMinute,omitempty"`
-	StartNr                      *uint32           `json:"StartNr,omitempty"`
-	SuggestedPresentationDelayS  *int              `json:"SuggestedPresentationDelayS,omitempty"`
-	AvailabilityTimeOffsetS      float64           `json:"AvailabilityTimeOffsetS,omitempty"`
-	ChunkDurS                    *float64          `json:"ChunkDurS,omitempty"`
-	LatencyTargetMS              *uint32           `json:"LatencyTargetMS,omitempty"`
-	AddLocationFlag              bool              `json:"AddLocationFlag,omitempty"`
-	Tfdt32Flag                   bool              `json:"Tfdt32Flag,omitempty"`
-	ContUpdateFlag               bool              `json:"ContUpdateFlag,omitempty"`
-	InsertAdFlag                 bool              `json:"InsertAdFlag,omitempty"`
-	ContMultiPeriodFlag          bool              `json:"ContMultiPeriodFlag,omitempty"`
-	SegTimelineMode              SegTimelineMode   `json:"SegTimelineMode,omitempty"`
-	SidxFlag                     bool              `json:"SidxFlag,omitempty"`
-	SegTimelineLossFlag          bool              `json:"SegTimelineLossFlag,omitempty"`
-	AvailabilityTimeCompleteFlag bool              `json:"AvailabilityTimeCompleteFlag,omitempty"`
-	TimeSubsStpp                 []string          `json:"TimeSubsStppLanguages,omitempty"`
-	TimeSubsWvtt                 []string          `json:"TimeSubsWvttLanguages,omitempty"`
-	TimeSubsDurMS                int               `json:"TimeSubsDurMS,omitempty"`
-	TimeSubsRegion               int               `json:"TimeSubsRegion,omitempty"`
-	CC608                        *CC608Config      `json:"CC608,omitempty"`
-	Host                         string            `json:"Host,omitempty"`
-	PatchTTL                     int               `json:"Patch,omitempty"`
-	DRM                          string            `json:"DRM,omitempty"` // Includes ECCP as eccp-cbcs or eccp-cenc
-	SegStatusCodes               []SegStatusCodes  `json:"SegStatus,omitempty"`
-	Traffic                      []LossItvls       `json:"Traffic,omitempty"`
-	Query                        *Query            `json:"Query,omitempty"`
-	SSRFlag                      bool              `json:"SSRFlag,omitempty"`
-	SSRAS                        string            `json:"SSRAS,omitempty"`
-	ChunkDurSSR                  string            `json:"ChunkDurSSR,omitempty"`
-	SGAI                         *SGAIConfig       `json:"SGAI,omitempty"`
-	Steer                        *SteeringConfig   `json:"Steer,omitempty"`
-	SteerLocation                string            `json:"-"` // service location of a steered segment request (cdn_ path token)
-	SteerSessionID               string            `json:"-"` // content-steering session id (sid_ path token or ?sessionId=)
-	SteerCSID                    string            `json:"-"` // content-steering group id (csid_ path token); shared group decision
+	URLParts                     []string             `json:"-"`
+	URLContentIdx                int                  `json:"-"`
+	UTCTimingMethods             []UTCTimingMethod    `json:"UTCTimingMethods,omitempty"`
+	PeriodDurations              []int                `json:"PeriodDurations,omitempty"`
+	StartTimeS                   int                  `json:"StartTimeS"`
+	StopTimeS                    *int                 `json:"StopTimeS,omitempty"`
+	TimeOffsetS                  *float64             `json:"TimeOffsetS,omitempty"`
+	DriftPPM                     *float64             `json:"DriftPPM,omitempty"`
+	Restart                      *RestartConfig       `json:"Restart,omitempty"`
+	SegDurs                      *SegDursConfig       `json:"SegDurs,omitempty"`
+	PubJitter                    *PubJitterConfig     `json:"PubJitter,omitempty"`
+	AVSync                       *AVSyncConfig        `json:"AVSync,omitempty"`
+	InitSegAvailOffsetS          *int                 `json:"InitSegAvailOffsetS,omitempty"`
+	TimeShiftBufferDepthS        *int                 `json:"TimeShiftBufferDepthS,omitempty"`
+	MinimumUpdatePeriodS         *int                 `json:"MinimumUpdatePeriodS,omitempty"`
+	PeriodsPerHour               *int                 `json:"PeriodsPerHour,omitempty"`
+	XlinkPeriods                 bool                 `json:"XlinkPeriods,omitempty"`
+	XlinkPeriodsPerHour          *int                 `json:"XlinkPeriodsPerHour,omitempty"`
+	PeriodId                     string               `json:"PeriodId,omitempty"`
+	EtpPeriodsPerHour            *int                 `json:"EtpPeriodsPerHour,omitempty"`
+	EtpDuration                  *int                 `json:"EtpDuration,omitempty"`
+	PeriodOffset                 *int                 `json:"PeriodOffset,omitempty"`
+	SCTE35PerMinute              *int                 `json:"SCTE35PerMinute,omitempty"`
+	SCTE35Schedule               *scte35.Schedule     `json:"SCTE35Schedule,omitempty"`
+	SCTE35Mode                   string               `json:"SCTE35Mode,omitempty"`
+	Events                       []*EventStreamConfig `json:"Events,omitempty"`
+	ID3                          string               `json:"ID3,omitempty"`
+	MPDEventsFlag                bool                 `json:"MPDEventsFlag,omitempty"`
+	PrftDelayMS                  *int                 `json:"PrftDelayMS,omitempty"`
+	TrickPlayFlag                bool                 `json:"TrickPlayFlag,omitempty"`
+	TimeThumbs                   *TimeThumbsConfig    `json:"TimeThumbs,omitempty"`
+	BeepHz                       *int                 `json:"BeepHz,omitempty"`
+	Ladder                       []int                `json:"Ladder,omitempty"`
+	RepChange                    *RepChangeConfig     `json:"RepChange,omitempty"`
+	ASFilter                     []MPDSelector        `json:"ASFilter,omitempty"`
+	RepsFilter                   []MPDSelector        `json:"RepsFilter,omitempty"`
+	StartNr                      *uint32              `json:"StartNr,omitempty"`
+	SuggestedPresentationDelayS  *int                 `json:"SuggestedPresentationDelayS,omitempty"`
+	AvailabilityTimeOffsetS      float64              `json:"AvailabilityTimeOffsetS,omitempty"`
+	ChunkDurS                    *float64             `json:"ChunkDurS,omitempty"`
+	LatencyTargetMS              *uint32              `json:"LatencyTargetMS,omitempty"`
+	AddLocationFlag              bool                 `json:"AddLocationFlag,omitempty"`
+	Tfdt32Flag                   bool                 `json:"Tfdt32Flag,omitempty"`
+	ContUpdateFlag               bool                 `json:"ContUpdateFlag,omitempty"`
+	InsertAdFlag                 bool                 `json:"InsertAdFlag,omitempty"`
+	ContMultiPeriodFlag          bool                 `json:"ContMultiPeriodFlag,omitempty"`
+	SegTimelineMode              SegTimelineMode      `json:"SegTimelineMode,omitempty"`
+	SidxFlag                     bool                 `json:"SidxFlag,omitempty"`
+	SegTimelineLossFlag          bool                 `json:"SegTimelineLossFlag,omitempty"`
+	AvailabilityTimeCompleteFlag bool                 `json:"AvailabilityTimeCompleteFlag,omitempty"`
+	TimeSubsStpp                 []string             `json:"TimeSubsStppLanguages,omitempty"`
+	TimeSubsWvtt                 []string             `json:"TimeSubsWvttLanguages,omitempty"`
+	TimeSubsDurMS                int                  `json:"TimeSubsDurMS,omitempty"`
+	TimeSubsRegion               int                  `json:"TimeSubsRegion,omitempty"`
+	CC608                        *CC608Config         `json:"CC608,omitempty"`
+	Host                         string               `json:"Host,omitempty"`
+	PatchTTL                     int                  `json:"Patch,omitempty"`
+	DRM                          string               `json:"DRM,omitempty"` // Includes ECCP as eccp-cbcs or eccp-cenc
+	SegStatusCodes               []SegStatusCodes     `json:"SegStatus,omitempty"`
+	Traffic                      []LossItvls          `json:"Traffic,omitempty"`
+	Query                        *Query               `json:"Query,omitempty"`
+	SSRFlag                      bool                 `json:"SSRFlag,omitempty"`
+	SSRAS                        string               `json:"SSRAS,omitempty"`
+	ChunkDurSSR                  string               `json:"ChunkDurSSR,omitempty"`
+	SGAI                         *SGAIConfig          `json:"SGAI,omitempty"`
+	SSAI                         *SSAIConfig          `json:"SSAI,omitempty"`
+	Steer                        *SteeringConfig      `json:"Steer,omitempty"`
+	CMSD                         *CMSDConfig          `json:"CMSD,omitempty"`
+	TimeSrv                      *TimeSrvConfig       `json:"TimeSrv,omitempty"`
+	SteerLocation                string               `json:"-"` // service location of a steered segment request (cdn_ path token)
+	SteerSessionID               string               `json:"-"` // content-steering session id (sid_ path token or ?sessionId=)
+	SteerCSID                    string               `json:"-"` // content-steering group id (csid_ path token); shared group decision
+	RestartInc                   *int                 `json:"-"` // encoder incarnation of a segment request (rst_ path token)
+	ChannelPeriod                *int                 `json:"-"` // channel Period of a segment request (chp_ path token)
+	SSAIAdStartS                 *int                 `json:"-"` // start of the ad Period of a segment request (adp_ path token)
+	NTPServer                    string               `json:"-"` // address of the built-in NTP server (timesrv_), empty if not running
 }
 
 // SegStatusCodes configures regular extraordinary segment response codes
@@ -312,11 +338,28 @@ func (rc *ResponseConfig) HasSegmentTimelineTime() bool {
 	return rc.SegTimelineMode != SegTimelineModeNone
 }
 
+// driftedNowMS returns the time of a media clock that has drifted DriftPPM ppm from the
+// wall-clock since availabilityStartTime. All segment and timeline calculations use it, so
+// the media timeline is stretched or compressed relative to availabilityStartTime.
+func (rc *ResponseConfig) driftedNowMS(nowMS int) int {
+	if rc.DriftPPM == nil {
+		return nowMS
+	}
+	elapsedMS := nowMS - rc.StartTimeS*1000
+	return nowMS + int(math.Round(float64(elapsedMS)**rc.DriftPPM*1e-6))
+}
+
 // getAvailabilityTimeOffset returns the availabilityTimeOffsetS. Note that it can be infinite.
 func (rc *ResponseConfig) getAvailabilityTimeOffsetS() float64 {
 	return rc.AvailabilityTimeOffsetS
 }
 
+// lowLatency returns true if the segments are made available before their end, in chunks
+// (chunkdur_ or ato_) or as sub-segments (ssras_).
+func (rc *ResponseConfig) lowLatency() bool {
+	return rc.ChunkDurS != nil || rc.AvailabilityTimeOffsetS > 0 || rc.SSRFlag
+}
+
 // getStartNr for MPD. Default value if not set is 1.
 func (rc *ResponseConfig) getStartNr() uint32 {
 	// Default startNr is 1 according to spec, but can be overridden by actual value set in cfg.
@@ -366,6 +409,20 @@ cfgLoop:
 			cfg.PeriodDurations = append(cfg.PeriodDurations, sc.Atoi(key, val))
 		case "timeoffset": //Time offset in seconds versus NTP
 			cfg.TimeOffsetS = sc.Atof(key, val)
+		case "drift": // Media clock drift in ppm versus wall-clock, accumulated since availabilityStartTime
+			cfg.DriftPPM = sc.Atof(key, val)
+		case "restart": // Encoder restarts: <cycleS>[;gap=<s>;snr=0|1;mode=period|cont]
+			cfg.Restart = sc.ParseRestartConfig(key, val)
+		case "rst": // encoder incarnation of a segment (set in generated BaseURLs)
+			cfg.RestartInc = sc.AtoiPtr(key, val)
+		case "chp": // channel Period of a segment (set in generated BaseURLs)
+			cfg.ChannelPeriod = sc.AtoiPtr(key, val)
+		case "segdurs": // Variable segment durations: <d1>,<d2>,... or rand[;min=<s>;max=<s>;seed=<n>]
+			cfg.SegDurs = sc.ParseSegDursConfig(key, val)
+		case "pubjitter": // Segment publish delays: <ms>,<ms>,... or rand[;min=<ms>;max=<ms>;seed=<n>], [;code=404|425]
+			cfg.PubJitter = sc.ParsePubJitterConfig(key, val)
+		case "avsync": // Audio offset versus video: <ms>[;drift=<ms per hour>]
+			cfg.AVSync = sc.ParseAVSyncConfig(key, val)
 		case "init": // Make the init segment available earlier
 			cfg.InitSegAvailOffsetS = sc.AtoiPtr(key, val)
 		case "tsbd": // Timeshift Buffer Depth
@@ -411,8 +468,36 @@ cfgLoop:
 			}
 		case "peroff": // Set the period offset
 			cfg.PeriodOffset = sc.AtoiPtr(key, val)
-		case "scte35": // Signal this many SCTE-35 ad periods inband (emsg messages) every minute
-			cfg.SCTE35PerMinute = sc.AtoiPtr(key, val)
+		case "scte35": // SCTE-35 inband (emsg): 1-3 ad periods per minute or <start>:<dur>[:mod...][,...][;cycle=s;lead=s]
+			if strings.Contains(val, ":") {
+				cfg.SCTE35Schedule = sc.ParseSCTE35Schedule(key, val)
+			} else {
+				cfg.SCTE35PerMinute = sc.AtoiPtr(key, val)
+			}
+		case "scte35mode": // SCTE-35 carriage: inband (emsg, default), mpd (EventStream) or both
+			cfg.SCTE35Mode = val
+		case "events": // Generic DASH events: scheme=<uri>;period=<s>;dur=<s>;data=<tmpl>;mode=mpd|emsg0|emsg1[,...]
+			cfg.Events = sc.ParseEventsConfig(key, val)
+		case "id3": // Timed ID3 metadata: emsg (in video segments), track (metadata AdaptationSet) or both
+			cfg.ID3 = val
+		case "mpdevents": // In-band MPD validity expiration (and patch) events when the MPD changes
+			cfg.MPDEventsFlag = true
+		case "prft": // In-band prft boxes with an encoder-to-origin delay in ms
+			cfg.PrftDelayMS = sc.AtoiPtr(key, val)
+		case "trickplay": // Trick-mode video AdaptationSet with the sync samples of the video
+			cfg.TrickPlayFlag = true
+		case "timethumbs": // Generated thumbnail tiles <cols>x<rows> showing UTC time and segment number
+			cfg.TimeThumbs = sc.ParseTimeThumbsConfig(key, val)
+		case "beep": // Generated LPCM audio with a <hz> beep at every full second
+			cfg.BeepHz = sc.AtoiPtr(key, val)
+		case "ladder": // Synthetic video bitrate ladder <kbps>-<kbps>... cloned from the first video rep
+			cfg.Ladder = sc.ParseLadderConfig(key, val)
+		case "repchange": // Representations dropped <cycleS>;drop=<id>,<id>...[;dur=<s>;mode=mpd|period]
+			cfg.RepChange = sc.ParseRepChangeConfig(key, val)
+		case "as": // Select, drop, and reorder AdaptationSets by [!][id|codec|type|lang:]<value>,...
+			cfg.ASFilter = sc.ParseMPDFilter(key, val)
+		case "reps": // Select, drop, and reorder Representations by [!][id|codec|type|lang:]<value>,...
+			cfg.RepsFilter = sc.ParseMPDFilter(key, val)
 		case "utc": // Get hyphen-separated list of utc-timing methods and make into list
 			cfg.UTCTimingMethods = sc.SplitUTCTimings(key, val)
 		case "snr": // Segment startNumber. -1 means default implicit number which ==  1
@@ -462,8 +547,16 @@ cfgLoop:
 			cfg.ChunkDurSSR = val
 		case "sgai": // Ed.6 Alternative-MPD Replace ad breaks: <off>:<dur>[,...][;k=v...]
 			cfg.SGAI = sc.ParseSGAIConfig(key, val)
+		case "ssai": // Server-side ad insertion: (<off>:<dur>[,...] | p<period>:<dur>)[;vgap=<ms>;agap=<ms>]
+			cfg.SSAI = sc.ParseSSAIConfig(key, val)
+		case "adp": // SSAI ad Period start of a segment (set in generated BaseURLs)
+			cfg.SSAIAdStartS = sc.AtoiPtr(key, val)
 		case "steer": // DASH Content Steering: <loc1>,<loc2>[,...][;ttl=s;mode=rotate|trigger;qbs=0|1;default=loc]
 			cfg.Steer = sc.ParseSteeringConfig(key, val)
+		case "cmsd": // CMSD response headers: <etp>[;mb=..;rtt=..;held=..;limit=0|1;nor=0|1;id=..]
+			cfg.CMSD = sc.ParseCMSDConfig(key, val)
+		case "timesrv": // built-in time service for UTCTiming: <skewMS>[;jitter=..;leap=..;nextleap=..;leapat=..]
+			cfg.TimeSrv = sc.ParseTimeSrvConfig(key, val)
 		case "cdn": // service location of a steered segment request (set in generated BaseURLs)
 			cfg.SteerLocation = val
 		case "sid": // content-steering session id (set in generated BaseURLs)
@@ -512,6 +605,15 @@ func verifyAndFillConfig(cfg *ResponseConfig, nowMS int) error {
 			return fmt.Errorf("timeShiftBufferDepth %ds is not less than %ds", tsbd, MAX_TIME_SHIFT_BUFFER_DEPTH_S)
 		}
 	}
+	if cfg.DriftPPM != nil {
+		if math.Abs(*cfg.DriftPPM) > maxDriftPPM {
+			return fmt.Errorf("drift %gppm is not within ±%dppm", *cfg.DriftPPM, maxDriftPPM)
+		}
+		// Drift accumulates from availabilityStartTime, so an epoch start would give a huge offset.
+		if cfg.StartTimeS <= 0 {
+			return fmt.Errorf("drift needs an availabilityStartTime after epoch (use start_ or startrel_)")
+		}
+	}
 	if cfg.ContMultiPeriodFlag && cfg.PeriodsPerHour == nil {
 		return fmt.Errorf("period continuity set, but not multiple periods per hour")
 	}
@@ -521,6 +623,60 @@ func verifyAndFillConfig(cfg *ResponseConfig, nowMS int) error {
 			return err
 		}
 	}
+	if cfg.SCTE35Mode != "" {
+		switch cfg.SCTE35Mode {
+		case scte35ModeInband, scte35ModeMPD, scte35ModeBoth:
+		default:
+			return fmt.Errorf("scte35mode %q: must be inband, mpd or both", cfg.SCTE35Mode)
+		}
+		if cfg.SCTE35PerMinute == nil && cfg.SCTE35Schedule == nil {
+			return fmt.Errorf("scte35mode needs scte35")
+		}
+		if cfg.SCTE35PerMinute != nil && cfg.SCTE35Mode != scte35ModeInband {
+			// Generate the fixed layouts from a schedule, so both carriages have the same events.
+			// In-band only, the legacy emsg generator is kept.
+			cfg.SCTE35Schedule, _ = scte35.PerMinuteSchedule(*cfg.SCTE35PerMinute)
+			cfg.SCTE35PerMinute = nil
+		}
+		if cfg.SCTE35Mode != scte35ModeInband && cfg.Restart != nil {
+			return fmt.Errorf("scte35mode %s cannot be combined with restart", cfg.SCTE35Mode)
+		}
+	}
+	if cfg.PrftDelayMS != nil && *cfg.PrftDelayMS < 0 {
+		return fmt.Errorf("prft delay %dms must not be negative", *cfg.PrftDelayMS)
+	}
+	if cfg.BeepHz != nil {
+		if err := checkBeepHz(*cfg.BeepHz); err != nil {
+			return err
+		}
+	}
+	if cfg.TrickPlayFlag && cfg.lowLatency() {
+		// The trick-mode segments are generated whole from the source segments.
+		return fmt.Errorf("trickplay cannot be combined with chunkdur/ato/ssras")
+	}
+	if len(cfg.Ladder) > 0 {
+		if cfg.TrickPlayFlag {
+			return fmt.Errorf("ladder cannot be combined with trickplay")
+		}
+		if cfg.lowLatency() {
+			// The rung segments are padded whole.
+			return fmt.Errorf("ladder cannot be combined with chunkdur/ato/ssras")
+		}
+	}
+	if cfg.RepChange != nil {
+		if cfg.PeriodsPerHour != nil || cfg.XlinkPeriodsPerHour != nil || cfg.EtpPeriodsPerHour != nil ||
+			cfg.InsertAdFlag || cfg.SGAI != nil || cfg.SSAI != nil || cfg.Restart != nil {
+			return fmt.Errorf("repchange cannot be combined with periods/xlink/etp/insertad/sgai/ssai/restart")
+		}
+	}
+	switch cfg.ID3 {
+	case "", id3ModeEmsg, id3ModeTrack, id3ModeBoth:
+	default:
+		return fmt.Errorf("id3 %q: must be emsg, track or both", cfg.ID3)
+	}
+	if len(cfg.Events) > 0 && cfg.Restart != nil {
+		return fmt.Errorf("events cannot be combined with restart")
+	}
 	// We do not check here that the drm is one that has been configured,
 	// since pre-encrypted content will influence what is valid.
 
@@ -540,6 +696,60 @@ func verifyAndFillConfig(cfg *ResponseConfig, nowMS int) error {
 			return fmt.Errorf("sgai cannot be combined with periods/xlink/etp/insertad")
 		}
 	}
+	if cfg.Restart != nil {
+		if cfg.PeriodsPerHour != nil || cfg.XlinkPeriodsPerHour != nil || cfg.EtpPeriodsPerHour != nil ||
+			cfg.InsertAdFlag || cfg.SGAI != nil || cfg.Steer != nil || len(cfg.Traffic) > 0 ||
+			cfg.PatchTTL > 0 || cfg.StopTimeS != nil {
+			return fmt.Errorf("restart cannot be combined with periods/xlink/etp/insertad/sgai/steer/traffic/patch/stop")
+		}
+		if cfg.SegTimelineMode == SegTimelineModeNr || cfg.SegTimelineMode == SegTimelineModeNrPattern {
+			return fmt.Errorf("restart does not support segtimelinenr")
+		}
+	}
+	if cfg.SegDurs != nil {
+		if cfg.liveMPDType() == segmentNumber {
+			return fmt.Errorf("segdurs needs a SegmentTimeline (segtimeline_ or segtimelinenr_)")
+		}
+		if cfg.PeriodsPerHour != nil || cfg.XlinkPeriodsPerHour != nil || cfg.EtpPeriodsPerHour != nil ||
+			cfg.InsertAdFlag || cfg.SGAI != nil {
+			return fmt.Errorf("segdurs cannot be combined with periods/xlink/etp/insertad/sgai")
+		}
+	}
+	if cfg.AVSync != nil {
+		if cfg.liveMPDType() == segmentNumber {
+			return fmt.Errorf("avsync needs a SegmentTimeline (segtimeline_ or segtimelinenr_)")
+		}
+		if cfg.SegTimelineMode == SegTimelineModePattern || cfg.SegTimelineMode == SegTimelineModeNrPattern {
+			return fmt.Errorf("avsync does not support SegmentTimeline patterns")
+		}
+		if cfg.PeriodsPerHour != nil || cfg.XlinkPeriodsPerHour != nil || cfg.EtpPeriodsPerHour != nil ||
+			cfg.InsertAdFlag || cfg.SGAI != nil {
+			return fmt.Errorf("avsync cannot be combined with periods/xlink/etp/insertad/sgai")
+		}
+	}
+	if cfg.SSAI != nil {
+		if cfg.SSAI.SCTE35 && cfg.SCTE35Schedule == nil {
+			return fmt.Errorf("ssai_scte35 needs a scte35_ schedule")
+		}
+		if cfg.SegTimelineMode == SegTimelineModePattern || cfg.SegTimelineMode == SegTimelineModeNrPattern {
+			return fmt.Errorf("ssai does not support SegmentTimeline patterns")
+		}
+		if (cfg.SSAI.VideoGapMS > 0 || cfg.SSAI.AudioGapMS > 0) && cfg.liveMPDType() == segmentNumber {
+			return fmt.Errorf("ssai vgap/agap need a SegmentTimeline (segtimeline_ or segtimelinenr_)")
+		}
+		if cfg.PeriodsPerHour != nil || cfg.XlinkPeriodsPerHour != nil || cfg.EtpPeriodsPerHour != nil ||
+			cfg.InsertAdFlag || cfg.SGAI != nil || cfg.Restart != nil || cfg.SegDurs != nil ||
+			cfg.AVSync != nil || cfg.PatchTTL > 0 {
+			return fmt.Errorf("ssai cannot be combined with periods/xlink/etp/insertad/sgai/restart/segdurs/avsync/patch")
+		}
+		if cfg.MPDEventsFlag {
+			// The SSAI MPD is per session, so the MPD changes cannot be found from a segment request.
+			return fmt.Errorf("ssai cannot be combined with mpdevents")
+		}
+	}
+	if cfg.SSAIAdStartS != nil && cfg.SSAI == nil {
+		return fmt.Errorf("adp needs ssai")
+	}
 	if cfg.Steer != nil {
 		if len(cfg.Steer.CDNs) < 2 {
 			return fmt.Errorf("steer needs at least two service locations")
@@ -392,3 +392,20 @@ func TestFullHost(t *testing.T) {
 		})
 	}
 }
+
+func TestDriftedNowMS(t *testing.T) {
+	cfg := NewResponseConfig()
+	cfg.StartTimeS = 1000
+	assert.Equal(t, 2_000_000, cfg.driftedNowMS(2_000_000))
+	cfg.DriftPPM = Ptr(-999.0)
+	assert.Equal(t, 2_000_000-999, cfg.driftedNowMS(2_000_000))
+	assert.Equal(t, 1_000_000, cfg.driftedNowMS(1_000_000))
+	cfg.DriftPPM = Ptr(150.0)
+	assert.Equal(t, 1_000_000+86_400_000+12_960, cfg.driftedNowMS(1_000_000+86_400_000))
+	require.NoError(t, verifyAndFillConfig(cfg, 2_000_000))
+	cfg.DriftPPM = Ptr(200_000.0)
+	require.ErrorContains(t, verifyAndFillConfig(cfg, 2_000_000), "not within")
+	cfg.DriftPPM = Ptr(10.0)
+	cfg.StartTimeS = 0
+	require.ErrorContains(t, verifyAndFillConfig(cfg, 2_000_000), "after epoch")
+}
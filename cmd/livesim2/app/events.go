@@ -0,0 +1,323 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/scte35"
+)
+
+// Generic DASH events (events_) are generated from event stream configurations. Each stream
+// has a scheme and value, and periodic events with a duration and a messageData template.
+// The events are carried in an MPD EventStream or in-band as emsg boxes (version 0 or 1) in
+// the video segments, signaled by an InbandEventStream.
+//
+// An event is announced lead seconds before its presentation time: it appears in the MPD
+// from that time, and its emsg is sent in the segment covering that time. MPD events are
+// removed once they have ended before the start of the time-shift window.
+
+// Carriage modes of an event stream.
+const (
+	eventsModeMPD   = "mpd"
+	eventsModeEmsg0 = "emsg0"
+	eventsModeEmsg1 = "emsg1"
+)
+
+const (
+	minEventsPeriodMS     = 1000
+	defaultEventTimescale = 1000
+)
+
+// EventStreamConfig configures one stream of generated DASH events.
+type EventStreamConfig struct {
+	Scheme     string `json:"scheme"`
+	Value      string `json:"value,omitempty"`
+	StartMS    int    `json:"startMS"`            // first event, relative to availabilityStartTime
+	PeriodMS   int    `json:"periodMS,omitempty"` // 0 for a single event
+	DurationMS int    `json:"durationMS,omitempty"`
+	LeadMS     int    `json:"leadMS,omitempty"`
+	Timescale  uint32 `json:"timescale"`
+	Data       string `json:"data,omitempty"` // messageData template with $Id$, $Time$ and $Segment$
+	Mode       string `json:"mode"`           // mpd, emsg0 or emsg1
+}
+
+// CreateEventsConfig parses <stream>[,<stream>...] where a stream is a list of
+// key=val parameters separated by ';' with keys scheme (mandatory), value, start, period,
+// dur, lead (seconds), ts (timescale), data (messageData template) and mode (mpd, emsg0, emsg1).
+func CreateEventsConfig(val string) ([]*EventStreamConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty events config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("events config %q has extra spaces", val)
+	}
+	var streams []*EventStreamConfig
+	for i, sv := range strings.Split(val, ",") {
+		es := EventStreamConfig{Timescale: defaultEventTimescale, Mode: eventsModeMPD}
+		for _, kv := range strings.Split(sv, ";") {
+			key, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return nil, fmt.Errorf("events param %q must be key=val", kv)
+			}
+			var err error
+			switch key {
+			case "scheme":
+				es.Scheme = v
+			case "value":
+				es.Value = v
+			case "start":
+				es.StartMS, err = scte35.ParseMS(v)
+			case "period":
+				es.PeriodMS, err = scte35.ParseMS(v)
+			case "dur":
+				es.DurationMS, err = scte35.ParseMS(v)
+			case "lead":
+				es.LeadMS, err = scte35.ParseMS(v)
+			case "ts":
+				var ts uint64
+				ts, err = strconv.ParseUint(v, 10, 32)
+				if err == nil && ts == 0 {
+					err = fmt.Errorf("%q must be positive", v)
+				}
+				es.Timescale = uint32(ts)
+			case "data":
+				es.Data = v
+			case "mode":
+				switch v {
+				case eventsModeMPD, eventsModeEmsg0, eventsModeEmsg1:
+					es.Mode = v
+				default:
+					err = fmt.Errorf("%q must be mpd, emsg0 or emsg1", v)
+				}
+			default:
+				return nil, fmt.Errorf("unknown events param %q", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("events %s: %w", key, err)
+			}
+		}
+		if es.Scheme == "" {
+			return nil, fmt.Errorf("events stream %d: scheme is mandatory", i)
+		}
+		if es.PeriodMS != 0 && es.PeriodMS < minEventsPeriodMS {
+			return nil, fmt.Errorf("events stream %d: period must be at least %dms", i, minEventsPeriodMS)
+		}
+		if es.PeriodMS != 0 && es.DurationMS > es.PeriodMS {
+			return nil, fmt.Errorf("events stream %d: duration longer than period", i)
+		}
+		streams = append(streams, &es)
+	}
+	return streams, nil
+}
+
+// ParseEventsConfig parses an events option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseEventsConfig(key, val string) []*EventStreamConfig {
+	if s.err != nil {
+		return nil
+	}
+	streams, err := CreateEventsConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return streams
+}
+
+// inBand returns true if the events are sent as emsg boxes.
+func (es *EventStreamConfig) inBand() bool {
+	return es.Mode != eventsModeMPD
+}
+
+// dashEvent is one occurrence of an event stream.
+type dashEvent struct {
+	id     uint64
+	timeMS int // presentation time relative to availabilityStartTime
+	sendMS int // announcement time
+}
+
+// events returns the events announced before toMS that have not ended before fromMS.
+func (es *EventStreamConfig) events(fromMS, toMS int) []dashEvent {
+	var evs []dashEvent
+	add := func(n int) {
+		t := es.StartMS + n*es.PeriodMS
+		send := max(0, t-es.LeadMS)
+		if send < toMS && t+es.DurationMS >= fromMS {
+			evs = append(evs, dashEvent{id: uint64(n), timeMS: t, sendMS: send})
+		}
+	}
+	if es.PeriodMS == 0 {
+		add(0)
+		return evs
+	}
+	first := max(0, (fromMS-es.DurationMS-es.StartMS)/es.PeriodMS)
+	for n := first; es.StartMS+n*es.PeriodMS-es.LeadMS < toMS; n++ {
+		add(n)
+	}
+	return evs
+}
+
+// messageData returns the message of event e, with the template variables substituted.
+// $Segment$ is the number of the segment of segDurMS containing the event time.
+func (es *EventStreamConfig) messageData(e dashEvent, startNr uint32, segDurMS int) string {
+	if !strings.Contains(es.Data, "$") {
+		return es.Data
+	}
+	segNr := uint64(startNr)
+	if segDurMS > 0 {
+		segNr += uint64(e.timeMS / segDurMS)
+	}
+	r := strings.NewReplacer(
+		"$Id$", strconv.FormatUint(e.id, 10),
+		"$Time$", strconv.FormatUint(uint64(e.timeMS)*uint64(es.Timescale)/1000, 10),
+		"$Segment$", strconv.FormatUint(segNr, 10))
+	return r.Replace(es.Data)
+}
+
+// inbandEvents returns true if any event stream is carried in-band.
+func (rc *ResponseConfig) inbandEvents() bool {
+	for _, es := range rc.Events {
+		if es.inBand() {
+			return true
+		}
+	}
+	return false
+}
+
+// addInbandEventStreams signals the in-band event streams of cfg in AdaptationSet as.
+func addInbandEventStreams(as *m.AdaptationSetType, cfg *ResponseConfig) {
+	for _, es := range cfg.Events {
+		if es.inBand() {
+			as.InbandEventStreams = append(as.InbandEventStreams,
+				&m.EventStreamType{SchemeIdUri: m.AnyURI(es.Scheme), Value: es.Value})
+		}
+	}
+}
+
+// addDashEventStreams adds the events of the MPD event streams of cfg that are announced
+// at nowMS and not ended before the time-shift window.
+func addDashEventStreams(mpd *m.MPD, a *asset, cfg *ResponseConfig, nowMS int) {
+	fromMS, toMS := cfg.timeShiftWindowMS(nowMS)
+	for _, es := range cfg.Events {
+		if es.inBand() {
+			continue
+		}
+		pes := newPeriodEventStreams(mpd, func(periodStartMS int) *m.EventStreamType {
+			return &m.EventStreamType{
+				SchemeIdUri:            m.AnyURI(es.Scheme),
+				Value:                  es.Value,
+				Timescale:              Ptr(es.Timescale),
+				PresentationTimeOffset: uint64(periodStartMS) * uint64(es.Timescale) / 1000,
+			}
+		})
+		for _, e := range es.events(fromMS, toMS+1) {
+			pes.add(e.timeMS, &m.EventType{
+				PresentationTime: uint64(e.timeMS) * uint64(es.Timescale) / 1000,
+				Duration:         uint64(es.DurationMS) * uint64(es.Timescale) / 1000,
+				Id:               Ptr(e.id),
+				Value:            es.messageData(e, cfg.getStartNr(), a.SegmentDurMS),
+			})
+		}
+	}
+}
+
+// timeShiftWindowMS returns the start of the time-shift window and nowMS, both relative
+// to availabilityStartTime. Without timeShiftBufferDepth, the window starts at 0.
+func (rc *ResponseConfig) timeShiftWindowMS(nowMS int) (fromMS, toMS int) {
+	toMS = nowMS - rc.StartTimeS*1000
+	if rc.TimeShiftBufferDepthS != nil {
+		fromMS = max(0, toMS-*rc.TimeShiftBufferDepthS*1000)
+	}
+	return fromMS, toMS
+}
+
+// periodEventStreams puts the Events of one stream in an EventStream per Period. Each
+// Event goes to the Period containing its presentation time, and the EventStream of a
+// Period is created with the Period start as presentationTimeOffset.
+type periodEventStreams struct {
+	mpd       *m.MPD
+	newStream func(periodStartMS int) *m.EventStreamType
+	streams   map[*m.Period]*m.EventStreamType
+}
+
+func newPeriodEventStreams(mpd *m.MPD, newStream func(periodStartMS int) *m.EventStreamType) *periodEventStreams {
+	return &periodEventStreams{mpd: mpd, newStream: newStream, streams: make(map[*m.Period]*m.EventStreamType)}
+}
+
+// add adds e with presentation time timeMS relative to availabilityStartTime. Events before
+// the first Period are dropped.
+func (p *periodEventStreams) add(timeMS int, e *m.EventType) {
+	period, periodStartMS := periodAtMS(p.mpd, timeMS)
+	if period == nil {
+		return
+	}
+	es, ok := p.streams[period]
+	if !ok {
+		es = p.newStream(periodStartMS)
+		period.EventStreams = append(period.EventStreams, es)
+		p.streams[period] = es
+	}
+	es.Events = append(es.Events, e)
+}
+
+// periodAtMS returns the last Period starting at or before ms, and its start in milliseconds.
+// The Period is nil if ms is before the first Period.
+func periodAtMS(mpd *m.MPD, ms int) (*m.Period, int) {
+	var period *m.Period
+	var periodStartMS int
+	for _, p := range mpd.Periods {
+		startMS := 0
+		if p.Start != nil {
+			startMS = int(*p.Start / 1_000_000)
+		}
+		if startMS > ms {
+			break
+		}
+		period, periodStartMS = p, startMS
+	}
+	return period, periodStartMS
+}
+
+// dashEventEmsgs returns the emsg boxes of the in-band events announced during the segment
+// [startTime, endTime) in timescale. Version 0 boxes have times relative to the segment start.
+func dashEventEmsgs(a *asset, cfg *ResponseConfig, startTime, endTime, timescale uint64) []*mp4.EmsgBox {
+	fromMS, toMS := int(startTime*1000/timescale), int(endTime*1000/timescale)
+	var emsgs []*mp4.EmsgBox
+	for _, es := range cfg.Events {
+		if !es.inBand() {
+			continue
+		}
+		ts := uint64(es.Timescale)
+		for _, e := range es.events(fromMS, toMS) {
+			if e.sendMS < fromMS {
+				continue
+			}
+			emsg := &mp4.EmsgBox{
+				TimeScale:     es.Timescale,
+				EventDuration: uint32(uint64(es.DurationMS) * ts / 1000),
+				ID:            uint32(e.id),
+				SchemeIDURI:   es.Scheme,
+				Value:         es.Value,
+				MessageData:   []byte(es.messageData(e, cfg.getStartNr(), a.SegmentDurMS)),
+			}
+			if es.Mode == eventsModeEmsg1 {
+				emsg.Version = 1
+				emsg.PresentationTime = uint64(e.timeMS) * ts / 1000
+			} else {
+				eventTime, segTime := uint64(e.timeMS)*ts/1000, startTime*ts/timescale
+				if eventTime > segTime {
+					emsg.PresentationTimeDelta = uint32(eventTime - segTime)
+				}
+			}
+			emsgs = append(emsgs, emsg)
+		}
+	}
+	return emsgs
+}
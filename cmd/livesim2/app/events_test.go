@@ -0,0 +1,138 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateEventsConfig(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    []*EventStreamConfig
+		wantErr string
+	}{
+		{val: "scheme=urn:a;period=10;dur=2.5;data=x$Id$", want: []*EventStreamConfig{
+			{Scheme: "urn:a", PeriodMS: 10_000, DurationMS: 2500, Timescale: 1000, Data: "x$Id$", Mode: "mpd"}}},
+		{val: "scheme=urn:a;value=1;start=5;lead=3;ts=90000;mode=emsg0,scheme=urn:b;mode=emsg1", want: []*EventStreamConfig{
+			{Scheme: "urn:a", Value: "1", StartMS: 5000, LeadMS: 3000, Timescale: 90000, Mode: "emsg0"},
+			{Scheme: "urn:b", Timescale: 1000, Mode: "emsg1"}}},
+		{val: "", wantErr: "empty events config"},
+		{val: "period=10", wantErr: "scheme is mandatory"},
+		{val: "scheme=urn:a;period=0.5", wantErr: "period must be at least"},
+		{val: "scheme=urn:a;period=10;dur=20", wantErr: "duration longer than period"},
+		{val: "scheme=urn:a;mode=xml", wantErr: "must be mpd, emsg0 or emsg1"},
+		{val: "scheme=urn:a;ts=0", wantErr: "must be positive"},
+		{val: "scheme=urn:a;dur=-1", wantErr: "not a valid time"},
+		{val: "scheme=urn:a;foo=1", wantErr: "unknown events param"},
+		{val: "scheme=urn:a;period", wantErr: "must be key=val"},
+	}
+	for _, c := range cases {
+		got, err := CreateEventsConfig(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	getMPD := func(t *testing.T, url string) *m.MPD {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		return mpd
+	}
+
+	// Events every 10s, announced 3s ahead. The window at 100s is [40s, 100s], so the event
+	// at 30s has ended before it and the event at 100s is announced.
+	mpd := getMPD(t, "/livesim2/events_scheme=urn:test:ev;value=1;period=10;dur=2;lead=3;data=id$Id$-t$Time$-s$Segment$"+
+		"/testpic_2s/Manifest.mpd?nowMS=100000")
+	p := mpd.Periods[0]
+	require.Len(t, p.EventStreams, 1)
+	es := p.EventStreams[0]
+	assert.Equal(t, "urn:test:ev", string(es.SchemeIdUri))
+	assert.Equal(t, "1", es.Value)
+	assert.Equal(t, uint32(1000), *es.Timescale)
+	require.Len(t, es.Events, 7)
+	first := es.Events[0]
+	assert.Equal(t, uint64(4), *first.Id)
+	assert.Equal(t, uint64(40_000), first.PresentationTime)
+	assert.Equal(t, uint64(2000), first.Duration)
+	assert.Equal(t, "id4-t40000-s20", first.Value)
+	assert.Equal(t, uint64(100_000), es.Events[6].PresentationTime)
+	assert.Empty(t, p.AdaptationSets[1].InbandEventStreams)
+
+	// In-band events in the video segment covering the announcement time, 37s for the event at 40s.
+	for _, ver := range []int{0, 1} {
+		opt := "events_scheme=urn:test:ev;period=10;dur=2;lead=3;ts=90000;data=s$Segment$;mode=emsg" + strconv.Itoa(ver)
+		mpd = getMPD(t, "/livesim2/"+opt+"/testpic_2s/Manifest.mpd?nowMS=100000")
+		assert.Empty(t, mpd.Periods[0].EventStreams)
+		vAS := mpd.Periods[0].AdaptationSets[1]
+		require.Len(t, vAS.InbandEventStreams, 1)
+		assert.Equal(t, "urn:test:ev", string(vAS.InbandEventStreams[0].SchemeIdUri))
+		assert.Empty(t, mpd.Periods[0].AdaptationSets[0].InbandEventStreams, "only video carries in-band events")
+		resp, body := testFullRequest(t, ts, "GET", "/livesim2/"+opt+"/testpic_2s/A48/18.m4s?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		assert.Empty(t, f.Segments[0].Fragments[0].Emsgs)
+
+		for _, segNr := range []string{"18", "19"} {
+			resp, body := testFullRequest(t, ts, "GET", "/livesim2/"+opt+"/testpic_2s/V300/"+segNr+".m4s?nowMS=100000", nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			f, err := mp4.DecodeFile(bytes.NewReader(body))
+			require.NoError(t, err)
+			emsgs := f.Segments[0].Fragments[0].Emsgs
+			if segNr == "19" {
+				assert.Empty(t, emsgs)
+				continue
+			}
+			require.Len(t, emsgs, 1)
+			e := emsgs[0]
+			assert.Equal(t, uint8(ver), e.Version)
+			assert.Equal(t, uint32(90000), e.TimeScale)
+			assert.Equal(t, uint32(4), e.ID)
+			assert.Equal(t, uint32(2*90000), e.EventDuration)
+			assert.Equal(t, "s20", string(e.MessageData))
+			if ver == 1 {
+				assert.Equal(t, uint64(40*90000), e.PresentationTime)
+			} else {
+				assert.Equal(t, uint32(4*90000), e.PresentationTimeDelta, "relative to segment start at 36s")
+			}
+		}
+	}
+
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/events_period=10/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
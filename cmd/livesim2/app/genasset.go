@@ -0,0 +1,386 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dash-Industry-Forum/livesim2/internal"
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/bits"
+	"github.com/Eyevinn/mp4ff/mp4"
+)
+
+// Generated assets (gen_) are virtual assets without any VoD content. Their video is
+// generated on the fly, with the UTC time, the segment and frame numbers, and the rung
+// burned in, so that latency, synchronization and segment numbering can be checked by
+// just looking at the picture. The asset path gen_<rung>[_<rung>...] gives the bitrate
+// ladder with one Representation per rung <height>p<fps>[@<kbps>], e.g. gen_720p25 or
+// gen_1080p50@6000_720p50@3000. Every frame is an AVC IDR frame rendered as for the
+// SGAI slate and padded to the rung bitrate. The asset loops every hour, but since
+// everything shown is derived from the live media time, the loop is not visible.
+
+const (
+	genAssetPrefix  = "gen_"
+	genMPDName      = "Manifest.mpd"
+	genTimescale    = 90000
+	genSegDurS      = 2
+	genLoopDurS     = 3600
+	genMinHeight    = 144
+	genMaxHeight    = 1080
+	genMaxFPS       = 60
+	genMinKbps      = 50
+	genMaxKbps      = 50000 // Max bitrate for Baseline profile level 4.2
+	genCodecs       = "avc1.42c02a"
+	genProfileIdc   = 66   // Constrained Baseline together with genConstraints
+	genConstraints  = 0xc0 // constraint_set0_flag and constraint_set1_flag
+	genLevelIdc     = 42
+	genBitsPerPixel = 0.07 // default bitrate in bits per pixel and frame
+	genMaxRungs     = 10   // max number of Representations of a generated asset
+	maxGenAssets    = 32   // max number of cached generated assets
+)
+
+// genRung is one Representation of a generated asset.
+type genRung struct {
+	width, height int
+	fps           int
+	kbps          int
+}
+
+// repID returns the Representation id of the rung.
+func (r genRung) repID() string {
+	return fmt.Sprintf("%dp", r.height)
+}
+
+// frameDur returns the frame duration in genTimescale.
+func (r genRung) frameDur() uint32 {
+	return uint32(genTimescale / r.fps)
+}
+
+// genVideo is the generator data of a generated asset.
+type genVideo struct {
+	rungs map[string]genRung   // by Representation id
+	gens  map[string]*slateGen // by Representation id
+}
+
+// parseGenRung parses a rung <height>p<fps>[@<kbps>], such as 720p25 or 720p25@2500.
+func parseGenRung(val string) (genRung, error) {
+	var r genRung
+	res, kbpsStr, hasKbps := strings.Cut(val, "@")
+	heightStr, fpsStr, ok := strings.Cut(res, "p")
+	if !ok {
+		return r, fmt.Errorf("rung %q: must be <height>p<fps>[@<kbps>]", val)
+	}
+	var err error
+	if r.height, err = strconv.Atoi(heightStr); err != nil {
+		return r, fmt.Errorf("rung %q height: %w", val, err)
+	}
+	if r.height < genMinHeight || r.height > genMaxHeight || r.height%2 != 0 {
+		return r, fmt.Errorf("rung %q: height must be even and in [%d, %d]", val, genMinHeight, genMaxHeight)
+	}
+	if r.fps, err = strconv.Atoi(fpsStr); err != nil {
+		return r, fmt.Errorf("rung %q fps: %w", val, err)
+	}
+	if r.fps < 1 || r.fps > genMaxFPS || genTimescale%r.fps != 0 {
+		return r, fmt.Errorf("rung %q: fps must divide %d and be in [1, %d]", val, genTimescale, genMaxFPS)
+	}
+	r.width = int(math.Round(float64(r.height)*16/9/8)) * 8 // 16:9 in whole 8x8 blocks
+	r.kbps = int(float64(r.width*r.height*r.fps) * genBitsPerPixel / 1000)
+	if hasKbps {
+		if r.kbps, err = strconv.Atoi(kbpsStr); err != nil {
+			return r, fmt.Errorf("rung %q kbps: %w", val, err)
+		}
+	}
+	if r.kbps < genMinKbps || r.kbps > genMaxKbps {
+		return r, fmt.Errorf("rung %q: kbps must be in [%d, %d]", val, genMinKbps, genMaxKbps)
+	}
+	return r, nil
+}
+
+// parseGenAssetPath returns the rungs of a generated asset path gen_<rung>[_<rung>...].
+// All rungs must have the same frame rate and different heights.
+func parseGenAssetPath(assetPath string) ([]genRung, error) {
+	val, ok := strings.CutPrefix(assetPath, genAssetPrefix)
+	if !ok || val == "" {
+		return nil, fmt.Errorf("generated asset %q: must be %s<rung>[_<rung>...]", assetPath, genAssetPrefix)
+	}
+	parts := strings.Split(val, "_")
+	if len(parts) > genMaxRungs {
+		return nil, fmt.Errorf("generated asset %q: at most %d rungs", assetPath, genMaxRungs)
+	}
+	rungs := make([]genRung, 0, len(parts))
+	heights := make(map[int]bool, len(parts))
+	for _, part := range parts {
+		r, err := parseGenRung(part)
+		if err != nil {
+			return nil, err
+		}
+		if len(rungs) > 0 && r.fps != rungs[0].fps {
+			return nil, fmt.Errorf("generated asset %q: all rungs must have the same fps", assetPath)
+		}
+		if heights[r.height] {
+			return nil, fmt.Errorf("generated asset %q: duplicate height %d", assetPath, r.height)
+		}
+		heights[r.height] = true
+		rungs = append(rungs, r)
+	}
+	return rungs, nil
+}
+
+// findGenAsset returns the generated asset that uri belongs to, creating it on first use.
+func (am *assetMgr) findGenAsset(uri string) (*asset, bool) {
+	assetPath, _, _ := strings.Cut(uri, "/")
+	if !strings.HasPrefix(assetPath, genAssetPrefix) {
+		return nil, false
+	}
+	am.genMu.Lock()
+	defer am.genMu.Unlock()
+	if a, ok := am.genAssets[assetPath]; ok {
+		return a, true
+	}
+	a, err := newGenAsset(slog.Default(), assetPath)
+	if err != nil {
+		slog.Info("generated asset", "assetPath", assetPath, "err", err)
+		return nil, false
+	}
+	if am.genAssets == nil || len(am.genAssets) >= maxGenAssets {
+		am.genAssets = make(map[string]*asset)
+	}
+	am.genAssets[assetPath] = a
+	return a, true
+}
+
+// newGenAsset creates the generated asset of assetPath with its MPD and Representations.
+func newGenAsset(logger *slog.Logger, assetPath string) (*asset, error) {
+	rungs, err := parseGenAssetPath(assetPath)
+	if err != nil {
+		return nil, err
+	}
+	a := newAsset(assetPath)
+	a.gen = &genVideo{
+		rungs: make(map[string]genRung, len(rungs)),
+		gens:  make(map[string]*slateGen, len(rungs)),
+	}
+	a.SegmentDurMS = genSegDurS * 1000
+	for _, r := range rungs {
+		rp, rawInit, err := genRepData(logger, assetPath, r)
+		if err != nil {
+			return nil, fmt.Errorf("rung %s: %w", r.repID(), err)
+		}
+		g, err := newSlateGen(rawInit)
+		if err != nil {
+			return nil, fmt.Errorf("rung %s: %w", r.repID(), err)
+		}
+		a.Reps[rp.ID] = rp
+		a.gen.rungs[rp.ID] = r
+		a.gen.gens[rp.ID] = g
+	}
+	mpdStr, err := genMPD(assetPath, rungs)
+	if err != nil {
+		return nil, fmt.Errorf("genMPD: %w", err)
+	}
+	a.MPDs[genMPDName] = internal.MPDData{
+		Name:   genMPDName,
+		Title:  assetPath,
+		Dur:    m.Seconds2DurPtr(genLoopDurS).String(),
+		MPDStr: mpdStr,
+	}
+	if err := a.consolidateAsset(logger); err != nil {
+		return nil, fmt.Errorf("consolidateAsset: %w", err)
+	}
+	// Every frame is an IDR frame, so the sync times for segdurs_ and trickplay_ are the
+	// frame grid instead of being read from VoD segments.
+	frameDur := uint64(rungs[0].frameDur())
+	a.syncTimes = make([]uint64, genLoopDurS*genTimescale/frameDur)
+	for i := range a.syncTimes {
+		a.syncTimes[i] = uint64(i) * frameDur
+	}
+	return a, nil
+}
+
+// genRepData returns the Representation data and the init segment of a rung.
+func genRepData(logger *slog.Logger, assetPath string, r genRung) (*RepData, []byte, error) {
+	id := r.repID()
+	rp := &RepData{
+		Version:                currentRepDataVersion,
+		ID:                     id,
+		ContentType:            "video",
+		Codecs:                 genCodecs,
+		MpdTimescale:           genTimescale,
+		InitURI:                id + "/init.mp4",
+		MediaURI:               id + "/$Number$.m4s",
+		ConstantSampleDuration: Ptr(r.frameDur()),
+	}
+	if err := rp.addRegExp(); err != nil {
+		return nil, nil, err
+	}
+	rawInit, err := genInitSegment(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("genInitSegment: %w", err)
+	}
+	if err := rp.setInit(logger, rawInit, assetPath); err != nil {
+		return nil, nil, fmt.Errorf("setInit: %w", err)
+	}
+	segDur := uint64(genSegDurS * genTimescale)
+	for i := range genLoopDurS / genSegDurS {
+		rp.Segments = append(rp.Segments, Segment{
+			StartTime: uint64(i) * segDur,
+			EndTime:   uint64(i+1) * segDur,
+			Nr:        uint32(i + 1),
+		})
+	}
+	return rp, rawInit, nil
+}
+
+// genSPS returns a Constrained Baseline SPS NAL unit for all-intra frames of a rung.
+func genSPS(r genRung) []byte {
+	var buf bytes.Buffer
+	w := bits.NewEBSPWriter(&buf)
+	w.Write(0x67, 8) // NAL unit header for SPS
+	w.Write(genProfileIdc, 8)
+	w.Write(genConstraints, 8)
+	w.Write(genLevelIdc, 8)
+	w.WriteExpGolomb(0) // seq_parameter_set_id
+	w.WriteExpGolomb(0) // log2_max_frame_num_minus4
+	w.WriteExpGolomb(2) // pic_order_cnt_type
+	w.WriteExpGolomb(1) // max_num_ref_frames
+	w.Write(0, 1)       // gaps_in_frame_num_value_allowed_flag
+	mbWidth, mbHeight := (r.width+15)/16, (r.height+15)/16
+	w.WriteExpGolomb(uint(mbWidth - 1))
+	w.WriteExpGolomb(uint(mbHeight - 1))
+	w.Write(1, 1) // frame_mbs_only_flag
+	w.Write(1, 1) // direct_8x8_inference_flag
+	cropRight, cropBottom := 16*mbWidth-r.width, 16*mbHeight-r.height
+	if cropRight > 0 || cropBottom > 0 {
+		w.Write(1, 1)                         // frame_cropping_flag
+		w.WriteExpGolomb(0)                   // frame_crop_left_offset
+		w.WriteExpGolomb(uint(cropRight / 2)) // in chroma samples
+		w.WriteExpGolomb(0)                   // frame_crop_top_offset
+		w.WriteExpGolomb(uint(cropBottom / 2))
+	} else {
+		w.Write(0, 1)
+	}
+	w.Write(0, 1) // vui_parameters_present_flag
+	w.WriteRbspTrailingBits()
+	return buf.Bytes()
+}
+
+// genPPS returns a CAVLC PPS NAL unit matching genSPS.
+func genPPS() []byte {
+	var buf bytes.Buffer
+	w := bits.NewEBSPWriter(&buf)
+	w.Write(0x68, 8)    // NAL unit header for PPS
+	w.WriteExpGolomb(0) // pic_parameter_set_id
+	w.WriteExpGolomb(0) // seq_parameter_set_id
+	w.Write(0, 1)       // entropy_coding_mode_flag
+	w.Write(0, 1)       // bottom_field_pic_order_in_frame_present_flag
+	w.WriteExpGolomb(0) // num_slice_groups_minus1
+	w.WriteExpGolomb(0) // num_ref_idx_l0_default_active_minus1
+	w.WriteExpGolomb(0) // num_ref_idx_l1_default_active_minus1
+	w.Write(0, 1)       // weighted_pred_flag
+	w.Write(0, 2)       // weighted_bipred_idc
+	w.WriteExpGolomb(0) // pic_init_qp_minus26 (se)
+	w.WriteExpGolomb(0) // pic_init_qs_minus26 (se)
+	w.WriteExpGolomb(0) // chroma_qp_index_offset (se)
+	w.Write(1, 1)       // deblocking_filter_control_present_flag
+	w.Write(0, 1)       // constrained_intra_pred_flag
+	w.Write(0, 1)       // redundant_pic_cnt_present_flag
+	w.WriteRbspTrailingBits()
+	return buf.Bytes()
+}
+
+// genInitSegment returns the init segment of a rung.
+func genInitSegment(r genRung) ([]byte, error) {
+	init := mp4.CreateEmptyInit()
+	trak := init.AddEmptyTrack(genTimescale, "video", "und")
+	if err := trak.SetAVCDescriptor("avc1", [][]byte{genSPS(r)}, [][]byte{genPPS()}, true); err != nil {
+		return nil, fmt.Errorf("SetAVCDescriptor: %w", err)
+	}
+	init.Moov.Mvex.Trex.DefaultSampleDuration = r.frameDur()
+	return getInitBytes(init)
+}
+
+// genMPD returns the static VoD MPD of a generated asset.
+func genMPD(assetPath string, rungs []genRung) (string, error) {
+	mpd := m.NewMPD("static")
+	mpd.Profiles = m.PROFILE_LIVE
+	mpd.MinBufferTime = m.Seconds2DurPtr(genSegDurS)
+	mpd.MediaPresentationDuration = m.Seconds2DurPtr(genLoopDurS)
+	mpd.ProgramInformation = []*m.ProgramInformationType{{Title: assetPath}}
+	p := m.NewPeriod()
+	p.Id = "P0"
+	p.Start = m.Seconds2DurPtr(0)
+	as := m.NewAdaptationSetWithParams("video", "video/mp4", true, 1)
+	as.Id = Ptr(uint32(1))
+	st := m.NewSegmentTemplate()
+	st.Initialization = "$RepresentationID$/init.mp4"
+	st.Media = "$RepresentationID$/$Number$.m4s"
+	st.SetTimescale(genTimescale)
+	st.Duration = Ptr(uint32(genSegDurS * genTimescale))
+	st.StartNumber = Ptr(uint32(1))
+	as.SegmentTemplate = st
+	for _, r := range rungs {
+		rep := m.NewVideoRepresentation(r.repID(), genCodecs, "", strconv.Itoa(r.fps), r.kbps*1000, r.width, r.height)
+		rep.Sar = "1:1"
+		as.AppendRepresentation(rep)
+	}
+	p.AppendAdaptationSet(as)
+	mpd.AppendPeriod(p)
+	return mpd.WriteToString("", false)
+}
+
+// genFrameLabel returns the text of the frame frameNr starting at utcMS in segment segNr.
+func genFrameLabel(utcMS int64, frameNr uint64, segNr uint32, r genRung) string {
+	return fmt.Sprintf("%s\n#%d f%d\n%dp%d", time.UnixMilli(utcMS).UTC().Format("15:04:05.000"),
+		segNr, frameNr, r.height, r.fps)
+}
+
+// genSegment returns the generated media segment described by meta. The frames are
+// numbered from the availabilityStartTime.
+func (g *genVideo) genSegment(cfg *ResponseConfig, meta segMeta) ([]byte, error) {
+	rep := meta.rep
+	r, ok := g.rungs[rep.ID]
+	if !ok {
+		return nil, fmt.Errorf("no generated rung %s", rep.ID)
+	}
+	frameDur := r.frameDur()
+	nrFrames := int(meta.newDur / frameDur)
+	frameSize := uint32(r.kbps * 1000 / 8 / r.fps)
+	firstFrameNr := meta.newTime / uint64(frameDur)
+	specs := make([]slateFrameSpec, nrFrames)
+	for i := range specs {
+		t := meta.newTime + uint64(i)*uint64(frameDur)
+		utcMS := int64(cfg.StartTimeS)*1000 + int64(t*1000/genTimescale)
+		specs[i] = slateFrameSpec{
+			dur:   frameDur,
+			size:  frameSize,
+			label: genFrameLabel(utcMS, firstFrameNr+uint64(i), meta.newNr, r),
+		}
+	}
+	samples, err := g.gens[rep.ID].samples(specs, meta.newTime, 0)
+	if err != nil {
+		return nil, fmt.Errorf("samples: %w", err)
+	}
+	frag, err := mp4.CreateFragment(meta.newNr, rep.initSeg.Moov.Trak.Tkhd.TrackID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range samples {
+		frag.AddFullSample(s)
+	}
+	seg := mp4.NewMediaSegment()
+	seg.AddFragment(frag)
+	sw := bits.NewFixedSliceWriter(int(seg.Size()))
+	if err := seg.EncodeSW(sw); err != nil {
+		return nil, fmt.Errorf("encode segment: %w", err)
+	}
+	return sw.Bytes(), nil
+}
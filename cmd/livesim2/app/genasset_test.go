@@ -0,0 +1,139 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/avc"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestParseGenAssetPath(t *testing.T) {
+	rungs, err := parseGenAssetPath("gen_1080p25@4500_360p25")
+	require.NoError(t, err)
+	assert.Equal(t, []genRung{
+		{width: 1920, height: 1080, fps: 25, kbps: 4500},
+		{width: 640, height: 360, fps: 25, kbps: 403},
+	}, rungs)
+	for _, val := range []string{"gen_", "gen_720", "gen_720p", "gen_721p25", "gen_1088p25", "gen_64p25", "gen_720p7",
+		"gen_720p25@10", "gen_720p25_360p30", "gen_720p25_720p25", "other_720p25",
+		"gen_1080p25_1000p25_900p25_800p25_720p25_640p25_540p25_480p25_360p25_270p25_180p25"} {
+		_, err := parseGenAssetPath(val)
+		assert.Error(t, err, val)
+	}
+}
+
+func TestGenSPS(t *testing.T) {
+	for _, height := range []int{144, 180, 360, 540, 720, 1080} {
+		r := genRung{width: int(float64(height)*16/9/8+0.5) * 8, height: height, fps: 25}
+		sps, err := avc.ParseSPSNALUnit(genSPS(r), true)
+		require.NoError(t, err)
+		assert.Equal(t, uint(r.width), sps.Width, height)
+		assert.Equal(t, uint(r.height), sps.Height, height)
+		assert.Equal(t, uint(2), sps.PicOrderCntType)
+	}
+}
+
+func TestGenAsset(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	url := "/livesim2/gen_360p25_180p25@100/"
+	resp, body := testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	mpd, err := m.ReadFromString(string(body))
+	require.NoError(t, err)
+	assert.Equal(t, "dynamic", *mpd.Type)
+	p := mpd.Periods[0]
+	require.Len(t, p.AdaptationSets, 1)
+	as := p.AdaptationSets[0]
+	assert.Equal(t, "video", string(as.ContentType))
+	require.Len(t, as.Representations, 2)
+	rep := as.Representations[1]
+	assert.Equal(t, "180p", rep.Id)
+	assert.Equal(t, genCodecs, rep.Codecs)
+	assert.Equal(t, uint32(320), rep.Width)
+	assert.Equal(t, uint32(180), rep.Height)
+	assert.Equal(t, uint32(100_000), rep.Bandwidth)
+
+	resp, body = testFullRequest(t, ts, "GET", url+"360p/init.mp4?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	f, err := mp4.DecodeFile(bytes.NewReader(body))
+	require.NoError(t, err)
+	require.NotNil(t, f.Init)
+	assert.Equal(t, uint32(genTimescale), f.Init.Moov.Trak.Mdia.Mdhd.Timescale)
+
+	resp, body = testFullRequest(t, ts, "GET", url+"360p/20.m4s?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	f, err = mp4.DecodeFile(bytes.NewReader(body))
+	require.NoError(t, err)
+	require.Len(t, f.Segments, 1)
+	frag := f.Segments[0].Fragments[0]
+	assert.Equal(t, uint32(20), frag.Moof.Mfhd.SequenceNumber)
+	assert.Equal(t, uint64(40*genTimescale), frag.Moof.Traf.Tfdt.BaseMediaDecodeTime())
+	samples, err := frag.GetFullSamples(nil)
+	require.NoError(t, err)
+	require.Len(t, samples, 2*25)
+	for _, s := range samples {
+		assert.True(t, s.IsSync())
+		assert.Equal(t, uint32(genTimescale/25), s.Dur)
+	}
+
+	// Segments in the future are too early
+	resp, _ = testFullRequest(t, ts, "GET", url+"360p/20.m4s?nowMS=30000", nil)
+	assert.Equal(t, http.StatusTooEarly, resp.StatusCode)
+
+	// Every frame is a sync sample for trickplay_ and segdurs_.
+	resp, body = testFullRequest(t, ts, "GET", "/livesim2/trickplay_1/gen_360p25/Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	mpd, err = m.ReadFromString(string(body))
+	require.NoError(t, err)
+	require.Len(t, mpd.Periods[0].AdaptationSets, 2)
+	assert.Equal(t, "360p"+trickPlayRepSuffix, mpd.Periods[0].AdaptationSets[1].Representations[0].Id)
+	resp, body = testFullRequest(t, ts, "GET", "/livesim2/trickplay_1/gen_360p25/360p_trick/20.m4s?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	f, err = mp4.DecodeFile(bytes.NewReader(body))
+	require.NoError(t, err)
+	samples, err = f.Segments[0].Fragments[0].GetFullSamples(nil)
+	require.NoError(t, err)
+	assert.Len(t, samples, 2*25)
+
+	resp, body = testFullRequest(t, ts, "GET", "/livesim2/segtimeline_1/segdurs_1,3,2/gen_360p25/360p/8640000.m4s?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	f, err = mp4.DecodeFile(bytes.NewReader(body))
+	require.NoError(t, err)
+	frag = f.Segments[0].Fragments[0]
+	assert.Equal(t, uint64(96*genTimescale), frag.Moof.Traf.Tfdt.BaseMediaDecodeTime())
+	samples, err = frag.GetFullSamples(nil)
+	require.NoError(t, err)
+	assert.Len(t, samples, 25)
+
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/gen_360p7/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGenFrameLabel(t *testing.T) {
+	r := genRung{width: 1280, height: 720, fps: 25}
+	assert.Equal(t, "22:13:20.400\n#42 f1234\n720p25", genFrameLabel(1_700_000_000_400, 1234, 42, r))
+}
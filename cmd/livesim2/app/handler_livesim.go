@@ -48,7 +48,7 @@ func cfgFromRequest(r *http.Request, log *slog.Logger) (nowMS int, cfg *Response
 	}
 
 	q := r.URL.Query()
-	nowMS, err = getNowMS(q.Get("nowMS"))
+	nowMS, err = getNowMS(q.Get("nowMS"), uPath)
 	if err != nil {
 		return 0, nil, generateAndLogHttpError(log, "bad nowMS query", http.StatusBadRequest)
 	}
@@ -82,6 +82,8 @@ func cfgFromRequest(r *http.Request, log *slog.Logger) (nowMS int, cfg *Response
 		nowMS += offsetMS
 	}
 
+	nowMS = cfg.driftedNowMS(nowMS)
+
 	if nowMS < cfg.StartTimeS*1000 {
 		tooEarlyMS := cfg.StartTimeS - nowMS
 		msg := fmt.Sprintf("%dms too early", tooEarlyMS)
@@ -107,14 +109,66 @@ func (s *Server) livesimHandlerFunc(w http.ResponseWriter, r *http.Request) {
 
 	contentPart := cfg.URLContentPart()
 	log.Debug("requested content", "url", contentPart)
+	ch, chRest, isChannel := s.assetMgr.findChannel(contentPart)
+	if isChannel {
+		if err := checkChannelCfg(ch, cfg); err != nil {
+			log.Info("channel", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if filepath.Ext(r.URL.Path) != ".mpd" {
+			// Generate the segment from the asset of the channel Period it belongs to.
+			e, pCfg, pNowMS, ok := channelSegmentCfg(ch, cfg, nowMS)
+			if !ok {
+				http.Error(w, "Not Found (channel period not running)", http.StatusNotFound)
+				return
+			}
+			cfg, nowMS = pCfg, pNowMS
+			contentPart = e.AssetPath + chRest
+			ch = nil
+		}
+	}
 	a, ok := s.assetMgr.findAsset(contentPart)
-	if !ok {
+	if !ok && ch == nil {
 		msg := fmt.Sprintf("unknown asset %q", contentPart)
 		log.Error(msg)
 		http.Error(w, msg, http.StatusNotFound)
 		return
 	}
+	if cfg.SSAIAdStartS != nil && filepath.Ext(r.URL.Path) != ".mpd" {
+		// Generate the ad segment relative to the start of its ad Period.
+		pCfg, pNowMS, ok := ssaiAdSegmentCfg(cfg, a, nowMS)
+		if !ok {
+			http.Error(w, "Not Found (ad period not running)", http.StatusNotFound)
+			return
+		}
+		cfg, nowMS = pCfg, pNowMS
+	}
+	if cfg.SegDurs != nil {
+		var err error
+		a, err = a.withSegDurs(s.assetMgr.vodFS, cfg.SegDurs)
+		if err != nil {
+			log.Info("segdurs", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if cfg.TrickPlayFlag && a != nil {
+		if err := a.loadSyncTimes(s.assetMgr.vodFS); err != nil {
+			log.Error("trickplay", "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if cfg.AVSync != nil && a != nil {
+		if err := checkAVSyncAsset(a); err != nil {
+			log.Info("avsync", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 	cfg.SetHost(s.Cfg.Host, r)
+	s.recordCMCD(log, r, contentPart)
 	switch filepath.Ext(r.URL.Path) {
 	case ".mpd":
 		if !checkQuery(cfg.Query, r.URL) {
@@ -129,10 +183,31 @@ func (s *Server) livesimHandlerFunc(w http.ResponseWriter, r *http.Request) {
 			// into the generated per-CDN BaseURLs and the ContentSteering server URL.
 			cfg.SteerSessionID = steeringSessionID(r)
 		}
+		if cfg.CMSD != nil {
+			mpdCMSD(cfg, nowMS).set(w, cfg.CMSD.ID)
+		}
+		if cfg.TimeSrv != nil {
+			// NTP requests carry no session, so the client IP selects the skew/jitter/leap config.
+			if ip, err := ipFromRequest(r); err == nil {
+				s.timeService.Register(ip, *cfg.TimeSrv)
+			}
+			cfg.NTPServer = s.timeService.NTPAddr(cfg.Host)
+		}
 		_, mpdName := path.Split(contentPart)
-		err := writeLiveMPD(log, w, cfg, s.Cfg.DrmCfg, a, mpdName, nowMS)
+		var err error
+		switch {
+		case ch != nil:
+			err = writeChannelMPD(log, w, cfg, s.Cfg.DrmCfg, ch, nowMS)
+		case cfg.SSAI != nil:
+			// The ad pods are selected per session and interests, as for the SGAI ad endpoint.
+			interests := parseInterests(r.URL.Query().Get("interests"))
+			err = writeSSAIMPD(log, w, cfg, s.Cfg.DrmCfg, s.assetMgr, s.adCatalog(), sgaiSessionID(r),
+				interests, a, mpdName, nowMS)
+		default:
+			err = writeLiveMPD(log, w, cfg, s.Cfg.DrmCfg, a, mpdName, nowMS)
+		}
 		if err != nil {
-			if errors.Is(err, errCC608AlreadyCaptioned) {
+			if errors.Is(err, errCC608AlreadyCaptioned) || errors.Is(err, errNoAdaptationSetLeft) {
 				log.Info("liveMPD rejected", "err", err)
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -141,8 +216,17 @@ func (s *Server) livesimHandlerFunc(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-	case ".mp4", ".m4s", ".cmfv", ".cmfa", ".cmft", ".jpg", ".jpeg", ".m4v", ".m4a":
+	case ".mp4", ".m4s", ".cmfv", ".cmfa", ".cmft", ".cmfm", ".jpg", ".jpeg", ".m4v", ".m4a":
 		segmentPart := strings.TrimPrefix(contentPart, a.AssetPath) // includes heading slash
+		if cfg.Restart != nil {
+			// Generate the segment from the encoder incarnation it belongs to.
+			var ok bool
+			cfg, nowMS, ok = restartSegmentCfg(cfg, a.SegmentDurMS, nowMS)
+			if !ok {
+				http.Error(w, "Not Found (encoder not running)", http.StatusNotFound)
+				return
+			}
+		}
 		if cfg.SteerLocation != "" && s.steeringSessions != nil {
 			// Segment fetched via a content-steering BaseURL: attribute it to its service
 			// location (cdn_ path token) and session (sid_ path token), record the group
@@ -151,28 +235,41 @@ func (s *Server) livesimHandlerFunc(w http.ResponseWriter, r *http.Request) {
 			// by the API and the status page.
 			s.steeringSessions.RecordSegment(cfg.SteerSessionID, cfg.SteerCSID, cfg.SteerLocation, segmentPart)
 		}
+		trafficState := lossNo
 		if len(cfg.Traffic) > 0 {
 			var patternNr int
 			patternNr, segmentPart = extractPattern(segmentPart)
 			if patternNr >= 0 {
-				itvls := cfg.Traffic[patternNr]
-				switch itvls.StateAt(nowMS / 1000) {
-				case lossNo:
-					// Just continue
-				case loss404:
-					http.Error(w, "Not Found", http.StatusNotFound)
-					return
-				case lossSlow:
-					time.Sleep(lossSlowTime)
-				case lossHang:
-					// Get the result, but after 10s
-					time.Sleep(lossHangTime)
-					http.Error(w, "Hang", http.StatusServiceUnavailable)
-					return
-				default:
-					http.Error(w, "strange loss state", http.StatusInternalServerError)
-					return
-				}
+				trafficState = cfg.Traffic[patternNr].StateAt(nowMS / 1000)
+			}
+		}
+		if cfg.CMSD != nil {
+			// Set before any traffic delay so that also delayed and failed responses carry CMSD.
+			segmentCMSD(cfg, a, segmentPart[1:], nowMS, trafficState).set(w, cfg.CMSD.ID)
+		}
+		switch trafficState {
+		case lossNo:
+			// Just continue
+		case loss404:
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		case lossSlow:
+			time.Sleep(lossSlowTime)
+		case lossHang:
+			// Get the result, but after 10s
+			time.Sleep(lossHangTime)
+			http.Error(w, "Hang", http.StatusServiceUnavailable)
+			return
+		default:
+			http.Error(w, "strange loss state", http.StatusInternalServerError)
+			return
+		}
+		if cfg.CMSD != nil {
+			if held := cfg.CMSD.heldMS(nowMS); held > 0 {
+				time.Sleep(time.Duration(held) * time.Millisecond)
+			}
+			if cfg.CMSD.Limit {
+				w = newThrottledWriter(w, cfg.CMSD.Etp.valueAt(nowMS/1000))
 			}
 		}
 		if cfg.Query != nil && contentTypeFromURL(cfg, a, segmentPart[1:]) == "video" {
@@ -244,6 +341,15 @@ func contentTypeFromURL(cfg *ResponseConfig, a *asset, segmentPart string) strin
 	if ok && err == nil {
 		return "subtitle"
 	}
+	if _, ok := id3SegmentPart(cfg, segmentPart); ok {
+		return "metadata"
+	}
+	if _, ok := timeThumbsSegmentPart(cfg, segmentPart); ok {
+		return "image"
+	}
+	if _, ok := beepSegmentPart(cfg, segmentPart); ok {
+		return "audio"
+	}
 	// Next match against init segments
 	for _, rep := range a.Reps {
 		if segmentPart == rep.InitURI {
@@ -258,12 +364,12 @@ func contentTypeFromURL(cfg *ResponseConfig, a *asset, segmentPart string) strin
 	return rep.ContentType
 }
 
-// getNowMS returns value from query or local clock.
-func getNowMS(nowMSValue string) (nowMS int, err error) {
+// getNowMS returns value from query or the server's (possibly virtual) clock for urlPath.
+func getNowMS(nowMSValue, urlPath string) (nowMS int, err error) {
 	if nowMSValue != "" {
 		return strconv.Atoi(nowMSValue)
 	}
-	return int(time.Now().UnixMilli()), nil
+	return int(serverClock.NowMS(urlPath)), nil
 }
 
 // getMSFromDate returns a nowMS value based on date (+1ms).
@@ -296,14 +402,37 @@ func extractPattern(segmentPart string) (int, string) {
 
 func writeLiveMPD(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConfig, drmCfg *drm.DrmConfig,
 	a *asset, mpdName string, nowMS int) error {
-	work := make([]byte, 0, 1024)
-	buf := bytes.NewBuffer(work)
 	lMPD, err := LiveMPD(a, mpdName, cfg, drmCfg, nowMS)
 	if err != nil {
 		return fmt.Errorf("convertToLive: %w", err)
 	}
+	return writeMPD(log, w, cfg, lMPD)
+}
+
+func writeChannelMPD(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConfig, drmCfg *drm.DrmConfig,
+	ch *channel, nowMS int) error {
+	lMPD, err := channelLiveMPD(ch, cfg, drmCfg, nowMS)
+	if err != nil {
+		return fmt.Errorf("channelLiveMPD: %w", err)
+	}
+	return writeMPD(log, w, cfg, lMPD)
+}
+
+func writeSSAIMPD(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConfig, drmCfg *drm.DrmConfig,
+	am *assetMgr, cat *adCatalog, sid string, interests []string, a *asset, mpdName string, nowMS int) error {
+	lMPD, err := ssaiLiveMPD(am, cat, sid, interests, a, mpdName, cfg, drmCfg, nowMS)
+	if err != nil {
+		return fmt.Errorf("ssaiLiveMPD: %w", err)
+	}
+	return writeMPD(log, w, cfg, lMPD)
+}
 
+// writeMPD writes the MPD, or only the Period cfg.PeriodId.
+func writeMPD(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConfig, lMPD *mpd.MPD) error {
+	work := make([]byte, 0, 1024)
+	buf := bytes.NewBuffer(work)
 	var size int
+	var err error
 
 	// Write either Period element if specified (in response to XLink request) of full MPD
 	if cfg.PeriodId != "" {
@@ -346,6 +475,30 @@ func writeSegment(ctx context.Context, w http.ResponseWriter, log *slog.Logger,
 	vodFS fs.FS, a *asset, segmentPart string, nowMS int, tt *template.Template, isLast bool) (code int, err error) {
 	// First check if init segment and return
 	log.Debug("writeSegment", "segmentPart", segmentPart)
+	trickPlay := false
+	if cfg.TrickPlayFlag {
+		// Trick-mode segments are generated from the segments of the source representation.
+		segmentPart, trickPlay = trickPlaySourcePart(a, segmentPart)
+	}
+	ladderKbps := 0
+	if len(cfg.Ladder) > 0 {
+		// The rungs of a ladder share the segments of their source representation.
+		segmentPart, ladderKbps, _ = ladderSourcePart(cfg, a, segmentPart)
+	}
+	if cfg.RepChange != nil && repChangeDropped(a, cfg, segmentPart, ladderKbps, nowMS) {
+		// The representation is not in the MPD at the time of the segment.
+		return http.StatusNotFound, nil
+	}
+	if len(cfg.ASFilter) > 0 || len(cfg.RepsFilter) > 0 {
+		dropped, err := mpdFilterDropped(a, cfg, segmentPart, ladderKbps)
+		if err != nil {
+			return 0, fmt.Errorf("mpdFilterDropped: %w", err)
+		}
+		if dropped {
+			// The representation is not in the filtered MPD.
+			return http.StatusNotFound, nil
+		}
+	}
 	isInitSegment, err := writeInitSegment(log, w, cfg, drmCfg, a, segmentPart)
 	if err != nil {
 		return 0, fmt.Errorf("writeInitSegment: %w", err)
@@ -362,6 +515,16 @@ func writeSegment(ctx context.Context, w http.ResponseWriter, log *slog.Logger,
 			return code, nil
 		}
 	}
+	if trickPlay {
+		return 0, writeTrickPlaySegment(log, w, cfg, drmCfg, vodFS, a, segmentPart, nowMS, isLast)
+	}
+	if ladderKbps > 0 {
+		return 0, writeLadderSegment(log, w, cfg, drmCfg, vodFS, a, segmentPart, nowMS, isLast, ladderKbps)
+	}
+	if _, ok := beepSegmentPart(cfg, segmentPart); ok {
+		// The beep segments are generated whole, also in the low-delay modes.
+		return 0, writeLiveSegment(log, w, cfg, drmCfg, vodFS, a, segmentPart, nowMS, tt, isLast)
+	}
 	if cfg.SSRFlag {
 		// Sub segment part (SSR/L3D) low-delay mode should return each subSegment as a separated response
 		newSegmentPart, subSegmentPart, err := calcSubSegmentPart(segmentPart)
@@ -14,6 +14,8 @@ import (
 
 	"github.com/Dash-Industry-Forum/livesim2/pkg/drm"
 	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -367,3 +369,64 @@ func TestCalcSubSegmentPart(t *testing.T) {
 		})
 	}
 }
+
+func TestMediaClockDrift(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	// timelineEndS returns the end of the video SegmentTimeline in seconds.
+	timelineEndS := func(t *testing.T, url string) float64 {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		st := mpd.Periods[0].AdaptationSets[1].SegmentTemplate // video
+		require.NotNil(t, st.SegmentTimeline)
+		var end uint64
+		for _, s := range st.SegmentTimeline.S {
+			if s.T != nil {
+				end = *s.T
+			}
+			end += uint64(s.R+1) * s.D
+		}
+		return float64(end) / float64(st.GetTimescale())
+	}
+
+	// 1000s after availabilityStartTime, a 2000ppm drift moves the media clock 2s (one segment).
+	const nowMS = 2_000_000
+	noDriftEndS := timelineEndS(t, fmt.Sprintf("/livesim2/segtimeline_1/start_1000/testpic_2s/Manifest.mpd?nowMS=%d", nowMS))
+	cases := []struct {
+		desc   string
+		drift  string
+		lastNr int
+	}{
+		{desc: "no drift", drift: "", lastNr: 499},
+		{desc: "fast media clock", drift: "drift_2000/", lastNr: 500},
+		{desc: "slow media clock", drift: "drift_-2000/", lastNr: 498},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			prefix := "/livesim2/start_1000/" + c.drift
+			url := fmt.Sprintf("%stestpic_2s/V300/%d.m4s?nowMS=%d", prefix, c.lastNr, nowMS)
+			resp, _ := testFullRequest(t, ts, "GET", url, nil)
+			assert.Equal(t, http.StatusOK, resp.StatusCode, url)
+			url = fmt.Sprintf("%stestpic_2s/V300/%d.m4s?nowMS=%d", prefix, c.lastNr+1, nowMS)
+			resp, _ = testFullRequest(t, ts, "GET", url, nil)
+			assert.Equal(t, http.StatusTooEarly, resp.StatusCode, url)
+
+			url = fmt.Sprintf("/livesim2/segtimeline_1/start_1000/%stestpic_2s/Manifest.mpd?nowMS=%d", c.drift, nowMS)
+			assert.InDelta(t, float64(2*(c.lastNr-499)), timelineEndS(t, url)-noDriftEndS, 0.01)
+		})
+	}
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/drift_100/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
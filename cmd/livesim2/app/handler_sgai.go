@@ -215,7 +215,7 @@ func (s *Server) sgaiAdsHandlerFunc(w http.ResponseWriter, r *http.Request) {
 			s.sgaiSessions.RecordDecision(sid, interestsRaw, nil)
 		}
 		breakDur := time.Duration(durS) * time.Second
-		breakEnd := time.Now().Add(breakDur)
+		breakEnd := time.UnixMilli(int64(unixMS())).Add(breakDur)
 		reason := "no interests: base ad break (AD BREAK slate kept)"
 		if interestsRaw != "" {
 			reason = fmt.Sprintf("no ads match interests %q: base ad break (AD BREAK slate kept)", interestsRaw)
@@ -258,7 +258,7 @@ func (s *Server) sgaiAdsHandlerFunc(w http.ResponseWriter, r *http.Request) {
 	for _, e := range podEntries {
 		totalAdDur += e.DurationMS
 	}
-	breakEnd := time.Now().Add(breakDur)
+	breakEnd := time.UnixMilli(int64(unixMS())).Add(breakDur)
 	log.Info("sgai ad decision", "sid", sid, "interests", interestsRaw, "pod", strings.Join(podIDs, ","),
 		"breakDurSec", durS, "podDurMs", totalAdDur, "breakEnd", breakEnd.Format("15:04:05.000"))
 	w.Header().Set("Content-Length", strconv.Itoa(size))
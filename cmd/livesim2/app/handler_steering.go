@@ -10,7 +10,6 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
 )
@@ -62,7 +61,7 @@ func (s *Server) steeringManifestHandlerFunc(w http.ResponseWriter, r *http.Requ
 	if s.steeringSessions != nil {
 		priority = s.steeringSessions.ComputeAndRecord(sid, csid, cfg, pathway, throughput)
 	} else {
-		priority = cfg.rotatePriority(time.Now().Unix())
+		priority = cfg.rotatePriority(int64(unixMS() / 1000))
 	}
 
 	manifest := SteeringManifest{
@@ -0,0 +1,203 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/bits"
+	"github.com/Eyevinn/mp4ff/mp4"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/cmaf"
+)
+
+// Timed ID3 metadata (id3_) is one ID3v2.4 tag per video segment, with a TXXX frame with
+// the wall-clock time and segment number as text, and a PRIV frame with the same values
+// as binary data. The tags are carried as AOM ID3 emsg boxes in the video segments and/or
+// as a CMAF timed-metadata track (ISO/IEC 23001-18 event message track) in an
+// AdaptationSet of its own.
+
+const (
+	ID3SchemeIdUri = "https://aomedia.org/emsg/ID3"
+	ID3PrivOwner   = "https://github.com/Dash-Industry-Forum/livesim2"
+	ID3RepID       = "id3"
+	ID3Timescale   = SUBS_TIME_TIMESCALE // the time subtitle timescale, for getRefSegMeta
+)
+
+// Carriage modes of ID3 metadata.
+const (
+	id3ModeEmsg  = "emsg"
+	id3ModeTrack = "track"
+	id3ModeBoth  = "both"
+)
+
+// id3InBand returns true if ID3 tags are sent as emsg boxes in the video segments.
+func (rc *ResponseConfig) id3InBand() bool {
+	return rc.ID3 == id3ModeEmsg || rc.ID3 == id3ModeBoth
+}
+
+// id3Track returns true if there is an ID3 metadata track.
+func (rc *ResponseConfig) id3Track() bool {
+	return rc.ID3 == id3ModeTrack || rc.ID3 == id3ModeBoth
+}
+
+// createID3Tag returns an ID3v2.4 tag with TXXX and PRIV frames for a segment starting at utcMS.
+func createID3Tag(utcMS int64, segNr uint32) []byte {
+	utc := time.UnixMilli(utcMS).UTC().Format("2006-01-02T15:04:05.000Z")
+	txxx := append([]byte{3}, "livesim2\x00"...) // UTF-8 encoding and description
+	txxx = append(txxx, fmt.Sprintf("%s #%d", utc, segNr)...)
+	priv := append([]byte(ID3PrivOwner), 0)
+	priv = binary.BigEndian.AppendUint64(priv, uint64(utcMS))
+	priv = binary.BigEndian.AppendUint32(priv, segNr)
+
+	var frames []byte
+	for _, f := range []struct {
+		id   string
+		data []byte
+	}{{"TXXX", txxx}, {"PRIV", priv}} {
+		frames = append(frames, f.id...)
+		frames = appendSyncSafe(frames, len(f.data))
+		frames = append(frames, 0, 0) // flags
+		frames = append(frames, f.data...)
+	}
+	tag := append([]byte("ID3"), 4, 0, 0) // version 2.4.0 without flags
+	tag = appendSyncSafe(tag, len(frames))
+	return append(tag, frames...)
+}
+
+// appendSyncSafe appends n as a 28-bit synchsafe integer.
+func appendSyncSafe(b []byte, n int) []byte {
+	return append(b, byte(n>>21)&0x7f, byte(n>>14)&0x7f, byte(n>>7)&0x7f, byte(n)&0x7f)
+}
+
+// id3UTCMS returns the wall-clock time of media time t (in timescale) of a stream.
+func id3UTCMS(cfg *ResponseConfig, t uint64, timescale uint32) int64 {
+	return int64(cfg.StartTimeS)*1000 + int64(t*1000/uint64(timescale))
+}
+
+// id3Emsg returns the ID3 emsg box of the video segment described by meta.
+func id3Emsg(cfg *ResponseConfig, meta segMeta) *mp4.EmsgBox {
+	return &mp4.EmsgBox{
+		Version:          1,
+		TimeScale:        meta.timescale,
+		PresentationTime: meta.newTime,
+		EventDuration:    meta.newDur,
+		ID:               meta.newNr,
+		SchemeIDURI:      ID3SchemeIdUri,
+		MessageData:      createID3Tag(id3UTCMS(cfg, meta.newTime, meta.timescale), meta.newNr),
+	}
+}
+
+// createID3InitSegment returns the init segment of the ID3 metadata track.
+func createID3InitSegment() *mp4.InitSegment {
+	init := mp4.CreateEmptyInit()
+	trak := init.AddEmptyTrack(ID3Timescale, "meta", "und")
+	evte := &mp4.EvteBox{DataReferenceIndex: 1}
+	evte.AddChild(&mp4.SilbBox{Schemes: []mp4.SilbEntry{{SchemeIdURI: ID3SchemeIdUri, AtLeastOneFlag: true}}})
+	trak.Mdia.Minf.Stbl.Stsd.AddChild(evte)
+	return init
+}
+
+// createID3MediaSegment returns a metadata segment with one sample with the ID3 event.
+func createID3MediaSegment(cfg *ResponseConfig, nr uint32, decodeTime uint64, dur uint32) (*mp4.MediaSegment, error) {
+	seg := mp4.NewMediaSegment()
+	frag, err := mp4.CreateFragment(nr, 1)
+	if err != nil {
+		return nil, err
+	}
+	seg.AddFragment(frag)
+	emib := mp4.EmibBox{
+		EventDuration: dur,
+		Id:            nr,
+		SchemeIdURI:   ID3SchemeIdUri,
+		MessageData:   createID3Tag(id3UTCMS(cfg, decodeTime, ID3Timescale), nr),
+	}
+	sw := bits.NewFixedSliceWriter(int(emib.Size()))
+	if err := emib.EncodeSW(sw); err != nil {
+		return nil, err
+	}
+	frag.AddFullSample(mp4.FullSample{
+		Sample:     mp4.Sample{Flags: mp4.SyncSampleFlags, Dur: dur, Size: uint32(emib.Size())},
+		DecodeTime: decodeTime,
+		Data:       sw.Bytes(),
+	})
+	return seg, nil
+}
+
+// id3SegmentPart returns the segment (init or number/time) of an ID3 track URL.
+func id3SegmentPart(cfg *ResponseConfig, segmentPart string) (string, bool) {
+	if !cfg.id3Track() {
+		return "", false
+	}
+	seg, ok := strings.CutPrefix(segmentPart, ID3RepID+"/")
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(seg, cmaf.CMAFMetaExtension)
+}
+
+// writeID3Segment returns true and tries to write an ID3 track segment if the URL matches.
+func writeID3Segment(w http.ResponseWriter, cfg *ResponseConfig, a *asset, segmentPart string, nowMS int,
+	isLast bool) (bool, error) {
+	seg, ok := id3SegmentPart(cfg, segmentPart)
+	if !ok {
+		return false, nil
+	}
+	var box encodableBox
+	if seg == "init" {
+		box = createID3InitSegment()
+	} else {
+		nrOrTime, err := strconv.Atoi(seg)
+		if err != nil {
+			return true, fmt.Errorf("bad seg nr %s: %w", seg, errNotFound)
+		}
+		ref, err := a.getRefSegMeta(nrOrTime, cfg, nowMS)
+		if err != nil {
+			return true, fmt.Errorf("getRefSegMeta: %w", err)
+		}
+		decodeTime := rep2SubsTime(ref.newTime, int(ref.timescale))
+		dur := uint32(rep2SubsTime(uint64(ref.newDur), int(ref.timescale)))
+		mediaSeg, err := createID3MediaSegment(cfg, ref.newNr, decodeTime, dur)
+		if err != nil {
+			return true, fmt.Errorf("createID3MediaSegment: %w", err)
+		}
+		if isLast {
+			mediaSeg.Styp.AddCompatibleBrands([]string{"lmsg"})
+		}
+		box = mediaSeg
+	}
+	return true, writeBox(w, "application/mp4", box)
+}
+
+// addID3AdaptationSet adds the AdaptationSet of the ID3 metadata track, aligned with the video.
+// Nothing is added if there is no video AdaptationSet.
+func addID3AdaptationSet(cfg *ResponseConfig, a *asset, period *m.Period) error {
+	vAS := firstVideoAdaptationSet(period)
+	if vAS == nil {
+		return nil
+	}
+	typicalSegSizeBits := 200 * 8
+	as := m.NewAdaptationSet()
+	as.Id = Ptr(uint32(200))
+	as.ContentType = "metadata"
+	as.MimeType = "application/mp4"
+	as.Codecs = "evte"
+	as.SegmentAlignment = true
+	as.SegmentTemplate = sideTrackSegmentTemplate(cfg, vAS.SegmentTemplate, ID3Timescale,
+		"init"+cmaf.CMAFMetaExtension, cmaf.CMAFMetaExtension)
+	rep := m.NewRepresentation()
+	rep.Id = ID3RepID
+	rep.StartWithSAP = 1
+	rep.Bandwidth = uint32(typicalSegSizeBits*1000) / uint32(a.SegmentDurMS)
+	as.AppendRepresentation(rep)
+	period.AppendAdaptationSet(as)
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/bits"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+// checkID3Tag checks the header, TXXX and PRIV frames of a tag created by createID3Tag.
+func checkID3Tag(t *testing.T, tag []byte, utcMS int64, segNr uint32, utc string) {
+	t.Helper()
+	require.Greater(t, len(tag), 10)
+	assert.Equal(t, []byte{'I', 'D', '3', 4, 0, 0}, tag[:6])
+	size := int(tag[6])<<21 | int(tag[7])<<14 | int(tag[8])<<7 | int(tag[9])
+	require.Equal(t, len(tag)-10, size)
+	frames := tag[10:]
+	got := make(map[string][]byte)
+	for len(frames) >= 10 {
+		id := string(frames[:4])
+		fSize := int(frames[4])<<21 | int(frames[5])<<14 | int(frames[6])<<7 | int(frames[7])
+		require.LessOrEqual(t, 10+fSize, len(frames))
+		got[id] = frames[10 : 10+fSize]
+		frames = frames[10+fSize:]
+	}
+	require.Empty(t, frames)
+	assert.Equal(t, "\x03livesim2\x00"+utc, string(got["TXXX"]))
+	priv := got["PRIV"]
+	owner, data, ok := bytes.Cut(priv, []byte{0})
+	require.True(t, ok)
+	assert.Equal(t, ID3PrivOwner, string(owner))
+	require.Len(t, data, 12)
+	assert.Equal(t, uint64(utcMS), binary.BigEndian.Uint64(data[:8]))
+	assert.Equal(t, segNr, binary.BigEndian.Uint32(data[8:]))
+}
+
+func TestCreateID3Tag(t *testing.T) {
+	checkID3Tag(t, createID3Tag(1_700_000_000_500, 42), 1_700_000_000_500, 42, "2023-11-14T22:13:20.500Z #42")
+}
+
+func TestID3(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	getMPD := func(t *testing.T, url string) *m.MPD {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		return mpd
+	}
+
+	// In-band ID3 emsg in every video segment
+	mpd := getMPD(t, "/livesim2/id3_emsg/testpic_2s/Manifest.mpd?nowMS=100000")
+	p := mpd.Periods[0]
+	require.Len(t, p.AdaptationSets, 2)
+	vAS := p.AdaptationSets[1]
+	require.Len(t, vAS.InbandEventStreams, 1)
+	assert.Equal(t, ID3SchemeIdUri, string(vAS.InbandEventStreams[0].SchemeIdUri))
+	assert.Empty(t, p.AdaptationSets[0].InbandEventStreams)
+
+	resp, body := testFullRequest(t, ts, "GET", "/livesim2/id3_emsg/testpic_2s/V300/20.m4s?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	f, err := mp4.DecodeFile(bytes.NewReader(body))
+	require.NoError(t, err)
+	emsgs := f.Segments[0].Fragments[0].Emsgs
+	require.Len(t, emsgs, 1)
+	e := emsgs[0]
+	assert.Equal(t, uint8(1), e.Version)
+	assert.Equal(t, ID3SchemeIdUri, e.SchemeIDURI)
+	assert.Equal(t, uint32(20), e.ID)
+	assert.Equal(t, uint64(40*90000), e.PresentationTime)
+	assert.Equal(t, uint32(2*90000), e.EventDuration)
+	checkID3Tag(t, e.MessageData, 40_000, 20, "1970-01-01T00:00:40.000Z #20")
+
+	// Metadata track in an AdaptationSet of its own
+	for _, mode := range []string{"track", "both"} {
+		for _, prefix := range []string{"", "segtimeline_1/"} {
+			url := "/livesim2/" + prefix + "id3_" + mode + "/testpic_2s/"
+			mpd = getMPD(t, url+"Manifest.mpd?nowMS=100000")
+			p = mpd.Periods[0]
+			require.Len(t, p.AdaptationSets, 3, url)
+			assert.Equal(t, mode == "both", len(p.AdaptationSets[1].InbandEventStreams) == 1, url)
+			mAS := p.AdaptationSets[2]
+			assert.Equal(t, "metadata", string(mAS.ContentType))
+			assert.Equal(t, "evte", mAS.Codecs)
+			assert.Equal(t, "$RepresentationID$/init.cmfm", mAS.SegmentTemplate.Initialization)
+			require.Len(t, mAS.Representations, 1)
+			assert.Equal(t, ID3RepID, mAS.Representations[0].Id)
+			segName := "20.cmfm"
+			if prefix != "" {
+				assert.Equal(t, "$RepresentationID$/$Time$.cmfm", mAS.SegmentTemplate.Media, url)
+				require.NotNil(t, mAS.SegmentTemplate.SegmentTimeline, url)
+				segName = "40000.cmfm"
+			} else {
+				assert.Equal(t, "$RepresentationID$/$Number$.cmfm", mAS.SegmentTemplate.Media, url)
+				assert.Equal(t, uint32(2000), *mAS.SegmentTemplate.Duration, url)
+			}
+
+			resp, body = testFullRequest(t, ts, "GET", url+"id3/init.cmfm?nowMS=100000", nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode, url)
+			f, err = mp4.DecodeFile(bytes.NewReader(body))
+			require.NoError(t, err)
+			require.NotNil(t, f.Init)
+			trak := f.Init.Moov.Trak
+			assert.Equal(t, "meta", trak.Mdia.Hdlr.HandlerType)
+			assert.Equal(t, uint32(ID3Timescale), trak.Mdia.Mdhd.Timescale)
+			evte, ok := trak.Mdia.Minf.Stbl.Stsd.Children[0].(*mp4.EvteBox)
+			require.True(t, ok, url)
+			require.NotNil(t, evte.Silb)
+			assert.Equal(t, ID3SchemeIdUri, evte.Silb.Schemes[0].SchemeIdURI)
+
+			resp, body = testFullRequest(t, ts, "GET", url+"id3/"+segName+"?nowMS=100000", nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode, url)
+			f, err = mp4.DecodeFile(bytes.NewReader(body))
+			require.NoError(t, err)
+			frag := f.Segments[0].Fragments[0]
+			assert.Equal(t, uint64(40_000), frag.Moof.Traf.Tfdt.BaseMediaDecodeTime())
+			samples, err := frag.GetFullSamples(nil)
+			require.NoError(t, err)
+			require.Len(t, samples, 1)
+			assert.Equal(t, uint32(2000), samples[0].Dur)
+			box, err := mp4.DecodeBoxSR(0, bits.NewFixedSliceReader(samples[0].Data))
+			require.NoError(t, err)
+			emib, ok := box.(*mp4.EmibBox)
+			require.True(t, ok, url)
+			assert.Equal(t, ID3SchemeIdUri, emib.SchemeIdURI)
+			assert.Equal(t, uint32(20), emib.Id)
+			checkID3Tag(t, emib.MessageData, 40_000, 20, "1970-01-01T00:00:40.000Z #20")
+
+			// Segments in the future are too early
+			resp, _ = testFullRequest(t, ts, "GET", url+"id3/"+segName+"?nowMS=30000", nil)
+			assert.Equal(t, http.StatusTooEarly, resp.StatusCode, url)
+		}
+	}
+
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/id3_xml/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
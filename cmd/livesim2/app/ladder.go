@@ -0,0 +1,190 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/drm"
+)
+
+// A synthetic bitrate ladder (ladder_) replaces the Representations of the first video
+// AdaptationSet by clones of its first Representation, one per rung bitrate. The clones
+// share the init segment and media of the source Representation, but every segment is
+// padded with a free box after its last mdat box up to the rung bitrate, so that ABR
+// algorithms can be tested at high bitrates with small assets. A segment that is already
+// larger than its rung bitrate is not padded.
+
+const (
+	ladderMaxRungs = 10
+	ladderMinKbps  = 50
+	ladderMaxKbps  = 50_000
+	// freeBoxHeaderSize is the size of the header of the padding free box.
+	freeBoxHeaderSize = 8
+)
+
+// ladderZeros is the payload chunk of the padding free box.
+var ladderZeros [64 * 1024]byte
+
+// CreateLadderConfig parses a ladder value of increasing bitrates <kbps>-<kbps>..., such as
+// 1000-3000-8000.
+func CreateLadderConfig(val string) ([]int, error) {
+	parts := strings.Split(val, "-")
+	if len(parts) > ladderMaxRungs {
+		return nil, fmt.Errorf("ladder %q: at most %d rungs", val, ladderMaxRungs)
+	}
+	rungs := make([]int, 0, len(parts))
+	for _, part := range parts {
+		kbps, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("ladder rung %q: %w", part, err)
+		}
+		if kbps < ladderMinKbps || kbps > ladderMaxKbps {
+			return nil, fmt.Errorf("ladder rung %d: kbps must be in [%d, %d]", kbps, ladderMinKbps, ladderMaxKbps)
+		}
+		if len(rungs) > 0 && kbps <= rungs[len(rungs)-1] {
+			return nil, fmt.Errorf("ladder %q: rungs must be increasing", val)
+		}
+		rungs = append(rungs, kbps)
+	}
+	return rungs, nil
+}
+
+// ParseLadderConfig parses a ladder option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseLadderConfig(key, val string) []int {
+	if s.err != nil {
+		return nil
+	}
+	rungs, err := CreateLadderConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return rungs
+}
+
+// ladderRepID returns the id of the rung with kbps of the source Representation srcID.
+func ladderRepID(srcID string, kbps int) string {
+	return fmt.Sprintf("%s_%dk", srcID, kbps)
+}
+
+// mpdRepID returns the MPD id of the source Representation rep, which is that of a rung
+// if ladderKbps is non-zero.
+func mpdRepID(rep *RepData, ladderKbps int) string {
+	if ladderKbps > 0 {
+		return ladderRepID(rep.ID, ladderKbps)
+	}
+	return rep.ID
+}
+
+// applyLadder replaces the Representations of the first video AdaptationSet with the rungs.
+// The segment URLs must contain the Representation id, so that the rungs can be told apart.
+// Nothing is changed if there is no video AdaptationSet.
+func applyLadder(cfg *ResponseConfig, period *m.Period) error {
+	vAS := firstVideoAdaptationSet(period)
+	if vAS == nil {
+		return nil
+	}
+	if vAS.SegmentTemplate == nil || !strings.Contains(vAS.SegmentTemplate.Media, "$RepresentationID$") {
+		return fmt.Errorf("video segment template without $RepresentationID$")
+	}
+	srcID := vAS.Representations[0].Id
+	vAS.Representations = vAS.Representations[:1]
+	reps := make([]*m.RepresentationType, 0, len(cfg.Ladder))
+	for _, kbps := range cfg.Ladder {
+		// The rungs only differ in id and bandwidth. Representations have no Clone, so the
+		// deep copy is made via the AdaptationSet with only the source Representation.
+		rep := vAS.Clone().Representations[0]
+		rep.Id = ladderRepID(srcID, kbps)
+		rep.Bandwidth = uint32(kbps * 1000)
+		reps = append(reps, rep)
+	}
+	vAS.Representations = nil
+	for _, rep := range reps {
+		vAS.AppendRepresentation(rep)
+	}
+	vAS.MinBandwidth = 0
+	vAS.MaxBandwidth = 0
+	return nil
+}
+
+// ladderSourcePart returns the segment part of the source Representation and the rung
+// bitrate if segmentPart is a ladder init or media segment.
+func ladderSourcePart(cfg *ResponseConfig, a *asset, segmentPart string) (string, int, bool) {
+	for _, rep := range a.Reps {
+		if rep.ContentType != "video" {
+			continue
+		}
+		for _, kbps := range cfg.Ladder {
+			rungID := ladderRepID(rep.ID, kbps)
+			if !strings.Contains(segmentPart, rungID) {
+				continue
+			}
+			srcPart := strings.ReplaceAll(segmentPart, rungID, rep.ID)
+			if srcPart == rep.InitURI || rep.mediaRegexp.MatchString(srcPart) {
+				return srcPart, kbps, true
+			}
+		}
+	}
+	return segmentPart, 0, false
+}
+
+// writeLadderSegment writes the source segment segmentPart padded to the rung bitrate kbps.
+func writeLadderSegment(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConfig, drmCfg *drm.DrmConfig,
+	vodFS fs.FS, a *asset, segmentPart string, nowMS int, isLast bool, kbps int) error {
+	outSeg, err := genLiveSegment(log, vodFS, a, cfg, drmCfg, segmentPart, nowMS, isLast)
+	if err != nil {
+		return fmt.Errorf("convertToLive: %w", err)
+	}
+	if outSeg.seg == nil {
+		return fmt.Errorf("no media segment for %s", segmentPart)
+	}
+	data, err := outSegData(log, cfg, drmCfg, outSeg)
+	if err != nil {
+		return err
+	}
+	meta := outSeg.meta
+	targetSize := uint64(kbps) * 125 * uint64(meta.newDur) / uint64(meta.timescale)
+	padSize := uint64(0)
+	if targetSize >= uint64(len(data))+freeBoxHeaderSize {
+		padSize = targetSize - uint64(len(data))
+	}
+	w.Header().Set("Content-Length", strconv.FormatUint(uint64(len(data))+padSize, 10))
+	w.Header().Set("Content-Type", meta.rep.SegmentType())
+	if err := writeSegData(log, w, data); err != nil {
+		return err
+	}
+	if padSize == 0 {
+		return nil
+	}
+	return writeFreePadding(log, w, padSize)
+}
+
+// writeFreePadding writes a free box of size bytes with zero payload. The payload is
+// written in chunks, so that high rung bitrates need no segment-sized buffer.
+func writeFreePadding(log *slog.Logger, w http.ResponseWriter, size uint64) error {
+	hdr := make([]byte, freeBoxHeaderSize)
+	binary.BigEndian.PutUint32(hdr, uint32(size))
+	copy(hdr[4:], "free")
+	if err := writeSegData(log, w, hdr); err != nil {
+		return err
+	}
+	for left := size - freeBoxHeaderSize; left > 0; {
+		n := min(left, uint64(len(ladderZeros)))
+		if err := writeSegData(log, w, ladderZeros[:n]); err != nil {
+			return err
+		}
+		left -= n
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateLadderConfig(t *testing.T) {
+	rungs, err := CreateLadderConfig("1000-3000-8000")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1000, 3000, 8000}, rungs)
+	for _, val := range []string{"", "1000-", "3000-1000", "1000-1000", "10", "60000",
+		"1-2-3-4-5-6-7-8-9-10-11"} {
+		_, err := CreateLadderConfig(val)
+		assert.Error(t, err, val)
+	}
+}
+
+func TestLadder(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	for _, prefix := range []string{"", "segtimeline_1/"} {
+		url := "/livesim2/" + prefix + "ladder_100-1000-8000/testpic_2s/"
+		resp, body := testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		vAS := mpd.Periods[0].AdaptationSets[1]
+		require.Len(t, vAS.Representations, 3, url)
+		for i, kbps := range []int{100, 1000, 8000} {
+			rep := vAS.Representations[i]
+			assert.Equal(t, ladderRepID("V300", kbps), rep.Id)
+			assert.Equal(t, uint32(kbps*1000), rep.Bandwidth)
+			assert.Equal(t, uint32(640), rep.Width)
+		}
+
+		resp, srcInit := testFullRequest(t, ts, "GET", url+"V300/init.mp4?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, rungInit := testFullRequest(t, ts, "GET", url+"V300_8000k/init.mp4?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, srcInit, rungInit)
+
+		segName := "20.m4s"
+		if prefix != "" {
+			segName = "3600000.m4s"
+		}
+		resp, srcSeg := testFullRequest(t, ts, "GET", url+"V300/"+segName+"?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		// 100kbps is below the source bitrate, so the segment is not padded
+		resp, body = testFullRequest(t, ts, "GET", url+"V300_100k/"+segName+"?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, srcSeg, body)
+		// 8000kbps during 2s is 2MB
+		resp, body = testFullRequest(t, ts, "GET", url+"V300_8000k/"+segName+"?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, body, 8000*125*2)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Len(t, f.Segments, 1)
+		srcF, err := mp4.DecodeFile(bytes.NewReader(srcSeg))
+		require.NoError(t, err)
+		frag, srcFrag := f.Segments[0].Fragments[0], srcF.Segments[0].Fragments[0]
+		samples, err := frag.GetFullSamples(nil)
+		require.NoError(t, err)
+		srcSamples, err := srcFrag.GetFullSamples(nil)
+		require.NoError(t, err)
+		assert.Equal(t, srcSamples, samples)
+
+		// Segments in the future are too early
+		resp, _ = testFullRequest(t, ts, "GET", url+"V300_8000k/"+segName+"?nowMS=30000", nil)
+		assert.Equal(t, http.StatusTooEarly, resp.StatusCode, url)
+	}
+
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/ladder_1000-500/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	for _, opt := range []string{"trickplay_1", "chunkdur_0.5/ato_1.5", "ato_inf", "ssras_1"} {
+		resp, _ = testFullRequest(t, ts, "GET", "/livesim2/ladder_1000/"+opt+"/testpic_2s/Manifest.mpd", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, opt)
+	}
+}
+
+func TestApplyLadder(t *testing.T) {
+	as := m.NewAdaptationSet()
+	as.ContentType = "video"
+	as.SegmentTemplate = &m.SegmentTemplateType{Media: "$RepresentationID$/$Number$.m4s"}
+	src := m.NewRepresentation()
+	src.Id = "V300"
+	src.SegmentTemplate = &m.SegmentTemplateType{Media: "$RepresentationID$/$Time$.m4s"}
+	as.AppendRepresentation(src)
+	period := m.NewPeriod()
+	period.AppendAdaptationSet(as)
+	require.NoError(t, applyLadder(&ResponseConfig{Ladder: []int{100, 1000}}, period))
+	reps := period.AdaptationSets[0].Representations
+	require.Len(t, reps, 2)
+	assert.Equal(t, "V300_100k", reps[0].Id)
+	assert.Equal(t, uint32(1_000_000), reps[1].Bandwidth)
+	// The rungs are deep copies
+	assert.NotSame(t, reps[0].SegmentTemplate, reps[1].SegmentTemplate)
+	assert.NotSame(t, src.SegmentTemplate, reps[0].SegmentTemplate)
+	assert.Equal(t, "V300", src.Id)
+}
@@ -109,6 +109,9 @@ func genLaURL(cfg *ResponseConfig) string {
 
 // LiveMPD generates a dynamic configured MPD for a VoD asset.
 func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfig, nowMS int) (*m.MPD, error) {
+	if cfg.Restart != nil {
+		return restartLiveMPD(a, mpdName, cfg, drmCfg, nowMS)
+	}
 	mpd, err := a.getVodMPD(mpdName)
 	if err != nil {
 		return nil, err
@@ -126,27 +129,17 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 	if cfg.TimeShiftBufferDepthS != nil {
 		mpd.TimeShiftBufferDepth = m.Seconds2DurPtr(*cfg.TimeShiftBufferDepthS)
 	}
+	if a.maxSegDurMS > 0 {
+		mpd.MaxSegmentDuration = Ptr(m.Duration(a.maxSegDurMS * 1_000_000))
+	}
 	if cfg.PatchTTL > 0 && mpd.Id == "" {
 		slog.Debug("Inserting ID for MPD for patch", "id", "auto-patch-id")
 		mpd.Id = "auto-patch-id"
 	}
 	if cfg.AddLocationFlag {
-		var strBuf strings.Builder
-		strBuf.WriteString(cfg.Host)
-		for i := 1; i < len(cfg.URLParts); i++ {
-			strBuf.WriteString("/")
-			switch {
-			case strings.HasPrefix(cfg.URLParts[i], "startrel_"):
-				fmt.Fprintf(&strBuf, "start_%d", cfg.StartTimeS)
-			case strings.HasPrefix(cfg.URLParts[i], "stoprel_"):
-				fmt.Fprintf(&strBuf, "stop_%d", *cfg.StopTimeS)
-			default:
-				fmt.Fprintf(&strBuf, "%s", cfg.URLParts[i])
-			}
-		}
 		mpd.Location = []*m.LocationType{
 			{
-				Value: strBuf.String(),
+				Value: locationURL(cfg),
 			},
 		}
 	}
@@ -176,6 +169,11 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 	}
 
 	addUTCTimings(mpd, cfg)
+	if cfg.TimeSrv != nil {
+		if lsi := cfg.TimeSrv.leapSecondInformation(); lsi != nil {
+			mpd.LeapSecondInformation = lsi
+		}
+	}
 
 	afterStop := false
 	endTimeMS := nowMS
@@ -320,7 +318,17 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 			as.EssentialProperties = append(as.EssentialProperties, ep)
 		}
 
-		if as.ContentType == "video" && cfg.SCTE35PerMinute != nil {
+		if as.ContentType == "video" {
+			addInbandEventStreams(as, cfg)
+			if cfg.id3InBand() {
+				as.InbandEventStreams = append(as.InbandEventStreams,
+					&m.EventStreamType{SchemeIdUri: ID3SchemeIdUri})
+			}
+			if cfg.MPDEventsFlag {
+				addMPDEventStreams(as, cfg)
+			}
+		}
+		if as.ContentType == "video" && cfg.scte35InBand() {
 			// Add SCTE35 signaling
 			as.InbandEventStreams = append(as.InbandEventStreams,
 				&m.EventStreamType{
@@ -385,6 +393,9 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 			if err != nil {
 				return nil, err
 			}
+			if cfg.PubJitter != nil {
+				cfg.dropUnpublished(&refSegEntries, endTimeMS, atoMS)
+			}
 			se = refSegEntries
 		} else {
 			switch as.ContentType {
@@ -394,12 +405,22 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 				if err != nil {
 					return nil, err
 				}
+				if cfg.PubJitter != nil {
+					cfg.dropUnpublished(&se, endTimeMS, atoMS)
+				}
 			case "audio":
 				var err error
 				se, err = a.generateTimelineEntriesFromRef(refSegEntries, as.Representations[0].Id, explicitChunkDurS)
 				if err != nil {
 					return nil, err
 				}
+				if cfg.AVSync != nil {
+					frameDur := audioFrameDur(a.Reps[as.Representations[0].Id])
+					se = cfg.AVSync.shiftEntries(se, frameDur)
+					if pto := cfg.AVSync.ptoTicks(se.mediaTimescale); pto > 0 {
+						as.SegmentTemplate.PresentationTimeOffset = Ptr(pto)
+					}
+				}
 			default:
 				return nil, fmt.Errorf("unknown content type %s", as.ContentType)
 			}
@@ -424,10 +445,26 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 				return nil, fmt.Errorf("adjustASForSegmentNumber: %w", err)
 			}
 			mpd.PublishTime = mpd.AvailabilityStartTime
+			if cfg.MPDEventsFlag {
+				mpd.PublishTime = m.ConvertToDateTime(mpdEventsPublishTimeS(a, cfg, nowMS))
+			}
 		default:
 			return nil, fmt.Errorf("unknown mpd type")
 		}
 	}
+	if len(cfg.Ladder) > 0 {
+		if err = applyLadder(cfg, period); err != nil {
+			return nil, fmt.Errorf("applyLadder: %w", err)
+		}
+	}
+	if len(cfg.ASFilter) > 0 || len(cfg.RepsFilter) > 0 {
+		if err = filterPeriod(cfg, period); err != nil {
+			return nil, fmt.Errorf("filterPeriod: %w", err)
+		}
+	}
+	if rc := cfg.RepChange; rc != nil && !rc.periodMode() && rc.droppedAt(endTimeMS-cfg.StartTimeS*1000) {
+		dropReps(rc, period)
+	}
 	if len(cfg.TimeSubsStpp) > 0 {
 		err = addTimeSubs(cfg, a, period, cfg.TimeSubsStpp, "stpp")
 		if err != nil {
@@ -440,6 +477,22 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 			return nil, fmt.Errorf("addTimeSubs wvtt: %w", err)
 		}
 	}
+	if cfg.id3Track() {
+		if err = addID3AdaptationSet(cfg, a, period); err != nil {
+			return nil, fmt.Errorf("addID3AdaptationSet: %w", err)
+		}
+	}
+	if cfg.TrickPlayFlag {
+		if err = addTrickPlayAdaptationSet(a, period); err != nil {
+			return nil, fmt.Errorf("addTrickPlayAdaptationSet: %w", err)
+		}
+	}
+	if cfg.TimeThumbs != nil {
+		addTimeThumbsAdaptationSet(cfg, a, period)
+	}
+	if cfg.BeepHz != nil {
+		addBeepAdaptationSet(cfg, a, period)
+	}
 	if cfg.CC608 != nil {
 		if cc608AlreadyCaptioned(a, period) {
 			return nil, errCC608AlreadyCaptioned
@@ -448,7 +501,12 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 			return nil, fmt.Errorf("addCC608Accessibility: %w", err)
 		}
 	}
-	if cfg.PeriodsPerHour == nil {
+	if cfg.PeriodsPerHour == nil && !cfg.RepChange.periodMode() {
+		addSCTE35EventStreams(mpd, cfg, endTimeMS)
+		addDashEventStreams(mpd, a, cfg, endTimeMS)
+		if cfg.PrftDelayMS != nil {
+			addProducerReferenceTimes(mpd, a, cfg)
+		}
 		if afterStop {
 			mpdDurS := *cfg.StopTimeS - cfg.StartTimeS
 			makeMPDStatic(mpd, mpdDurS)
@@ -459,12 +517,24 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 	}
 
 	// Split into multiple periods
-	err = splitPeriod(mpd, a, cfg, wTimes)
-	if err != nil {
-		return nil, fmt.Errorf("splitPeriods: %w", err)
+	if cfg.RepChange.periodMode() {
+		err = splitRepChangePeriods(mpd, a, cfg, wTimes)
+		if err != nil {
+			return nil, fmt.Errorf("splitRepChangePeriods: %w", err)
+		}
+	} else {
+		err = splitPeriod(mpd, a, cfg, wTimes)
+		if err != nil {
+			return nil, fmt.Errorf("splitPeriods: %w", err)
+		}
+	}
+	addSCTE35EventStreams(mpd, cfg, endTimeMS)
+	addDashEventStreams(mpd, a, cfg, endTimeMS)
+	if cfg.PrftDelayMS != nil {
+		addProducerReferenceTimes(mpd, a, cfg)
 	}
 
-	if cfg.liveMPDType() == segmentNumber {
+	if cfg.liveMPDType() == segmentNumber && !cfg.MPDEventsFlag {
 		mpd.PublishTime, err = lastPeriodStartTime(mpd)
 		if err != nil {
 			return nil, fmt.Errorf("lastPeriodStartTime: %w", err)
@@ -481,6 +551,69 @@ func LiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfi
 	return mpd, nil
 }
 
+// periodStreamCfg returns a configuration that generates a Period running from startS to endS
+// (relative to availabilityStartTime) as a stream with availabilityStartTime at the Period
+// start, and the time to generate it at (clamped to the Period end). ok is false if the
+// Period has not started or is outside the time-shift window at nowMS.
+func periodStreamCfg(cfg *ResponseConfig, startS, endS, nowMS int) (pCfg *ResponseConfig, pNowMS int, ok bool) {
+	startMS, endMS := (cfg.StartTimeS+startS)*1000, (cfg.StartTimeS+endS)*1000
+	if nowMS < startMS {
+		return nil, 0, false
+	}
+	c := *cfg
+	c.AddLocationFlag = false
+	c.StartTimeS = cfg.StartTimeS + startS
+	pNowMS = nowMS
+	if nowMS > endMS {
+		pNowMS = endMS
+		if c.TimeShiftBufferDepthS != nil {
+			// Keep the window end at nowMS - tsbd, as for the running Period.
+			tsbd := *c.TimeShiftBufferDepthS - (nowMS-endMS+999)/1000
+			if tsbd <= 0 {
+				return nil, 0, false
+			}
+			c.TimeShiftBufferDepthS = Ptr(tsbd)
+		}
+	}
+	return &c, pNowMS, true
+}
+
+// tokenBaseURL returns an absolute BaseURL: the stream's directory with a path token, such as
+// rst_<inc> or chp_<k>, injected after the /livesim2 mount (see steeringBaseURL).
+func tokenBaseURL(cfg *ResponseConfig, token string) string {
+	dirParts := cfg.URLParts[1 : len(cfg.URLParts)-1] // drop leading "" and trailing MPD filename
+	var b strings.Builder
+	b.WriteString(cfg.Host)
+	b.WriteByte('/')
+	b.WriteString(dirParts[0]) // "livesim2"
+	b.WriteByte('/')
+	b.WriteString(token)
+	for _, p := range dirParts[1:] {
+		b.WriteByte('/')
+		b.WriteString(p)
+	}
+	b.WriteByte('/')
+	return b.String()
+}
+
+// locationURL returns the MPD URL with relative start and stop times made absolute.
+func locationURL(cfg *ResponseConfig) string {
+	var strBuf strings.Builder
+	strBuf.WriteString(cfg.Host)
+	for i := 1; i < len(cfg.URLParts); i++ {
+		strBuf.WriteString("/")
+		switch {
+		case strings.HasPrefix(cfg.URLParts[i], "startrel_"):
+			fmt.Fprintf(&strBuf, "start_%d", cfg.StartTimeS)
+		case strings.HasPrefix(cfg.URLParts[i], "stoprel_"):
+			fmt.Fprintf(&strBuf, "stop_%d", *cfg.StopTimeS)
+		default:
+			fmt.Fprintf(&strBuf, "%s", cfg.URLParts[i])
+		}
+	}
+	return strBuf.String()
+}
+
 func updateSSRAdaptationSet(as *m.AdaptationSetType, nextID uint32, prevID *uint32,
 	chunkDurSSRMap map[uint32]float64, explicitChunkDurS **float64) {
 	// Add SubNumber to SegmentTemplate
@@ -579,44 +712,9 @@ func splitPeriod(mpd *m.MPD, a *asset, cfg *ResponseConfig, wTimes wrapTimes) er
 	nrPeriods := endPeriodNr - startPeriodNr + 1
 	periods := make([]*m.Period, 0, nrPeriods)
 	for pNr := startPeriodNr; pNr <= endPeriodNr; pNr++ {
-		p := inPeriod.Clone()
-		p.Id = fmt.Sprintf("P%d", pNr)
-		p.Start = m.Seconds2DurPtr(pNr * periodDur)
-		for aNr, as := range p.AdaptationSets {
-			inAS := inPeriod.AdaptationSets[aNr]
-			timeScale := int(as.SegmentTemplate.GetTimescale())
-			pto := Ptr(uint64(pNr * periodDur * timeScale))
-			templateType := cfg.liveMPDType()
-			if as.ContentType == "image" {
-				templateType = segmentNumber
-			}
-			switch templateType {
-			case segmentNumber:
-				as.SegmentTemplate.PresentationTimeOffset = pto
-				segDur := int(*as.SegmentTemplate.Duration)
-				startNr := uint32(pNr * periodDur * timeScale / segDur)
-				as.SegmentTemplate.StartNumber = Ptr(startNr)
-			case timeLineTime:
-				as.SegmentTemplate.PresentationTimeOffset = pto
-				inS := inAS.SegmentTemplate.SegmentTimeline.S
-				periodStart, periodEnd := uint64(pNr*periodDur), uint64((pNr+1)*periodDur)
-				as.SegmentTemplate.SegmentTimeline.S, _ = reduceS(inS, nil, timeScale, periodStart, periodEnd)
-			case timeLineNumber:
-				as.SegmentTemplate.PresentationTimeOffset = pto
-				inS := inAS.SegmentTemplate.SegmentTimeline.S
-				startNr := inAS.SegmentTemplate.StartNumber
-				periodStart, periodEnd := uint64(pNr*periodDur), uint64((pNr+1)*periodDur)
-				as.SegmentTemplate.SegmentTimeline.S, as.SegmentTemplate.StartNumber = reduceS(inS, startNr, timeScale, periodStart, periodEnd)
-			default:
-				return fmt.Errorf("unknown mpd type")
-			}
-			if cfg.ContMultiPeriodFlag {
-				periodContinuity := m.DescriptorType{
-					SchemeIdUri: "urn:mpeg:dash:period-continuity:2015",
-					Value:       "1",
-				}
-				as.SupplementalProperties = append(as.SupplementalProperties, &periodContinuity)
-			}
+		p, err := cutPeriod(inPeriod, cfg, fmt.Sprintf("P%d", pNr), pNr*periodDur, (pNr+1)*periodDur)
+		if err != nil {
+			return err
 		}
 		periods = append(periods, p)
 	}
@@ -638,10 +736,57 @@ func splitPeriod(mpd *m.MPD, a *asset, cfg *ResponseConfig, wTimes wrapTimes) er
 	return nil
 }
 
-func reduceS(entries []*m.S, startNr *uint32, timescale int, periodStartS, periodEndS uint64) ([]*m.S, *uint32) {
+// cutPeriod returns a clone of inPeriod with id, starting at startS and with the segments
+// in [startS, endS), where the times are in seconds relative to availabilityStartTime.
+func cutPeriod(inPeriod *m.Period, cfg *ResponseConfig, id string, startS, endS int) (*m.Period, error) {
+	p := inPeriod.Clone()
+	p.Id = id
+	p.Start = m.Seconds2DurPtr(startS)
+	for aNr, as := range p.AdaptationSets {
+		inAS := inPeriod.AdaptationSets[aNr]
+		timeScale := int(as.SegmentTemplate.GetTimescale())
+		pto := Ptr(uint64(startS * timeScale))
+		templateType := cfg.liveMPDType()
+		if as.ContentType == "image" {
+			templateType = segmentNumber
+		}
+		switch templateType {
+		case segmentNumber:
+			as.SegmentTemplate.PresentationTimeOffset = pto
+			segDur := int(*as.SegmentTemplate.Duration)
+			startNr := uint32(startS * timeScale / segDur)
+			as.SegmentTemplate.StartNumber = Ptr(startNr)
+		case timeLineTime:
+			as.SegmentTemplate.PresentationTimeOffset = pto
+			inS := inAS.SegmentTemplate.SegmentTimeline.S
+			periodStart, periodEnd := uint64(startS), uint64(endS)
+			as.SegmentTemplate.SegmentTimeline.S, _ = reduceS(inS, nil, timeScale, periodStart*1000, periodEnd*1000)
+		case timeLineNumber:
+			as.SegmentTemplate.PresentationTimeOffset = pto
+			inS := inAS.SegmentTemplate.SegmentTimeline.S
+			startNr := inAS.SegmentTemplate.StartNumber
+			periodStart, periodEnd := uint64(startS), uint64(endS)
+			as.SegmentTemplate.SegmentTimeline.S, as.SegmentTemplate.StartNumber = reduceS(inS, startNr, timeScale, periodStart*1000, periodEnd*1000)
+		default:
+			return nil, fmt.Errorf("unknown mpd type")
+		}
+		if cfg.ContMultiPeriodFlag {
+			periodContinuity := m.DescriptorType{
+				SchemeIdUri: "urn:mpeg:dash:period-continuity:2015",
+				Value:       "1",
+			}
+			as.SupplementalProperties = append(as.SupplementalProperties, &periodContinuity)
+		}
+	}
+	return p, nil
+}
+
+// reduceS returns the entries that start in [periodStartMS, periodEndMS), and the
+// number of the first of them.
+func reduceS(entries []*m.S, startNr *uint32, timescale int, periodStartMS, periodEndMS uint64) ([]*m.S, *uint32) {
 	var t uint64
-	pStart := periodStartS * uint64(timescale)
-	pEnd := periodEndS * uint64(timescale)
+	pStart := (periodStartMS*uint64(timescale) + 999) / 1000
+	pEnd := (periodEndMS*uint64(timescale) + 999) / 1000
 	nr := uint32(0)
 	if startNr != nil {
 		nr = *startNr
@@ -661,7 +806,7 @@ func reduceS(entries []*m.S, startNr *uint32, timescale int, periodStartS, perio
 				continue
 			}
 			if t >= pEnd {
-				return newS, &nr
+				return newS, &outStartNr
 			}
 			if currS == nil {
 				currS = &m.S{
@@ -712,13 +857,15 @@ func createServiceDescription(latencyTargetMS uint32) []*m.ServiceDescriptionTyp
 	}
 }
 
-func createProducerReferenceTimes(startTimeS int) []*m.ProducerReferenceTimeType {
+// createProducerReferenceTimes returns a ProducerReferenceTime mapping presentationTime to wallClockS.
+func createProducerReferenceTimes(wallClockS float64, presentationTime uint64, inband bool) []*m.ProducerReferenceTimeType {
 	return []*m.ProducerReferenceTimeType{
 		{
 			Id:               0,
-			PresentationTime: 0,
+			Inband:           inband,
+			PresentationTime: presentationTime,
 			Type:             "encoder",
-			WallClockTime:    string(m.ConvertToDateTime(float64(startTimeS))),
+			WallClockTime:    string(m.ConvertToDateTime(wallClockS)),
 			UTCTiming: &m.DescriptorType{
 				SchemeIdUri: UtcTimingHttpXSDateScheme,
 				Value:       UtcTimingXSDateHttpServerMS,
@@ -774,7 +921,7 @@ func setOffsetInAdaptationSet(cfg *ResponseConfig, as *m.AdaptationSetType) (ato
 		as.SegmentTemplate.AvailabilityTimeComplete = Ptr(false)
 		if cfg.getAvailabilityTimeOffsetS() > 0 {
 			as.SegmentTemplate.AvailabilityTimeOffset = m.FloatInf64(cfg.getAvailabilityTimeOffsetS())
-			as.ProducerReferenceTimes = createProducerReferenceTimes(cfg.StartTimeS)
+			as.ProducerReferenceTimes = createProducerReferenceTimes(float64(cfg.StartTimeS), 0, false)
 		}
 	}
 	atoMS = int(1000 * ato)
@@ -877,29 +1024,10 @@ func addTimeSubs(cfg *ResponseConfig, a *asset, period *m.Period, languages []st
 	segDurMS := a.SegmentDurMS
 	typicalStppSegSizeBits := 2000 * 8 // 2kB
 	typicalWvttSegSizeBits := 200 * 8
-	vST := vAS.SegmentTemplate
 	for i, lang := range languages {
 		rep := m.NewRepresentation()
 		rep.StartWithSAP = 1
-		st := m.NewSegmentTemplate()
-		st.Initialization = "$RepresentationID$/init.mp4"
-		if cfg.HasSegmentTimelineTime() {
-			st.Media = "$RepresentationID$/$Time$.m4s"
-		} else {
-			st.Media = "$RepresentationID$/$Number$.m4s"
-		}
-		st.SetTimescale(SUBS_TIME_TIMESCALE)
-
-		if vST.Duration != nil {
-			st.Duration = Ptr(*vST.Duration * 1000 / vST.GetTimescale())
-		}
-		if vST.StartNumber != nil {
-			st.StartNumber = vST.StartNumber
-		}
-		if vST.SegmentTimeline != nil {
-			// Create segmentTimeline for subtitles from vST
-			st.SegmentTimeline = changeTimelineTimescale(vST.SegmentTimeline, int(*vST.Timescale), SUBS_TIME_TIMESCALE)
-		}
+		st := sideTrackSegmentTemplate(cfg, vAS.SegmentTemplate, SUBS_TIME_TIMESCALE, SUBS_TIME_INIT, ".m4s")
 		as := m.NewAdaptationSet()
 		as.Id = Ptr(uint32(100 + i))
 		as.Lang = lang
@@ -925,6 +1053,32 @@ func addTimeSubs(cfg *ResponseConfig, a *asset, period *m.Period, languages []st
 	return nil
 }
 
+// sideTrackSegmentTemplate returns the SegmentTemplate of a generated track aligned with
+// the video SegmentTemplate vST, with segment times in timescale.
+func sideTrackSegmentTemplate(cfg *ResponseConfig, vST *m.SegmentTemplateType, timescale int,
+	initName, mediaExt string) *m.SegmentTemplateType {
+	st := m.NewSegmentTemplate()
+	st.Initialization = "$RepresentationID$/" + initName
+	if cfg.HasSegmentTimelineTime() {
+		st.Media = "$RepresentationID$/$Time$" + mediaExt
+	} else {
+		st.Media = "$RepresentationID$/$Number$" + mediaExt
+	}
+	st.SetTimescale(uint32(timescale))
+
+	if vST.Duration != nil {
+		st.Duration = Ptr(*vST.Duration * uint32(timescale) / vST.GetTimescale())
+	}
+	if vST.StartNumber != nil {
+		st.StartNumber = vST.StartNumber
+	}
+	if vST.SegmentTimeline != nil {
+		// Create segmentTimeline from vST
+		st.SegmentTimeline = changeTimelineTimescale(vST.SegmentTimeline, int(*vST.Timescale), timescale)
+	}
+	return st
+}
+
 // addCC608Accessibility advertises the injected in-band CTA-608 captions by adding
 // an Accessibility descriptor (urn:scte:dash:cc:cea-608:2015, value "CC1=<lang>")
 // to every video AdaptationSet. Unlike subtitles, no new AdaptationSet is created —
@@ -977,14 +1131,29 @@ func lastSegAvailTimeS(cfg *ResponseConfig, lsi lastSegInfo) float64 {
 }
 
 // addUTCTimings adds or keeps the UTCTiming elements to the MPD.
+// With the timesrv_ option, the NTP and HTTP methods point at the built-in time service.
 func addUTCTimings(mpd *m.MPD, cfg *ResponseConfig) {
+	ntpServer, sntpServer := UtcTimingNtpServer, UtcTimingSntpServer
+	xsDateServer, xsDateServerMS := UtcTimingXSDateHttpServer, UtcTimingXSDateHttpServerMS
+	isoServer, isoServerMS := UtcTimingISOHttpServer, UtcTimingISOHttpServerMS
+	headURL := fmt.Sprintf("%s%s", cfg.Host, UtcTimingHeadAsset)
+	if ts := cfg.TimeSrv; ts != nil {
+		if cfg.NTPServer != "" { // NTP listener running
+			ntpServer, sntpServer = cfg.NTPServer, cfg.NTPServer
+		}
+		xsDateServer = cfg.Host + timeSrvXSDatePath + ts.query(false)
+		xsDateServerMS = cfg.Host + timeSrvXSDatePath + ts.query(true)
+		isoServer = cfg.Host + timeSrvISOPath + ts.query(false)
+		isoServerMS = cfg.Host + timeSrvISOPath + ts.query(true)
+		headURL = isoServer
+	}
 	switch {
 	case len(cfg.UTCTimingMethods) == 0:
 		// default if none is set. Use HTTP with ms precision.
 		mpd.UTCTimings = []*m.DescriptorType{
 			{
 				SchemeIdUri: UtcTimingHttpXSDateScheme,
-				Value:       UtcTimingXSDateHttpServerMS,
+				Value:       xsDateServerMS,
 			},
 		}
 		return
@@ -1003,37 +1172,37 @@ func addUTCTimings(mpd *m.MPD, cfg *ResponseConfig) {
 			case UtcTimingNtp:
 				ut = &m.DescriptorType{
 					SchemeIdUri: UtcTimingNtpDateScheme,
-					Value:       UtcTimingNtpServer,
+					Value:       ntpServer,
 				}
 			case UtcTimingSntp:
 				ut = &m.DescriptorType{
 					SchemeIdUri: UtcTimingSntpDateScheme,
-					Value:       UtcTimingSntpServer,
+					Value:       sntpServer,
 				}
 			case UtcTimingHttpXSDate:
 				ut = &m.DescriptorType{
 					SchemeIdUri: UtcTimingHttpXSDateScheme,
-					Value:       UtcTimingXSDateHttpServer,
+					Value:       xsDateServer,
 				}
 			case UtcTimingHttpXSDateMs:
 				ut = &m.DescriptorType{
 					SchemeIdUri: UtcTimingHttpXSDateScheme,
-					Value:       UtcTimingXSDateHttpServerMS,
+					Value:       xsDateServerMS,
 				}
 			case UtcTimingHttpISO:
 				ut = &m.DescriptorType{
 					SchemeIdUri: UtcTimingHttpISOScheme,
-					Value:       UtcTimingISOHttpServer,
+					Value:       isoServer,
 				}
 			case UtcTimingHttpISOMs:
 				ut = &m.DescriptorType{
 					SchemeIdUri: UtcTimingHttpISOScheme,
-					Value:       UtcTimingISOHttpServerMS,
+					Value:       isoServerMS,
 				}
 			case UtcTimingHttpHead:
 				ut = &m.DescriptorType{
 					SchemeIdUri: UtcTimingHttpHeadScheme,
-					Value:       fmt.Sprintf("%s%s", cfg.Host, UtcTimingHeadAsset),
+					Value:       headURL,
 				}
 
 			case UtcTimingNone:
@@ -1064,6 +1233,19 @@ func changeTimelineTimescale(inSTL *m.SegmentTimelineType, oldTimescale, newTime
 	return &o
 }
 
+// firstVideoAdaptationSet returns the first video AdaptationSet of the period, or nil if
+// there is none. The options that derive from it (ladder_, trickplay_ and the id3_ track)
+// leave the period unchanged without video, e.g. for audio-only assets, or after as_
+// filtering or a repchange_ drop.
+func firstVideoAdaptationSet(period *m.Period) *m.AdaptationSetType {
+	for _, as := range period.AdaptationSets {
+		if as.ContentType == "video" {
+			return as
+		}
+	}
+	return nil
+}
+
 // orderAdaptationSetsByContentType creates a new slice of adaptation sets with video first, and then audio.
 func orderAdaptationSetsByContentType(aSets []*m.AdaptationSetType) []*m.AdaptationSetType {
 	outASets := make([]*m.AdaptationSetType, 0, len(aSets))
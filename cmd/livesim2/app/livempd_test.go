@@ -954,6 +954,27 @@ func TestMultiPeriod(t *testing.T) {
 	}
 }
 
+func TestReduceS(t *testing.T) {
+	// Ten 2s segments numbered from 1, starting at 0s
+	entries := []*m.S{{T: Ptr(uint64(0)), D: 2, R: 9}}
+	cases := []struct {
+		desc           string
+		startMS, endMS uint64
+		wantedS        []*m.S
+		wantedStartNr  uint32
+	}{
+		{"period in the middle", 4000, 10_000, []*m.S{{T: Ptr(uint64(4)), D: 2, R: 2}}, 3},
+		{"last period", 16_000, 30_000, []*m.S{{T: Ptr(uint64(16)), D: 2, R: 1}}, 9},
+		{"start between segments", 5000, 8000, []*m.S{{T: Ptr(uint64(6)), D: 2}}, 4},
+	}
+	for _, c := range cases {
+		s, startNr := reduceS(entries, Ptr(uint32(1)), 1, c.startMS, c.endMS)
+		assert.Equal(t, c.wantedS, s, c.desc)
+		require.NotNil(t, startNr)
+		assert.Equal(t, c.wantedStartNr, *startNr, c.desc)
+	}
+}
+
 func TestRelStartStopTimeIntoLocation(t *testing.T) {
 	vodFS := os.DirFS("testdata/assets")
 	am := newAssetMgr(vodFS, "", false, false)
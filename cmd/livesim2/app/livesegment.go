@@ -27,7 +27,7 @@ import (
 
 // genLiveSegment generates a live segment from one or more VoD segments following cfg and media type
 // isLast triggers insertion of lmsg compatibility brand
-func genLiveSegment(log *slog.Logger, vodFS fs.FS, a *asset, cfg *ResponseConfig,
+func genLiveSegment(log *slog.Logger, vodFS fs.FS, a *asset, cfg *ResponseConfig, drmCfg *drm.DrmConfig,
 	segmentPart string, nowMS int, isLast bool) (segOut, error) {
 	var so segOut
 
@@ -119,6 +119,37 @@ func genLiveSegment(log *slog.Logger, vodFS fs.FS, a *asset, cfg *ResponseConfig
 				log.Debug("added SCTE-35 emsg message", "asset", a.AssetPath, "segment", segmentPart)
 			}
 		}
+		if cfg.SCTE35Schedule != nil && cfg.scte35InBand() && contentType == "video" {
+			startTime := uint64(meta.newTime)
+			endTime := startTime + uint64(meta.newDur)
+			for _, emsg := range scheduleEmsgs(cfg.SCTE35Schedule, startTime, endTime, uint64(meta.timescale)) {
+				seg.Fragments[0].AddEmsg(emsg)
+				log.Debug("added SCTE-35 emsg message", "asset", a.AssetPath, "segment", segmentPart, "id", emsg.ID)
+			}
+		}
+		if cfg.id3InBand() && contentType == "video" {
+			seg.Fragments[0].AddEmsg(id3Emsg(cfg, meta))
+		}
+		if cfg.inbandEvents() && contentType == "video" {
+			startTime := uint64(meta.newTime)
+			endTime := startTime + uint64(meta.newDur)
+			for _, emsg := range dashEventEmsgs(a, cfg, startTime, endTime, uint64(meta.timescale)) {
+				seg.Fragments[0].AddEmsg(emsg)
+				log.Debug("added event emsg message", "asset", a.AssetPath, "segment", segmentPart,
+					"scheme", emsg.SchemeIDURI, "id", emsg.ID)
+			}
+		}
+		if cfg.MPDEventsFlag && contentType == "video" {
+			emsgs, err := mpdChangeEmsgs(a, cfg, drmCfg, meta)
+			if err != nil {
+				return so, fmt.Errorf("mpdChangeEmsgs: %w", err)
+			}
+			for _, emsg := range emsgs {
+				seg.Fragments[0].AddEmsg(emsg)
+				log.Debug("added MPD event emsg message", "asset", a.AssetPath, "segment", segmentPart,
+					"value", emsg.Value)
+			}
+		}
 
 		// CTA-608: refuse to inject if this video representation already carries
 		// captions (detected at scan time), so we never emit a second, conflicting
@@ -136,6 +167,11 @@ func genLiveSegment(log *slog.Logger, vodFS fs.FS, a *asset, cfg *ResponseConfig
 			}
 			log.Debug("injected CTA-608 captions", "asset", a.AssetPath, "segment", segmentPart)
 		}
+		if cfg.PrftDelayMS != nil {
+			for _, frag := range seg.Fragments {
+				addPrft(frag, cfg, meta.timescale)
+			}
+		}
 		outSeg.seg = seg
 		outSeg.data = nil
 	}
@@ -226,8 +262,7 @@ func findSegMetaFromTime(a *asset, rep *RepData, time uint64, cfg *ResponseConfi
 
 	// Check interval validity
 	segAvailTimeS := float64(int(seg.EndTime)+wrapTime+mediaRef) / float64(rep.MediaTimescale)
-	nowS := float64(nowMS) * 0.001
-	err = CheckTimeValidity(segAvailTimeS, nowS, float64(*cfg.TimeShiftBufferDepthS), cfg.getAvailabilityTimeOffsetS())
+	err = checkSegmentTime(cfg, segAvailTimeS, nowMS, uint64(idx+nrWraps*len(rep.Segments)))
 	if err != nil {
 		return segMeta{}, err
 	}
@@ -293,8 +328,7 @@ func findRefSegMetaFromTime(a *asset, rep *RepData, time uint64, cfg *ResponseCo
 
 	// Check interval validity
 	segAvailTimeS := float64(refEndTime) / float64(refRep.MediaTimescale)
-	nowS := float64(nowMS) * 0.001
-	err := CheckTimeValidity(segAvailTimeS, nowS, float64(*cfg.TimeShiftBufferDepthS), cfg.getAvailabilityTimeOffsetS())
+	err := checkSegmentTime(cfg, segAvailTimeS, nowMS, relNr+wrapNr)
 	if err != nil {
 		return segMeta{}, err
 	}
@@ -330,7 +364,7 @@ func calcSegmentAvailabilityTime(a *asset, rep *RepData, nr uint32, cfg *Respons
 		return int64(cfg.StartTimeS) * 1000, nil
 	}
 	segAvailTimeS -= ato
-	milliSeconds := int64(segAvailTimeS * 1_000)
+	milliSeconds := int64(segAvailTimeS*1_000) + int64(cfg.pubDelayMS(uint64(nrAfterStart)))
 	return milliSeconds, nil
 }
 
@@ -352,8 +386,7 @@ func findSegMetaFromNr(a *asset, rep *RepData, nr uint32, cfg *ResponseConfig, n
 
 	// Check interval validity
 	segAvailTimeS := float64(seg.EndTime+wrapTime+mediaRef) / float64(rep.MediaTimescale)
-	nowS := float64(nowMS) * 0.001
-	err := CheckTimeValidity(segAvailTimeS, nowS, float64(*cfg.TimeShiftBufferDepthS), cfg.getAvailabilityTimeOffsetS())
+	err := checkSegmentTime(cfg, segAvailTimeS, nowMS, uint64(nrDiffSinceStart))
 	if err != nil {
 		return segMeta{}, err
 	}
@@ -382,6 +415,12 @@ func writeInitSegment(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConf
 	if isTimeSubsInit {
 		return true, err
 	}
+	if seg, ok := id3SegmentPart(cfg, segmentPart); ok && seg == "init" {
+		return writeID3Segment(w, cfg, nil, segmentPart, 0, false)
+	}
+	if seg, ok := beepSegmentPart(cfg, segmentPart); ok && seg == "init" {
+		return writeBeepSegment(w, cfg, nil, segmentPart, 0, false)
+	}
 	match, err := matchInit(segmentPart, cfg, drmCfg, a)
 	if err != nil {
 		return false, fmt.Errorf("getInitBytes: %w", err)
@@ -455,31 +494,81 @@ func writeLiveSegment(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConf
 	if isTimeSubsMedia {
 		return err
 	}
-	outSeg, err := genLiveSegment(log, vodFS, a, cfg, segmentPart, nowMS, isLast)
+	isID3Media, err := writeID3Segment(w, cfg, a, segmentPart, nowMS, isLast)
+	if isID3Media {
+		return err
+	}
+	isTimeThumb, err := writeTimeThumbsSegment(w, cfg, a, segmentPart, nowMS)
+	if isTimeThumb {
+		return err
+	}
+	isBeep, err := writeBeepSegment(w, cfg, a, segmentPart, nowMS, isLast)
+	if isBeep {
+		return err
+	}
+	outSeg, err := genLiveSegment(log, vodFS, a, cfg, drmCfg, segmentPart, nowMS, isLast)
 	if err != nil {
 		return fmt.Errorf("convertToLive: %w", err)
 	}
-	var data []byte
-	if outSeg.seg != nil {
-		if cfg.DRM != "" {
-			frags := outSeg.seg.Fragments
-			err := encryptFrags(log, cfg, drmCfg, outSeg.meta.rep, frags)
-			if err != nil {
-				return fmt.Errorf("encryptFrags: %w", err)
-			}
-		}
-		sw := bits.NewFixedSliceWriter(int(outSeg.seg.Size()))
-		err = outSeg.seg.EncodeSW(sw)
-		if err != nil {
-			log.Error("write live segment response", "error", err)
-			return err
-		}
-		data = sw.Bytes()
-	} else {
-		data = outSeg.data
+	return writeOutSeg(log, w, cfg, drmCfg, outSeg)
+}
+
+// writeOutSeg encrypts the segment of outSeg if configured, and writes it to w.
+func writeOutSeg(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConfig, drmCfg *drm.DrmConfig, outSeg segOut) error {
+	data, err := outSegData(log, cfg, drmCfg, outSeg)
+	if err != nil {
+		return err
 	}
 	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
 	w.Header().Set("Content-Type", outSeg.meta.rep.SegmentType())
+	return writeSegData(log, w, data)
+}
+
+// outSegData returns the bytes of the output segment, encrypted if configured.
+func outSegData(log *slog.Logger, cfg *ResponseConfig, drmCfg *drm.DrmConfig, outSeg segOut) ([]byte, error) {
+	if outSeg.seg == nil {
+		return outSeg.data, nil
+	}
+	if cfg.DRM != "" {
+		frags := outSeg.seg.Fragments
+		err := encryptFrags(log, cfg, drmCfg, outSeg.meta.rep, frags)
+		if err != nil {
+			return nil, fmt.Errorf("encryptFrags: %w", err)
+		}
+	}
+	sw := bits.NewFixedSliceWriter(int(outSeg.seg.Size()))
+	err := outSeg.seg.EncodeSW(sw)
+	if err != nil {
+		log.Error("write live segment response", "error", err)
+		return nil, err
+	}
+	return sw.Bytes(), nil
+}
+
+// encodableBox is an mp4 box or segment that can be encoded.
+type encodableBox interface {
+	Size() uint64
+	EncodeSW(sw bits.SliceWriter) error
+}
+
+// writeBox writes the encoded box as the response with contentType.
+func writeBox(w http.ResponseWriter, contentType string, box encodableBox) error {
+	size := int(box.Size())
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(size))
+	sw := bits.NewFixedSliceWriter(size)
+	if err := box.EncodeSW(sw); err != nil {
+		return fmt.Errorf("encode segment: %w", err)
+	}
+	if _, err := w.Write(sw.Bytes()); err != nil {
+		slog.Error("write segment response", "error", err)
+		return err
+	}
+	return nil
+}
+
+// writeSegData writes all of data as (part of) a segment response.
+func writeSegData(log *slog.Logger, w http.ResponseWriter, data []byte) error {
 	nrWritten := 0
 	for {
 		n, err := w.Write(data[nrWritten:])
@@ -598,6 +687,20 @@ func createOutSeg(vodFS fs.FS, a *asset, cfg *ResponseConfig, segmentPart string
 	if err != nil {
 		return so, err
 	}
+	if a.gen != nil {
+		so.data, err = a.gen.genSegment(cfg, so.meta)
+		if err != nil {
+			return so, fmt.Errorf("genSegment: %w", err)
+		}
+		return so, nil
+	}
+	if rep.srcSegments != nil {
+		so.data, err = createRegroupedSeg(vodFS, a, rep, so.meta.origTime, so.meta.origDur, so.meta.origNr)
+		if err != nil {
+			return so, fmt.Errorf("createRegroupedSeg: %w", err)
+		}
+		return so, nil
+	}
 	segPath := path.Join(a.AssetPath, replaceTimeAndNr(rep.MediaURI, so.meta.origTime, so.meta.origNr))
 	so.data, err = fs.ReadFile(vodFS, segPath)
 	if err != nil {
@@ -658,6 +761,9 @@ func createAudioSegment(vodFS fs.FS, a *asset, cfg *ResponseConfig, segmentPart
 		refMeta.newTime+uint64(refMeta.newDur),
 		uint64(refRep.duration()),
 		refTimescale, rep)
+	if cfg.AVSync != nil {
+		recipe.shiftTo(cfg.AVSync.shiftedTime(recipe.startTime, uint32(rep.MediaTimescale), audioFrameDur(rep)))
+	}
 	var so segOut
 	so.seg, err = createAudioSeg(vodFS, a, recipe)
 	if err != nil {
@@ -693,6 +799,12 @@ func findRefSegMeta(a *asset, cfg *ResponseConfig, segmentPart string, nowMS int
 		// The MPD shows adjusted timeline where segment 0 has shortened duration
 		// We need to map the adjusted time back to the original timeline
 		requestTime := uint64(segID)
+		if cfg.AVSync != nil && rep.ContentType == "audio" {
+			requestTime, err = cfg.AVSync.contentTime(requestTime, uint32(rep.MediaTimescale), audioFrameDur(rep))
+			if err != nil {
+				return refMeta, errNotFound
+			}
+		}
 		if rep.EditListOffset > 0 && rep.ContentType == "audio" {
 			// For times after segment 0, we need to add back the editListOffset
 			// because segment 0's duration was shortened by editListOffset
@@ -715,7 +827,7 @@ func findRefSegMeta(a *asset, cfg *ResponseConfig, segmentPart string, nowMS int
 func prepareChunks(log *slog.Logger, vodFS fs.FS, a *asset, cfg *ResponseConfig, drmCfg *drm.DrmConfig,
 	segmentPart string, nowMS int, isLast bool, chunkIndex *int) (segOut, []chunk, error) {
 
-	so, err := genLiveSegment(log, vodFS, a, cfg, segmentPart, nowMS, isLast)
+	so, err := genLiveSegment(log, vodFS, a, cfg, drmCfg, segmentPart, nowMS, isLast)
 	if err != nil {
 		return so, nil, fmt.Errorf("convertToLive: %w", err)
 	}
@@ -745,6 +857,11 @@ func prepareChunks(log *slog.Logger, vodFS fs.FS, a *asset, cfg *ResponseConfig,
 	if err != nil {
 		return so, nil, fmt.Errorf("chunkSegment: %w", err)
 	}
+	if cfg.PrftDelayMS != nil {
+		for _, chk := range chunks {
+			addPrft(chk.frag, cfg, so.meta.timescale)
+		}
+	}
 	if cfg.DRM != "" {
 		frags := make([]*mp4.Fragment, len(chunks))
 		for i, chk := range chunks {
@@ -794,7 +911,9 @@ func writeChunkedSegment(ctx context.Context, log *slog.Logger, w http.ResponseW
 	}
 	rep := so.meta.rep
 
-	startUnixMS := unixMS()
+	// The chunks are paced by the clock of the stream, which may not run at real-time speed.
+	urlPath := strings.Join(cfg.URLParts, "/")
+	startStreamMS := int(serverClock.NowMS(urlPath))
 	newTimeInt, err := uint64ToInt(so.meta.newTime)
 	if err != nil {
 		return fmt.Errorf("newTime out of range: %w", err)
@@ -806,23 +925,16 @@ func writeChunkedSegment(ctx context.Context, log *slog.Logger, w http.ResponseW
 			return ctx.Err()
 		}
 		chunkAvailMS := chunkAvailTime * 1000 / int(rep.MediaTimescale)
-		if chunkAvailMS < nowMS {
-			err = writeChunk(w, chk)
-			if err != nil {
-				return fmt.Errorf("writeChunk: %w", err)
+		for {
+			nowUpdateMS := int(serverClock.NowMS(urlPath)) - startStreamMS + nowMS
+			if chunkAvailMS <= nowUpdateMS {
+				break
 			}
-			continue
-		}
-		nowUpdateMS := unixMS() - startUnixMS + nowMS
-		if chunkAvailMS < nowUpdateMS {
-			err = writeChunk(w, chk)
-			if err != nil {
-				return fmt.Errorf("writeChunk: %w", err)
+			time.Sleep(serverClock.RealDuration(urlPath, int64(chunkAvailMS-nowUpdateMS)))
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
-			continue
 		}
-		sleepMS := chunkAvailMS - nowUpdateMS
-		time.Sleep(time.Duration(sleepMS * 1_000_000))
 		err = writeChunk(w, chk)
 		if err != nil {
 			return fmt.Errorf("writeChunk: %w", err)
@@ -887,8 +999,9 @@ func writeSubSegment(ctx context.Context, log *slog.Logger, w http.ResponseWrite
 	return nil
 }
 
+// unixMS returns the current server time in ms, following the global virtual clock.
 func unixMS() int {
-	return int(time.Now().UnixMilli())
+	return int(serverClock.NowMS(""))
 }
 
 type chunk struct {
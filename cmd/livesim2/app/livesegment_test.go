@@ -15,6 +15,7 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Dash-Industry-Forum/livesim2/pkg/drm"
 	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
@@ -109,7 +110,7 @@ func TestLiveSegment(t *testing.T) {
 				default: // "TimelineTime":
 					media = strings.ReplaceAll(media, "$NrOrTime$", fmt.Sprintf("%d", mediaTime))
 				}
-				so, err := genLiveSegment(log, vodFS, asset, cfg, media, nowMS, false /*isLast */)
+				so, err := genLiveSegment(log, vodFS, asset, cfg, nil, media, nowMS, false /*isLast */)
 				require.NoError(t, err)
 				require.Equal(t, tc.segmentMimeType, so.meta.rep.SegmentType())
 				seg := so.seg
@@ -135,7 +136,7 @@ func TestAc3Timing(t *testing.T) {
 	for sNr := 0; sNr <= 5; sNr++ {
 		media := "audio_$NrOrTime$.m4s"
 		media = strings.ReplaceAll(media, "$NrOrTime$", fmt.Sprintf("%d", sNr))
-		so, err := genLiveSegment(log, vodFS, asset, cfg, media, nowMS, false /* isLast */)
+		so, err := genLiveSegment(log, vodFS, asset, cfg, nil, media, nowMS, false /* isLast */)
 		require.NoError(t, err)
 		bmdt := int(so.seg.Fragments[0].Moof.Traf.Tfdt.BaseMediaDecodeTime())
 		overShoot := bmdt - (2 * sNr * 48000)
@@ -189,7 +190,7 @@ func TestCheckAudioSegmentTimeAddressing(t *testing.T) {
 				default:
 					segMedia = strings.ReplaceAll(c.media, "$NrOrTime$", fmt.Sprintf("%d", mediaTime))
 				}
-				so, err := genLiveSegment(log, vodFS, asset, cfg, segMedia, c.nowMS, false /* isLast */)
+				so, err := genLiveSegment(log, vodFS, asset, cfg, nil, segMedia, c.nowMS, false /* isLast */)
 				require.NoError(t, err)
 				trun := so.seg.Fragments[0].Moof.Traf.Trun
 				nrSamples := c.nrSamplesMod[nr%len(c.nrSamplesMod)]
@@ -242,7 +243,7 @@ func TestLiveThumbSegment(t *testing.T) {
 			media := tc.media
 			// Always number, even if MPD is timelinetime
 			media = strings.ReplaceAll(media, "$NrOrTime$", fmt.Sprintf("%d", tc.reqNr))
-			so, err := genLiveSegment(log, vodFS, asset, cfg, media, nowMS, false /* isLast */)
+			so, err := genLiveSegment(log, vodFS, asset, cfg, nil, media, nowMS, false /* isLast */)
 			require.NoError(t, err)
 			origNr := tc.reqNr%tc.nrSegs + 1 // one-based
 			require.Equal(t, tc.segmentMimeType, so.meta.rep.SegmentType())
@@ -299,6 +300,38 @@ func TestWriteChunkedSegment(t *testing.T) {
 	}
 }
 
+// TestWriteChunkedSegmentClockSpeed checks that the chunks are paced by the virtual clock.
+func TestWriteChunkedSegmentClockSpeed(t *testing.T) {
+	vodFS := os.DirFS("testdata/assets")
+	am := newAssetMgr(vodFS, "", false, false)
+	log := slog.Default()
+	err := am.discoverAssets(log)
+	require.NoError(t, err)
+	cfg := NewResponseConfig()
+	cfg.AvailabilityTimeCompleteFlag = false
+	cfg.AvailabilityTimeOffsetS = 7.0
+	chunkDur := 1.0
+	cfg.ChunkDurS = &chunkDur
+	asset, ok := am.findAsset("testpic_8s")
+	require.True(t, ok)
+
+	// The last two chunks of segment 10 [80s, 88s) are available 2s after 86s, which
+	// takes 100ms at speed 20.
+	_, err = serverClock.Apply(ClockChange{Speed: Ptr(20.0)})
+	require.NoError(t, err)
+	defer serverClock.ResetAll()
+	rr := httptest.NewRecorder()
+	start := time.Now()
+	err = writeChunkedSegment(context.Background(), log, rr, cfg, nil, vodFS, asset, "V300/10.m4s", 86_000, false)
+	require.NoError(t, err)
+	elapsed := time.Since(start)
+	assert.Greater(t, elapsed, 50*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+	mp4d, err := mp4.DecodeFileSR(bits.NewFixedSliceReader(rr.Body.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 8, len(mp4d.Segments[0].Fragments))
+}
+
 func TestAvailabilityTime(t *testing.T) {
 	testCases := []struct {
 		desc       string
@@ -451,7 +484,7 @@ func TestStartNumber(t *testing.T) {
 		cfg := NewResponseConfig()
 		cfg.StartNr = Ptr(tc.startNr)
 		media := strings.Replace(tc.media, "$NrOrTime$", fmt.Sprintf("%d", (tc.requestNr)), 1)
-		so, err := genLiveSegment(log, vodFS, asset, cfg, media, tc.nowMS, false /* isLast */)
+		so, err := genLiveSegment(log, vodFS, asset, cfg, nil, media, tc.nowMS, false /* isLast */)
 		if tc.expectedErr != "" {
 			require.Error(t, err)
 			require.Contains(t, err.Error(), tc.expectedErr)
@@ -609,7 +642,7 @@ func TestLLSegmentAvailability(t *testing.T) {
 			cfg.StartNr = Ptr(tc.startNr)
 		}
 		media := strings.Replace(tc.media, "$NrOrTime$", fmt.Sprintf("%d", (tc.requestMedia)), 1)
-		so, err := genLiveSegment(log, vodFS, asset, cfg, media, tc.nowMS, false /* isLast */)
+		so, err := genLiveSegment(log, vodFS, asset, cfg, nil, media, tc.nowMS, false /* isLast */)
 		if tc.expectedErr != "" {
 			require.Error(t, err)
 			require.Contains(t, err.Error(), tc.expectedErr)
@@ -844,7 +877,7 @@ func TestMpeghAssets(t *testing.T) {
 
 			// Test audio media segment (segment 0)
 			audioMediaPath := fmt.Sprintf("%s_0.m4s", tc.audioRepId)
-			so, err := genLiveSegment(logger, vodFS, asset, cfg, audioMediaPath, nowMS, false)
+			so, err := genLiveSegment(logger, vodFS, asset, cfg, nil, audioMediaPath, nowMS, false)
 			require.NoError(t, err, "Failed to generate audio segment")
 			require.NotNil(t, so, "Audio segment output is nil")
 			require.Equal(t, "audio/mp4", so.meta.rep.SegmentType(), "Audio segment wrong type")
@@ -875,7 +908,7 @@ func TestMpeghAssets(t *testing.T) {
 
 			// Test video media segment (segment 0)
 			videoMediaPath := fmt.Sprintf("%s_0.m4s", tc.videoRepId)
-			so, err = genLiveSegment(logger, vodFS, asset, cfg, videoMediaPath, nowMS, false)
+			so, err = genLiveSegment(logger, vodFS, asset, cfg, nil, videoMediaPath, nowMS, false)
 			require.NoError(t, err, "Failed to generate video segment")
 			require.NotNil(t, so, "Video segment output is nil")
 			require.Equal(t, "video/mp4", so.meta.rep.SegmentType(), "Video segment wrong type")
@@ -888,14 +921,14 @@ func TestMpeghAssets(t *testing.T) {
 
 			// Test segment 1 to verify timing progression
 			audioMediaPath = fmt.Sprintf("%s_1.m4s", tc.audioRepId)
-			so, err = genLiveSegment(logger, vodFS, asset, cfg, audioMediaPath, nowMS, false)
+			so, err = genLiveSegment(logger, vodFS, asset, cfg, nil, audioMediaPath, nowMS, false)
 			require.NoError(t, err, "Failed to generate audio segment 1")
 			baseDecodeTime = so.seg.Fragments[0].Moof.Traf.Tfdt.BaseMediaDecodeTime()
 			expectedAudioTime := 76800 // 1.6s * 48000
 			require.Equal(t, expectedAudioTime, int(baseDecodeTime), "Second audio segment timing incorrect")
 
 			videoMediaPath = fmt.Sprintf("%s_1.m4s", tc.videoRepId)
-			so, err = genLiveSegment(logger, vodFS, asset, cfg, videoMediaPath, nowMS, false)
+			so, err = genLiveSegment(logger, vodFS, asset, cfg, nil, videoMediaPath, nowMS, false)
 			require.NoError(t, err, "Failed to generate video segment 1")
 			baseDecodeTime = so.seg.Fragments[0].Moof.Traf.Tfdt.BaseMediaDecodeTime()
 			expectedVideoTime := 80 // 1.6s * 50
@@ -16,7 +16,9 @@ func addVersionAndCORSHeaders(next http.Handler) http.Handler {
 		w.Header().Add("Access-Control-Allow-Origin", "*")
 		w.Header().Add("Access-Control-Allow-Methods", "POST, GET, HEAD, OPTIONS")
 		w.Header().Add("Access-Control-Expose-Headers", "*")
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Accept")
+		// CMCD headers are allowed so that header-mode CMCD passes a CORS preflight.
+		w.Header().Add("Access-Control-Allow-Headers",
+			"Content-Type, Accept, CMCD-Request, CMCD-Object, CMCD-Status, CMCD-Session")
 		w.Header().Add("Timing-Allow-Origin", "*")
 		next.ServeHTTP(w, r)
 	}
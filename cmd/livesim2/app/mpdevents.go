@@ -0,0 +1,207 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/drm"
+	"github.com/Dash-Industry-Forum/livesim2/pkg/patch"
+)
+
+// MPD events (mpdevents_) are in-band DASH MPD events (ISO/IEC 23009-1 5.10.4) in the video
+// segments. They are sent in the first segment available after the generated MPD has changed,
+// e.g. by a new Period, an added MPD event or a ladder change, so that players with a long
+// minimumUpdatePeriod learn about the change directly.
+//
+// A change is detected by generating the MPD at the availability times of the previous segment
+// and of the segment itself, and comparing them without the parts that change with every new
+// segment (publishTime, SegmentTimeline, startNumber, PatchLocation and UTCTiming). The MPDs
+// are cached per MPD URL and time, so that the MPD at the end of a segment is reused for the
+// next segment and for the other video Representations.
+
+const (
+	MPDEventScheme = "urn:mpeg:dash:event:2012"
+	// MPD validity expiration with the new MPD@publishTime as message_data.
+	mpdEventValidityExpiration = "1"
+	// MPD patch with the patch document as message_data (with patch_).
+	mpdEventPatch = "2"
+	// mpdEventUnknownDuration means that the remaining presentation duration is unknown.
+	mpdEventUnknownDuration = 0xffffffff
+)
+
+// addMPDEventStreams signals the in-band MPD events in AdaptationSet as.
+func addMPDEventStreams(as *m.AdaptationSetType, cfg *ResponseConfig) {
+	as.InbandEventStreams = append(as.InbandEventStreams,
+		&m.EventStreamType{SchemeIdUri: MPDEventScheme, Value: mpdEventValidityExpiration})
+	if cfg.PatchTTL > 0 {
+		as.InbandEventStreams = append(as.InbandEventStreams,
+			&m.EventStreamType{SchemeIdUri: MPDEventScheme, Value: mpdEventPatch})
+	}
+}
+
+// mpdChangeEmsgs returns the MPD event emsg boxes of the segment described by meta, or nil
+// if the MPD has not changed since the previous segment became available.
+func mpdChangeEmsgs(a *asset, cfg *ResponseConfig, drmCfg *drm.DrmConfig, meta segMeta) ([]*mp4.EmsgBox, error) {
+	ts := uint64(meta.timescale)
+	startMS := int(meta.newTime * 1000 / ts)
+	endMS := int((meta.newTime + uint64(meta.newDur)) * 1000 / ts)
+	if startMS <= 0 {
+		return nil, nil // no earlier MPD
+	}
+	mpdName := mpdNameForRep(a, meta.rep.ID)
+	if mpdName == "" {
+		return nil, nil
+	}
+	mpdCfg := mpdResponseConfig(cfg, a, mpdName)
+	astMS := cfg.StartTimeS * 1000
+	oldSnap, err := mpdSnapshotAt(a, mpdName, mpdCfg, drmCfg, astMS+startMS)
+	if err != nil {
+		return nil, err
+	}
+	newSnap, err := mpdSnapshotAt(a, mpdName, mpdCfg, drmCfg, astMS+endMS)
+	if err != nil {
+		return nil, err
+	}
+	if oldSnap.structure == newSnap.structure {
+		return nil, nil
+	}
+	emsgs := []*mp4.EmsgBox{mpdEventEmsg(meta, mpdEventValidityExpiration, []byte(newSnap.publishTime))}
+	if cfg.PatchTTL > 0 {
+		// No patch if the publishTime has not changed.
+		doc, _, err := patch.MPDDiff([]byte(oldSnap.full), []byte(newSnap.full))
+		if err == nil {
+			b, err := doc.WriteToBytes()
+			if err != nil {
+				return nil, fmt.Errorf("write patch: %w", err)
+			}
+			emsgs = append(emsgs, mpdEventEmsg(meta, mpdEventPatch, b))
+		}
+	}
+	return emsgs, nil
+}
+
+// maxMPDSnapshots is the number of cached MPD snapshots.
+const maxMPDSnapshots = 256
+
+// mpdSnapshot is a generated MPD, in full and without the parts changing with every segment.
+type mpdSnapshot struct {
+	full        string
+	structure   string
+	publishTime string
+}
+
+// mpdSnapshots caches the MPD snapshots per MPD URL and time.
+var mpdSnapshots = newBoundedCache[*mpdSnapshot](maxMPDSnapshots)
+
+// mpdSnapshotAt returns the snapshot of MPD mpdName generated with mpdCfg at nowMS. The host
+// and URL of mpdCfg have all the options, so they identify the MPD together with the time.
+func mpdSnapshotAt(a *asset, mpdName string, mpdCfg *ResponseConfig, drmCfg *drm.DrmConfig,
+	nowMS int) (*mpdSnapshot, error) {
+	key := fmt.Sprintf("%s%s@%d", mpdCfg.Host, strings.Join(mpdCfg.URLParts, "/"), nowMS)
+	if snap, ok := mpdSnapshots.get(key); ok {
+		return snap, nil
+	}
+	mpd, err := LiveMPD(a, mpdName, mpdCfg, drmCfg, nowMS)
+	if err != nil {
+		return nil, fmt.Errorf("LiveMPD: %w", err)
+	}
+	snap := &mpdSnapshot{publishTime: string(mpd.PublishTime)}
+	snap.full, err = mpd.WriteToString("", true)
+	if err != nil {
+		return nil, err
+	}
+	snap.structure, err = mpdStructure(mpd)
+	if err != nil {
+		return nil, err
+	}
+	mpdSnapshots.add(key, snap)
+	return snap, nil
+}
+
+// mpdEventEmsg returns an MPD event emsg expiring the MPD at the start of the segment.
+func mpdEventEmsg(meta segMeta, value string, data []byte) *mp4.EmsgBox {
+	return &mp4.EmsgBox{
+		Version:          1,
+		TimeScale:        meta.timescale,
+		PresentationTime: meta.newTime,
+		EventDuration:    mpdEventUnknownDuration,
+		ID:               meta.newNr,
+		SchemeIDURI:      MPDEventScheme,
+		Value:            value,
+		MessageData:      data,
+	}
+}
+
+// mpdEventsPublishTimeS returns the publishTime in seconds of a $Number$ MPD with MPD events,
+// which is the end of the latest segment, so that a changed MPD has a later publishTime.
+func mpdEventsPublishTimeS(a *asset, cfg *ResponseConfig, nowMS int) float64 {
+	astMS := cfg.StartTimeS * 1000
+	if nowMS <= astMS {
+		return float64(cfg.StartTimeS)
+	}
+	segEndMS := nowMS - (nowMS-astMS)%a.SegmentDurMS
+	return float64(segEndMS) / 1000
+}
+
+// mpdNameForRep returns the first MPD of the asset (in name order) with representation repID.
+func mpdNameForRep(a *asset, repID string) string {
+	for _, mpdName := range slices.Sorted(maps.Keys(a.MPDs)) {
+		mpd, err := a.getVodMPD(mpdName)
+		if err != nil {
+			continue
+		}
+		for _, as := range mpd.Periods[0].AdaptationSets {
+			for _, rep := range as.Representations {
+				if rep.Id == repID {
+					return mpdName
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// mpdResponseConfig returns a copy of the segment request cfg with the URL of MPD mpdName,
+// so that generated URLs such as PatchLocation are those of the MPD.
+func mpdResponseConfig(cfg *ResponseConfig, a *asset, mpdName string) *ResponseConfig {
+	mpdCfg := *cfg
+	urlParts := slices.Clone(cfg.URLParts[:cfg.URLContentIdx])
+	urlParts = append(urlParts, strings.Split(a.AssetPath, "/")...)
+	mpdCfg.URLParts = append(urlParts, mpdName)
+	return &mpdCfg
+}
+
+// mpdStructure returns the MPD as a string without the parts that change with every new segment.
+// The MPD is modified.
+func mpdStructure(mpd *m.MPD) (string, error) {
+	mpd.PublishTime = ""
+	mpd.PatchLocation = nil
+	mpd.UTCTimings = nil
+	for _, p := range mpd.Periods {
+		for _, as := range p.AdaptationSets {
+			clearSegmentTimeline(as.SegmentTemplate)
+			for _, rep := range as.Representations {
+				clearSegmentTimeline(rep.SegmentTemplate)
+			}
+		}
+	}
+	return mpd.WriteToString("", false)
+}
+
+// clearSegmentTimeline removes the SegmentTimeline and the startNumber that follows it.
+func clearSegmentTimeline(st *m.SegmentTemplateType) {
+	if st == nil || st.SegmentTimeline == nil {
+		return
+	}
+	st.SegmentTimeline = nil
+	st.StartNumber = nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestMPDEvents(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	getMPD := func(t *testing.T, url string) *m.MPD {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		return mpd
+	}
+	// getEmsgs returns the emsg boxes of a video segment fetched when it has just become available.
+	getEmsgs := func(t *testing.T, prefix string, seg, nowMS int) []*mp4.EmsgBox {
+		url := fmt.Sprintf("/livesim2/%stestpic_2s/V300/%d.m4s?nowMS=%d", prefix, seg, nowMS)
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		return f.Segments[0].Fragments[0].Emsgs
+	}
+
+	// A new Period every minute. The Period starting at 60s is in the MPD when segment 29
+	// [58s, 60s) becomes available, so that segment has the event.
+	prefix := "mpdevents_1/periods_60/"
+	mpd := getMPD(t, "/livesim2/"+prefix+"testpic_2s/Manifest.mpd?nowMS=60000")
+	require.Len(t, mpd.Periods, 2)
+	assert.Equal(t, "1970-01-01T00:01:00Z", string(mpd.PublishTime))
+	vAS := mpd.Periods[1].AdaptationSets[1]
+	require.Len(t, vAS.InbandEventStreams, 1)
+	assert.Equal(t, MPDEventScheme, string(vAS.InbandEventStreams[0].SchemeIdUri))
+	assert.Equal(t, "1", vAS.InbandEventStreams[0].Value)
+	for _, nr := range []int{28, 29, 30} {
+		emsgs := getEmsgs(t, prefix, nr, (nr+1)*2000+500)
+		if nr != 29 {
+			assert.Empty(t, emsgs, nr)
+			continue
+		}
+		require.Len(t, emsgs, 1)
+		e := emsgs[0]
+		assert.Equal(t, MPDEventScheme, e.SchemeIDURI)
+		assert.Equal(t, "1", e.Value)
+		assert.Equal(t, uint8(1), e.Version)
+		assert.Equal(t, uint64(58*90000), e.PresentationTime)
+		assert.Equal(t, uint32(0xffffffff), e.EventDuration)
+		assert.Equal(t, string(mpd.PublishTime), string(e.MessageData))
+	}
+
+	// The MPD at the end of segment 29 is cached and reused as the MPD before segment 30.
+	_, ok := mpdSnapshots.get(ts.URL + "/livesim2/" + prefix + "testpic_2s/Manifest.mpd@60000")
+	assert.True(t, ok)
+
+	// Without changes, there are no events.
+	assert.Empty(t, getEmsgs(t, "mpdevents_1/", 29, 60500))
+
+	// With patch_, there is also an MPD patch event from the MPD of the previous segment.
+	prefix = "mpdevents_1/segtimeline_1/periods_60/patch_60/"
+	mpd = getMPD(t, "/livesim2/"+prefix+"testpic_2s/Manifest.mpd?nowMS=60000")
+	require.Len(t, mpd.Periods[0].AdaptationSets[1].InbandEventStreams, 2)
+	emsgs := getEmsgs(t, prefix, 29*180000, 60500)
+	require.Len(t, emsgs, 2)
+	assert.Equal(t, "1970-01-01T00:01:00Z", string(emsgs[0].MessageData))
+	assert.Equal(t, "2", emsgs[1].Value)
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromBytes(emsgs[1].MessageData))
+	root := doc.Root()
+	assert.Equal(t, "Patch", root.Tag)
+	assert.Equal(t, "1970-01-01T00:00:58Z", root.SelectAttrValue("originalPublishTime", ""))
+	assert.Equal(t, "1970-01-01T00:01:00Z", root.SelectAttrValue("publishTime", ""))
+	patchLoc := root.FindElement("//PatchLocation")
+	require.NotNil(t, patchLoc)
+	assert.Contains(t, patchLoc.Text(), "/patch/livesim2/"+prefix+"testpic_2s/Manifest.mpp?publishTime=")
+	newPeriod := root.FindElement("//add/Period[@id='P1']")
+	require.NotNil(t, newPeriod)
+
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/mpdevents_1/ssai_30:20/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
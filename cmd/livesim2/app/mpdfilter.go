@@ -0,0 +1,239 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+)
+
+// MPD filters (as_ and reps_) select, drop and reorder the AdaptationSets of the Period and the
+// Representations of each AdaptationSet. A filter is a comma-separated list of selectors
+// [!][<field>:]<value>, where field is id (default), codec (prefix), type, or lang. If there
+// are selecting selectors, only the matching elements are kept, in the order of the first
+// selector matching them. Elements matching a dropping selector (with !) are then removed. An
+// AdaptationSet without any remaining Representation is removed as well. Segment requests for
+// Representations that are not in the filtered VoD MPDs of the asset return 404.
+
+// Fields of an MPD selector.
+const (
+	selectID    = "id"
+	selectCodec = "codec"
+	selectType  = "type"
+	selectLang  = "lang"
+)
+
+// MPDSelector matches AdaptationSets or Representations with a field value.
+type MPDSelector struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Drop  bool   `json:"drop,omitempty"`
+}
+
+// CreateMPDFilter parses a filter value of selectors [!][<field>:]<value>,..., such as
+// type:audio,!lang:sv.
+func CreateMPDFilter(val string) ([]MPDSelector, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty filter")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("filter %q has extra spaces", val)
+	}
+	parts := strings.Split(val, ",")
+	filter := make([]MPDSelector, 0, len(parts))
+	for _, part := range parts {
+		var s MPDSelector
+		part, s.Drop = strings.CutPrefix(part, "!")
+		field, value, ok := strings.Cut(part, ":")
+		if !ok {
+			field, value = selectID, part
+		}
+		switch field {
+		case selectID, selectCodec, selectType, selectLang:
+		default:
+			return nil, fmt.Errorf("selector %q: field must be id, codec, type, or lang", part)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("selector %q: empty value", part)
+		}
+		s.Field, s.Value = field, value
+		filter = append(filter, s)
+	}
+	return filter, nil
+}
+
+// ParseMPDFilter parses an as or reps option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseMPDFilter(key, val string) []MPDSelector {
+	if s.err != nil {
+		return nil
+	}
+	filter, err := CreateMPDFilter(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return filter
+}
+
+// matchFields returns true if the selector matches the id, codecs, content type, or language.
+func (s MPDSelector) matchFields(id string, codecs []string, contentType, lang string) bool {
+	switch s.Field {
+	case selectID:
+		return id == s.Value
+	case selectCodec:
+		return slices.ContainsFunc(codecs, func(c string) bool { return strings.HasPrefix(c, s.Value) })
+	case selectType:
+		return contentType == s.Value
+	case selectLang:
+		return strings.EqualFold(lang, s.Value)
+	}
+	return false
+}
+
+// matchAS returns true if the selector matches the AdaptationSet, where the codecs of its
+// Representations are also matched.
+func (s MPDSelector) matchAS(as *m.AdaptationSetType) bool {
+	id := ""
+	if as.Id != nil {
+		id = strconv.FormatUint(uint64(*as.Id), 10)
+	}
+	codecs := []string{as.Codecs}
+	for _, rep := range as.Representations {
+		codecs = append(codecs, rep.Codecs)
+	}
+	return s.matchFields(id, codecs, string(as.ContentType), as.Lang)
+}
+
+// matchRep returns true if the selector matches the Representation in as.
+func (s MPDSelector) matchRep(as *m.AdaptationSetType, rep *m.RepresentationType) bool {
+	codecs := rep.Codecs
+	if codecs == "" {
+		codecs = as.Codecs
+	}
+	return s.matchFields(rep.Id, []string{codecs}, string(as.ContentType), as.Lang)
+}
+
+// applyMPDFilter returns the items kept by filter in their new order.
+func applyMPDFilter[T any](filter []MPDSelector, items []T, match func(s MPDSelector, item T) bool) []T {
+	out := make([]T, 0, len(items))
+	selected := make([]bool, len(items))
+	for _, s := range filter {
+		if s.Drop {
+			continue
+		}
+		for i, item := range items {
+			if !selected[i] && match(s, item) {
+				selected[i] = true
+				out = append(out, item)
+			}
+		}
+	}
+	if !slices.ContainsFunc(filter, func(s MPDSelector) bool { return !s.Drop }) {
+		out = append(out, items...)
+	}
+	return slices.DeleteFunc(out, func(item T) bool {
+		return slices.ContainsFunc(filter, func(s MPDSelector) bool { return s.Drop && match(s, item) })
+	})
+}
+
+// errNoAdaptationSetLeft is returned if the filters remove all AdaptationSets of a Period.
+var errNoAdaptationSetLeft = errors.New("no adaptation set left after filtering")
+
+// filterPeriod applies the as and reps filters to period. It is an error if no
+// AdaptationSet remains.
+func filterPeriod(cfg *ResponseConfig, period *m.Period) error {
+	if len(cfg.ASFilter) > 0 {
+		period.AdaptationSets = applyMPDFilter(cfg.ASFilter, period.AdaptationSets, MPDSelector.matchAS)
+	}
+	if len(cfg.RepsFilter) > 0 {
+		for _, as := range period.AdaptationSets {
+			as.Representations = applyMPDFilter(cfg.RepsFilter, as.Representations,
+				func(s MPDSelector, rep *m.RepresentationType) bool { return s.matchRep(as, rep) })
+		}
+		removeEmptyAdaptationSets(period)
+	}
+	if len(period.AdaptationSets) == 0 {
+		return errNoAdaptationSetLeft
+	}
+	return nil
+}
+
+// removeEmptyAdaptationSets removes the AdaptationSets without Representations from period.
+func removeEmptyAdaptationSets(period *m.Period) {
+	period.AdaptationSets = slices.DeleteFunc(period.AdaptationSets, func(as *m.AdaptationSetType) bool {
+		return len(as.Representations) == 0
+	})
+}
+
+// maxFilteredRepIDs is the number of cached sets of filtered Representation ids.
+const maxFilteredRepIDs = 256
+
+// filteredRepIDsCache caches the filtered Representation ids per asset and filters.
+var filteredRepIDsCache = newBoundedCache[map[string]bool](maxFilteredRepIDs)
+
+// filteredRepIDs returns the ids of the Representations in the filtered VoD MPDs of the asset.
+// The result is cached, since it only depends on the asset, the ladder and the filters.
+func filteredRepIDs(a *asset, cfg *ResponseConfig) (map[string]bool, error) {
+	key := fmt.Sprintf("%s|%v|%v|%v", a.AssetPath, cfg.Ladder, cfg.ASFilter, cfg.RepsFilter)
+	if ids, ok := filteredRepIDsCache.get(key); ok {
+		return ids, nil
+	}
+	ids := make(map[string]bool)
+	for _, mpdName := range slices.Sorted(maps.Keys(a.MPDs)) {
+		mpd, err := a.getVodMPD(mpdName)
+		if err != nil {
+			return nil, fmt.Errorf("getVodMPD %s: %w", mpdName, err)
+		}
+		for _, period := range mpd.Periods {
+			fillContentTypes(a.AssetPath, period)
+			if len(cfg.Ladder) > 0 {
+				if err := applyLadder(cfg, period); err != nil {
+					continue // The MPD requests fail as well
+				}
+			}
+			if err := filterPeriod(cfg, period); err != nil {
+				continue
+			}
+			for _, as := range period.AdaptationSets {
+				for _, rep := range as.Representations {
+					ids[rep.Id] = true
+				}
+			}
+		}
+	}
+	filteredRepIDsCache.add(key, ids)
+	return ids, nil
+}
+
+// mpdFilterDropped returns true if segmentPart is an init or media segment of a Representation
+// removed by the filters. ladderKbps is the rung bitrate if segmentPart has been mapped to the
+// ladder source.
+func mpdFilterDropped(a *asset, cfg *ResponseConfig, segmentPart string, ladderKbps int) (bool, error) {
+	var rep *RepData
+	for _, r := range a.Reps {
+		if segmentPart == r.InitURI {
+			rep = r
+			break
+		}
+	}
+	if rep == nil {
+		var err error
+		rep, _, err = findRepAndSegmentID(a, segmentPart)
+		if err != nil {
+			return false, nil // Not a segment of the asset Representations
+		}
+	}
+	ids, err := filteredRepIDs(a, cfg)
+	if err != nil {
+		return false, err
+	}
+	return !ids[mpdRepID(rep, ladderKbps)], nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateMPDFilter(t *testing.T) {
+	filter, err := CreateMPDFilter("V300,!codec:mp4a,type:audio,lang:en")
+	require.NoError(t, err)
+	assert.Equal(t, []MPDSelector{
+		{Field: selectID, Value: "V300"},
+		{Field: selectCodec, Value: "mp4a", Drop: true},
+		{Field: selectType, Value: "audio"},
+		{Field: selectLang, Value: "en"},
+	}, filter)
+	for _, val := range []string{"", "V300,", "!", "lang:", "bw:300", "V300, A48"} {
+		_, err := CreateMPDFilter(val)
+		assert.Error(t, err, val)
+	}
+}
+
+func TestApplyMPDFilter(t *testing.T) {
+	match := func(s MPDSelector, item string) bool { return item == s.Value }
+	items := []string{"a", "b", "c"}
+	cases := []struct {
+		filter   string
+		expected []string
+	}{
+		{"c,a", []string{"c", "a"}},
+		{"!b", []string{"a", "c"}},
+		{"c,b,!b", []string{"c"}},
+		{"d", []string{}},
+	}
+	for _, c := range cases {
+		filter, err := CreateMPDFilter(c.filter)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, applyMPDFilter(filter, items, match), c.filter)
+	}
+}
+
+func TestMPDFilter(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	cases := []struct {
+		option   string
+		expected [][]string // Representation ids per AdaptationSet
+	}{
+		{"as_2,1", [][]string{{"V300"}, {"A48"}}},
+		{"as_type:video", [][]string{{"V300"}}},
+		{"as_lang:EN", [][]string{{"A48"}}},
+		{"reps_!V300", [][]string{{"A48"}}},
+		{"reps_codec:mp4a", [][]string{{"A48"}}},
+		{"ladder_100-1000/reps_V300_1000k,type:audio", [][]string{{"A48"}, {"V300_1000k"}}},
+	}
+	for _, c := range cases {
+		url := "/livesim2/" + c.option + "/testpic_2s/"
+		resp, body := testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, c.option)
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		p := mpd.Periods[0]
+		require.Len(t, p.AdaptationSets, len(c.expected), c.option)
+		kept := make(map[string]bool)
+		for i, as := range p.AdaptationSets {
+			ids := make([]string, 0, len(as.Representations))
+			for _, rep := range as.Representations {
+				ids = append(ids, rep.Id)
+				kept[rep.Id] = true
+			}
+			assert.Equal(t, c.expected[i], ids, c.option)
+		}
+		// The segments of the removed Representations are not found
+		repIDs := []string{"A48", "V300"}
+		if strings.HasPrefix(c.option, "ladder_") {
+			repIDs = []string{"A48", "V300_100k", "V300_1000k"}
+		}
+		for _, repID := range repIDs {
+			expected := http.StatusNotFound
+			if kept[repID] {
+				expected = http.StatusOK
+			}
+			resp, _ = testFullRequest(t, ts, "GET", url+repID+"/init.mp4?nowMS=100000", nil)
+			assert.Equal(t, expected, resp.StatusCode, c.option, repID)
+			resp, _ = testFullRequest(t, ts, "GET", url+repID+"/20.m4s?nowMS=100000", nil)
+			assert.Equal(t, expected, resp.StatusCode, c.option, repID)
+		}
+	}
+
+	// Without video, there is no trick-mode or ID3 AdaptationSet
+	for _, opt := range []string{"trickplay_1", "id3_track"} {
+		url := "/livesim2/as_type:audio/" + opt + "/testpic_2s/Manifest.mpd?nowMS=100000"
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		require.Len(t, mpd.Periods[0].AdaptationSets, 1, url)
+		assert.Equal(t, "audio", string(mpd.Periods[0].AdaptationSets[0].ContentType), url)
+	}
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/as_type:audio/trickplay_1/testpic_2s/V300_trick/20.m4s?nowMS=100000", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Filters that remove everything, or are invalid, are client errors
+	for _, opt := range []string{"as_!type:audio,!type:video", "reps_nope", "reps_bw:300"} {
+		resp, _ = testFullRequest(t, ts, "GET", "/livesim2/"+opt+"/testpic_2s/Manifest.mpd", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, opt)
+	}
+
+	// The filtered ids are cached per asset and filters
+	_, ok := filteredRepIDsCache.get("testpic_2s|[]|[]|[{id V300 true}]")
+	assert.True(t, ok)
+}
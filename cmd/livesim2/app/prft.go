@@ -0,0 +1,64 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+)
+
+// In-band producer reference times (prft_) are prft boxes in every segment or chunk of the
+// asset media. A segment or chunk becomes available at the wall-clock time of its end, so its
+// first frame entered the simulated encoder its duration plus the encoder-to-origin delay
+// earlier. The prft NTP time is therefore availabilityStartTime + media time - delay.
+// Each AdaptationSet has a ProducerReferenceTime with the same mapping and inband="true".
+
+// prftNTP returns the NTP time of media time t in timescale.
+func prftNTP(cfg *ResponseConfig, t uint64, timescale uint32) mp4.NTP64 {
+	wallClockMS := int64(cfg.StartTimeS)*1000 + int64(t*1000/uint64(timescale)) - int64(*cfg.PrftDelayMS)
+	return mp4.NewNTP64(float64(wallClockMS) / 1000)
+}
+
+// addPrft adds (or replaces) the prft box of fragment frag, just before its moof box.
+func addPrft(frag *mp4.Fragment, cfg *ResponseConfig, timescale uint32) {
+	traf := frag.Moof.Traf
+	mediaTime := traf.Tfdt.BaseMediaDecodeTime()
+	prft := mp4.CreatePrftBox(1, mp4.PrftTimeEncoderInput, traf.Tfhd.TrackID,
+		prftNTP(cfg, mediaTime, timescale), mediaTime)
+	children := make([]mp4.Box, 0, len(frag.Children)+1)
+	for _, c := range frag.Children {
+		switch c.(type) {
+		case *mp4.PrftBox:
+			continue
+		case *mp4.MoofBox:
+			children = append(children, prft)
+		}
+		children = append(children, c)
+	}
+	frag.Children = children
+	frag.Prft = prft
+}
+
+// addProducerReferenceTimes signals the in-band prft boxes by a ProducerReferenceTime in each
+// AdaptationSet of asset media. The presentationTime is the presentationTimeOffset.
+func addProducerReferenceTimes(mpd *m.MPD, a *asset, cfg *ResponseConfig) {
+	for _, p := range mpd.Periods {
+		for _, as := range p.AdaptationSets {
+			st := as.SegmentTemplate
+			if as.ContentType == "image" || st == nil || len(as.Representations) == 0 {
+				continue
+			}
+			if _, ok := a.Reps[as.Representations[0].Id]; !ok {
+				continue // generated track without prft
+			}
+			var pto uint64
+			if st.PresentationTimeOffset != nil {
+				pto = *st.PresentationTimeOffset
+			}
+			wallClockMS := cfg.StartTimeS*1000 + int(pto*1000/uint64(st.GetTimescale())) - *cfg.PrftDelayMS
+			as.ProducerReferenceTimes = createProducerReferenceTimes(float64(wallClockMS)/1000, pto, true)
+		}
+	}
+}
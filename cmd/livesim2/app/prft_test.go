@@ -0,0 +1,82 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestPrft(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	// One Period per minute: the ProducerReferenceTime of each AdaptationSet maps the
+	// presentationTimeOffset to its wall-clock time minus the 500ms delay.
+	resp, body := testFullRequest(t, ts, "GET",
+		"/livesim2/prft_500/periods_60/segtimeline_1/timesubsstpp_en/testpic_2s/Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	mpd, err := m.ReadFromString(string(body))
+	require.NoError(t, err)
+	require.Len(t, mpd.Periods, 2)
+	p := mpd.Periods[1]
+	require.Len(t, p.AdaptationSets, 3)
+	for i, ptoS := range []uint64{48000 * 60, 90000 * 60} {
+		prts := p.AdaptationSets[i].ProducerReferenceTimes
+		require.Len(t, prts, 1)
+		assert.True(t, prts[0].Inband)
+		assert.Equal(t, ptoS, prts[0].PresentationTime)
+		assert.Equal(t, "1970-01-01T00:00:59.5Z", prts[0].WallClockTime)
+	}
+	assert.Empty(t, p.AdaptationSets[2].ProducerReferenceTimes, "generated subtitles have no prft")
+
+	// prftTimes returns the NTP times in ms and the media times of the prft boxes of a segment.
+	prftTimes := func(t *testing.T, url string) (ntpMS []int64, mediaTimes []uint64) {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		for _, c := range f.Children {
+			if prft, ok := c.(*mp4.PrftBox); ok {
+				assert.Equal(t, uint32(mp4.PrftTimeEncoderInput), prft.Flags)
+				assert.Equal(t, uint32(2), prft.ReferenceTrackID, "video track")
+				ntpMS = append(ntpMS, int64(prft.NTPTimestamp.UTC()*1000+0.5))
+				mediaTimes = append(mediaTimes, prft.MediaTime)
+			}
+		}
+		return ntpMS, mediaTimes
+	}
+
+	ntpMS, mediaTimes := prftTimes(t, "/livesim2/prft_500/testpic_2s/V300/20.m4s?nowMS=100000")
+	assert.Equal(t, []int64{39_500}, ntpMS)
+	assert.Equal(t, []uint64{40 * 90000}, mediaTimes)
+
+	// Every chunk has a prft box
+	ntpMS, mediaTimes = prftTimes(t, "/livesim2/prft_0/chunkdur_0.5/ato_1.5/testpic_2s/V300/20.m4s?nowMS=100000")
+	assert.Equal(t, []int64{40_000, 40_500, 41_000, 41_500}, ntpMS)
+	assert.Equal(t, []uint64{40 * 90000, 40*90000 + 45000, 41 * 90000, 41*90000 + 45000}, mediaTimes)
+
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/prft_-1/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -12,6 +12,9 @@ import (
 
 var (
 	defaultBuckets = []float64{5, 10, 20, 50, 100, 200, 500, 1000}
+	// cmcdBLBuckets are buffer lengths in ms and cmcdMTPBuckets throughputs in kbps.
+	cmcdBLBuckets  = []float64{0, 500, 1000, 2000, 4000, 8000, 15000, 30000, 60000}
+	cmcdMTPBuckets = []float64{500, 1000, 2000, 5000, 10000, 20000, 50000, 100000, 500000}
 	prometheusMW   prometheusMiddleware
 )
 
@@ -22,6 +25,9 @@ const (
 	mpdLatencyName     = "mpd_request_duration_milliseconds"
 	otherReqsName      = "other_requests_total"
 	otherLatencyName   = "other_request_duration_milliseconds"
+	cmcdReportsName    = "cmcd_reports_total"
+	cmcdBLName         = "cmcd_buffer_length_milliseconds"
+	cmcdMTPName        = "cmcd_measured_throughput_kbps"
 )
 
 // prometheusMiddleware provides a handler that exposes prometheus metrics for various requests
@@ -32,6 +38,9 @@ type prometheusMiddleware struct {
 	mpdLatency     *prometheus.HistogramVec
 	otherReqs      *prometheus.CounterVec
 	otherLatency   *prometheus.HistogramVec
+	cmcdReports    *prometheus.CounterVec
+	cmcdBL         *prometheus.HistogramVec
+	cmcdMTP        *prometheus.HistogramVec
 }
 
 func init() {
@@ -47,6 +56,14 @@ func init() {
 		"Number other requests processed, partitioned by status code.", "livesim2")
 	prometheusMW.otherLatency = newHistogram(otherLatencyName,
 		"Other response latency.", "livesim2", defaultBuckets)
+	prometheusMW.cmcdReports = newLabeledCounter(cmcdReportsName,
+		"Number of CMCD payloads received, partitioned by mode.", "livesim2", "mode")
+	prometheusMW.cmcdBL = newLabeledHistogram(cmcdBLName,
+		"CMCD buffer length (bl) reported by clients, partitioned by object type.", "livesim2",
+		cmcdBLBuckets, "ot")
+	prometheusMW.cmcdMTP = newLabeledHistogram(cmcdMTPName,
+		"CMCD measured throughput (mtp) reported by clients, partitioned by object type.", "livesim2",
+		cmcdMTPBuckets, "ot")
 }
 
 // NewPrometheusMiddleware returns a new prometheus Middleware handler.
@@ -82,27 +99,62 @@ func (mw prometheusMiddleware) handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+// observeCMCD counts a CMCD payload and adds its bl and mtp values to the histograms.
+func (mw prometheusMiddleware) observeCMCD(mode CmcdMode, data map[string]string) {
+	if mw.cmcdReports == nil {
+		return
+	}
+	mw.cmcdReports.WithLabelValues(string(mode)).Inc()
+	ot := cmcdObjectTypeLabel(data["ot"])
+	for _, v := range cmcdInts(data["bl"]) {
+		mw.cmcdBL.WithLabelValues(ot).Observe(float64(v))
+	}
+	for _, v := range cmcdInts(data["mtp"]) {
+		mw.cmcdMTP.WithLabelValues(ot).Observe(float64(v))
+	}
+}
+
+// cmcdObjectTypeLabel returns the CTA-5004 object type ot as a label value, or "other"
+// for any other value, so that clients cannot create arbitrary series.
+func cmcdObjectTypeLabel(ot string) string {
+	switch ot {
+	case "m", "a", "v", "av", "i", "c", "tt", "k", "o":
+		return ot
+	default:
+		return "other"
+	}
+}
+
 func newCounter(counterName, help, serviceName string) *prometheus.CounterVec {
+	return newLabeledCounter(counterName, help, serviceName, "code")
+}
+
+func newLabeledCounter(counterName, help, serviceName, label string) *prometheus.CounterVec {
 	cv := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name:        counterName,
 			Help:        help,
 			ConstLabels: prometheus.Labels{"service": serviceName},
 		},
-		[]string{"code"},
+		[]string{label},
 	)
 	prometheus.MustRegister(cv)
 	return cv
 }
 
 func newHistogram(histogramName, help, serviceName string, buckets []float64) *prometheus.HistogramVec {
+	return newLabeledHistogram(histogramName, help, serviceName, buckets, "code")
+}
+
+func newLabeledHistogram(histogramName, help, serviceName string, buckets []float64,
+	label string) *prometheus.HistogramVec {
 	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:        histogramName,
 		Help:        help,
 		ConstLabels: prometheus.Labels{"service": serviceName},
 		Buckets:     buckets,
 	},
-		[]string{"code"},
+		[]string{label},
 	)
 	prometheus.MustRegister(h)
 	return h
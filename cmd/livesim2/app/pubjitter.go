@@ -0,0 +1,206 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Segment publish jitter. With pubjitter_, every segment is published to the "origin" a
+// number of milliseconds after its nominal availability time. The delay of a segment only
+// depends on its index counted from availabilityStartTime, so all representations of a
+// segment are delayed equally, and the MPD and the segment requests agree.
+
+const (
+	pubJitterRandom       = "rand"
+	pubJitterDefaultMaxMS = 1000
+	pubJitterDefaultSeed  = 1
+	maxPubJitterMS        = 60_000
+)
+
+// PubJitterConfig is the publish delay schedule. Either DelaysMS is a pattern that is
+// cycled through by segment index, or the delays are drawn uniformly from [MinMS, MaxMS]
+// with a seeded random generator.
+type PubJitterConfig struct {
+	DelaysMS []int  `json:"delaysMS,omitempty"`
+	Random   bool   `json:"random,omitempty"`
+	MinMS    int    `json:"minMS,omitempty"`
+	MaxMS    int    `json:"maxMS,omitempty"`
+	Seed     uint64 `json:"seed,omitempty"`
+	NotFound bool   `json:"notFound,omitempty"` // 404 instead of 425 for a delayed segment
+}
+
+// CreatePubJitterConfig parses <ms>,<ms>,...[;code=404|425] or
+// rand[;min=<ms>;max=<ms>;seed=<n>;code=404|425].
+func CreatePubJitterConfig(val string) (*PubJitterConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty pubjitter config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("pubjitter config %q has extra spaces", val)
+	}
+	parts := strings.Split(val, ";")
+	cfg := &PubJitterConfig{}
+	if parts[0] == pubJitterRandom {
+		cfg.Random = true
+		cfg.MaxMS = pubJitterDefaultMaxMS
+		cfg.Seed = pubJitterDefaultSeed
+	} else {
+		for _, d := range strings.Split(parts[0], ",") {
+			ms, err := parsePubJitterMS(d)
+			if err != nil {
+				return nil, err
+			}
+			cfg.DelaysMS = append(cfg.DelaysMS, ms)
+		}
+	}
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("pubjitter param %q must be key=val", kv)
+		}
+		var err error
+		switch {
+		case key == "code":
+			switch v {
+			case "404":
+				cfg.NotFound = true
+			case "425":
+				cfg.NotFound = false
+			default:
+				err = fmt.Errorf("pubjitter code %q: must be 404 or 425", v)
+			}
+		case key == "min" && cfg.Random:
+			cfg.MinMS, err = parsePubJitterMS(v)
+		case key == "max" && cfg.Random:
+			cfg.MaxMS, err = parsePubJitterMS(v)
+		case key == "seed" && cfg.Random:
+			cfg.Seed, err = strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				err = fmt.Errorf("pubjitter seed %q: %w", v, err)
+			}
+		default:
+			err = fmt.Errorf("unknown pubjitter param %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.MaxMS < cfg.MinMS {
+		return nil, fmt.Errorf("pubjitter max %dms is less than min %dms", cfg.MaxMS, cfg.MinMS)
+	}
+	return cfg, nil
+}
+
+// parsePubJitterMS parses a delay in milliseconds.
+func parsePubJitterMS(val string) (int, error) {
+	ms, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("pubjitter delay %q: %w", val, err)
+	}
+	if ms < 0 || ms > maxPubJitterMS {
+		return 0, fmt.Errorf("pubjitter delay %dms must be in [0, %d]ms", ms, maxPubJitterMS)
+	}
+	return ms, nil
+}
+
+// ParsePubJitterConfig parses a pubjitter option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParsePubJitterConfig(key, val string) *PubJitterConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreatePubJitterConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// delayMS returns the publish delay of the segment with index idx since availabilityStartTime.
+func (pj *PubJitterConfig) delayMS(idx uint64) int {
+	if !pj.Random {
+		return pj.DelaysMS[idx%uint64(len(pj.DelaysMS))]
+	}
+	rng := rand.New(rand.NewPCG(pj.Seed, idx))
+	return pj.MinMS + rng.IntN(pj.MaxMS-pj.MinMS+1)
+}
+
+// pubDelayMS returns the publish delay of segment idx, or 0 without pubjitter_.
+func (rc *ResponseConfig) pubDelayMS(idx uint64) int {
+	if rc.PubJitter == nil {
+		return 0
+	}
+	return rc.PubJitter.delayMS(idx)
+}
+
+// checkSegmentTime checks that the segment with index idx and nominal availability time
+// segAvailTimeS is available at nowMS, taking the publish delay into account.
+// A delayed segment gives 404 instead of 425 if so configured.
+func checkSegmentTime(cfg *ResponseConfig, segAvailTimeS float64, nowMS int, idx uint64) error {
+	nowS := float64(nowMS) * 0.001
+	tsbdS := float64(*cfg.TimeShiftBufferDepthS)
+	atoS := cfg.getAvailabilityTimeOffsetS()
+	delayMS := cfg.pubDelayMS(idx)
+	err := CheckTimeValidity(segAvailTimeS+float64(delayMS)*0.001, nowS, tsbdS, atoS)
+	if err != nil && delayMS > 0 && cfg.PubJitter.NotFound {
+		var tooEarly errTooEarly
+		if errors.As(err, &tooEarly) && CheckTimeValidity(segAvailTimeS, nowS, tsbdS, atoS) == nil {
+			return errNotFound
+		}
+	}
+	return err
+}
+
+// dropUnpublished cuts the timeline at the first segment that is not yet published at
+// nowMS. The delays need not increase with the segment index, so an earlier segment may
+// be published after a later one, and the timeline must not have holes.
+func (rc *ResponseConfig) dropUnpublished(se *segEntries, nowMS, atoMS int) {
+	firstNr := rc.firstUnpublishedNr(se, nowMS, atoMS)
+	if firstNr < 0 {
+		return
+	}
+	for se.lsi.nr >= firstNr {
+		last := se.entries[len(se.entries)-1]
+		if last.R > 0 {
+			last.R--
+		} else {
+			se.entries = se.entries[:len(se.entries)-1]
+		}
+		if len(se.entries) == 0 {
+			se.startNr = -1
+			se.lsi.nr = -1
+			return
+		}
+		se.lsi.nr--
+		se.lsi.startTime = se.lastTime()
+		se.lsi.dur = se.entries[len(se.entries)-1].D
+	}
+}
+
+// firstUnpublishedNr returns the number of the first segment of the timeline that is not
+// yet published at nowMS, or -1 if all segments are published.
+func (rc *ResponseConfig) firstUnpublishedNr(se *segEntries, nowMS, atoMS int) int {
+	nr := se.startNr
+	var t uint64
+	for _, e := range se.entries {
+		if e.T != nil {
+			t = *e.T
+		}
+		for range e.R + 1 {
+			t += e.D
+			availMS := int(t*1000/uint64(se.mediaTimescale)) + rc.StartTimeS*1000 - atoMS
+			if availMS+rc.pubDelayMS(uint64(nr)) > nowMS {
+				return nr
+			}
+			nr++
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreatePubJitterConfig(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    *PubJitterConfig
+		wantErr string
+	}{
+		{val: "0,500,1500", want: &PubJitterConfig{DelaysMS: []int{0, 500, 1500}}},
+		{val: "800;code=404", want: &PubJitterConfig{DelaysMS: []int{800}, NotFound: true}},
+		{val: "rand", want: &PubJitterConfig{Random: true, MaxMS: 1000, Seed: 1}},
+		{val: "rand;min=100;max=3000;seed=9;code=425", want: &PubJitterConfig{Random: true, MinMS: 100, MaxMS: 3000, Seed: 9}},
+		{val: "", wantErr: "empty pubjitter config"},
+		{val: "1,x", wantErr: "pubjitter delay"},
+		{val: "-1", wantErr: "must be in"},
+		{val: "100000", wantErr: "must be in"},
+		{val: "500;seed=1", wantErr: "unknown pubjitter param"},
+		{val: "rand;min=300;max=200", wantErr: "less than min"},
+		{val: "rand;code=500", wantErr: "must be 404 or 425"},
+		{val: "rand;max", wantErr: "must be key=val"},
+		{val: "rand; max=5", wantErr: "extra spaces"},
+	}
+	for _, c := range cases {
+		got, err := CreatePubJitterConfig(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestPubJitterDelay(t *testing.T) {
+	pj := PubJitterConfig{DelaysMS: []int{0, 500, 1500}}
+	assert.Equal(t, []int{0, 500, 1500, 0}, []int{pj.delayMS(0), pj.delayMS(1), pj.delayMS(2), pj.delayMS(3)})
+	pj = PubJitterConfig{Random: true, MinMS: 100, MaxMS: 300, Seed: 3}
+	for idx := uint64(0); idx < 100; idx++ {
+		d := pj.delayMS(idx)
+		assert.True(t, d >= 100 && d <= 300, "delay %d", d)
+		assert.Equal(t, d, pj.delayMS(idx), "deterministic")
+	}
+}
+
+func TestPubJitterStream(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	// Segment 50 (100-102s) has delay 1500ms, so it is published at 103.5s.
+	lastVideoT := func(t *testing.T, url string) uint64 {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		stl := mpd.Periods[0].AdaptationSets[1].SegmentTemplate.SegmentTimeline
+		var t0 uint64
+		for _, s := range stl.S {
+			if s.T != nil {
+				t0 = *s.T
+			}
+			t0 += s.D * uint64(s.R+1)
+		}
+		return t0 - 180000
+	}
+	assert.Equal(t, uint64(98*90000), lastVideoT(t, "/livesim2/segtimeline_1/pubjitter_0,500,1500/testpic_2s/Manifest.mpd?nowMS=103000"))
+	assert.Equal(t, uint64(100*90000), lastVideoT(t, "/livesim2/segtimeline_1/pubjitter_0,500,1500/testpic_2s/Manifest.mpd?nowMS=103500"))
+	// Segment 48 (96-98s) is published at 103s, after segment 49 (98-100s), so the timeline
+	// ends with segment 47 until then.
+	assert.Equal(t, uint64(94*90000), lastVideoT(t, "/livesim2/segtimeline_1/pubjitter_5000,0/testpic_2s/Manifest.mpd?nowMS=102500"))
+	assert.Equal(t, uint64(98*90000), lastVideoT(t, "/livesim2/segtimeline_1/pubjitter_5000,0/testpic_2s/Manifest.mpd?nowMS=103000"))
+
+	cases := []struct {
+		url      string
+		wantCode int
+	}{
+		{"/livesim2/pubjitter_0,500,1500/testpic_2s/V300/50.m4s?nowMS=103000", http.StatusTooEarly},
+		{"/livesim2/pubjitter_0,500,1500/testpic_2s/A48/50.m4s?nowMS=103000", http.StatusTooEarly},
+		{"/livesim2/pubjitter_0,500,1500/testpic_2s/V300/50.m4s?nowMS=103500", http.StatusOK},
+		{"/livesim2/pubjitter_0,500,1500;code=404/testpic_2s/V300/50.m4s?nowMS=103000", http.StatusNotFound},
+		{"/livesim2/pubjitter_0,500,1500;code=404/testpic_2s/V300/51.m4s?nowMS=103000", http.StatusTooEarly},
+		{"/livesim2/segtimeline_1/pubjitter_0,500,1500/testpic_2s/V300/9000000.m4s?nowMS=103000", http.StatusTooEarly},
+		{"/livesim2/segtimeline_1/pubjitter_0,500,1500/testpic_2s/V300/9000000.m4s?nowMS=103500", http.StatusOK},
+		{"/livesim2/segtimeline_1/pubjitter_0,500,1500/testpic_2s/A48/4800512.m4s?nowMS=103000", http.StatusTooEarly},
+		{"/livesim2/segtimeline_1/pubjitter_5000,0/testpic_2s/V300/8640000.m4s?nowMS=102500", http.StatusTooEarly},
+		{"/livesim2/segtimeline_1/pubjitter_5000,0/testpic_2s/V300/8820000.m4s?nowMS=102500", http.StatusOK},
+	}
+	for _, c := range cases {
+		resp, _ := testFullRequest(t, ts, "GET", c.url, nil)
+		assert.Equal(t, c.wantCode, resp.StatusCode, c.url)
+	}
+
+	cfgJ := NewResponseConfig()
+	cfgJ.PubJitter = &PubJitterConfig{DelaysMS: []int{0, 500, 1500}}
+	a, ok := server.assetMgr.findAsset("testpic_2s")
+	require.True(t, ok)
+	availMS, err := calcSegmentAvailabilityTime(a, a.refRep, 50, cfgJ)
+	require.NoError(t, err)
+	assert.Equal(t, int64(103_500), availMS)
+}
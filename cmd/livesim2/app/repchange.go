@@ -0,0 +1,193 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+)
+
+// Representation changes (repchange_) emulate an encoder that drops and adds back some
+// Representations during a live session. With repchange_<cycleS>;drop=<ids>, the listed
+// Representations are present during the first cycleS-durS seconds of every cycle after
+// availabilityStartTime, and absent during the last durS seconds, as are their trick-mode
+// Representations. An AdaptationSet without any remaining Representation is absent as well.
+// The Representations can be ladder_ rungs.
+//
+// In mpd mode (default), the MPD has a single Period, and the Representations are present
+// or not depending on the time of the MPD request. In period mode, the MPD has a new Period
+// at every change in the time-shift window. Segments of a Representation that start when
+// the Representation is absent, as well as its init segment while it is absent, return 404.
+
+// RepChangeMode tells how a Representation change is signaled in the MPD.
+type RepChangeMode string
+
+const (
+	RepChangeModeMPD    RepChangeMode = "mpd"    // the same Period changes
+	RepChangeModePeriod RepChangeMode = "period" // new Period at each change
+)
+
+// RepChangeConfig is the schedule of Representations dropped during live.
+type RepChangeConfig struct {
+	CycleS int           `json:"cycleS"`
+	DurS   int           `json:"durS"`
+	Drop   []string      `json:"drop"`
+	Mode   RepChangeMode `json:"mode"`
+}
+
+// CreateRepChangeConfig parses <cycleS>;drop=<id>,<id>...[;dur=<s>;mode=mpd|period].
+func CreateRepChangeConfig(val string) (*RepChangeConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty repchange config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("repchange config %q has extra spaces", val)
+	}
+	parts := strings.Split(val, ";")
+	cycle, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("repchange cycle %q: %w", parts[0], err)
+	}
+	cfg := &RepChangeConfig{CycleS: cycle, DurS: cycle / 2, Mode: RepChangeModeMPD}
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("repchange param %q must be key=val", kv)
+		}
+		switch key {
+		case "drop":
+			cfg.Drop = strings.Split(v, ",")
+			if slices.Contains(cfg.Drop, "") {
+				return nil, fmt.Errorf("repchange drop %q has an empty id", v)
+			}
+		case "dur":
+			cfg.DurS, err = strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("repchange dur %q: %w", v, err)
+			}
+		case "mode":
+			switch RepChangeMode(v) {
+			case RepChangeModeMPD, RepChangeModePeriod:
+				cfg.Mode = RepChangeMode(v)
+			default:
+				return nil, fmt.Errorf("repchange mode %q: must be mpd or period", v)
+			}
+		default:
+			return nil, fmt.Errorf("unknown repchange param %q", key)
+		}
+	}
+	if len(cfg.Drop) == 0 {
+		return nil, fmt.Errorf("repchange config %q has no drop ids", val)
+	}
+	if cfg.DurS <= 0 || cfg.DurS >= cfg.CycleS {
+		return nil, fmt.Errorf("repchange dur %ds must be in [1, %d]", cfg.DurS, cfg.CycleS-1)
+	}
+	return cfg, nil
+}
+
+// ParseRepChangeConfig parses a repchange option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseRepChangeConfig(key, val string) *RepChangeConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreateRepChangeConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// periodMode returns true if the changes are signaled by new Periods.
+func (rc *RepChangeConfig) periodMode() bool {
+	return rc != nil && rc.Mode == RepChangeModePeriod
+}
+
+// droppedAt returns true if the Representations are absent at relMS after availabilityStartTime.
+func (rc *RepChangeConfig) droppedAt(relMS int) bool {
+	return relMS >= 0 && relMS%(rc.CycleS*1000) >= (rc.CycleS-rc.DurS)*1000
+}
+
+// stateNr returns the number of the state (even when present, odd when dropped) at relS.
+func (rc *RepChangeConfig) stateNr(relS int) int {
+	nr := 2 * (relS / rc.CycleS)
+	if rc.droppedAt(relS * 1000) {
+		nr++
+	}
+	return nr
+}
+
+// stateStartS returns the start of state nr in seconds after availabilityStartTime.
+func (rc *RepChangeConfig) stateStartS(nr int) int {
+	return nr/2*rc.CycleS + nr%2*(rc.CycleS-rc.DurS)
+}
+
+// dropReps removes the Representations with the dropped ids from period, together with
+// their trick-mode Representations, and the AdaptationSets that no longer have any
+// Representation.
+func dropReps(rc *RepChangeConfig, period *m.Period) {
+	for _, as := range period.AdaptationSets {
+		as.Representations = slices.DeleteFunc(as.Representations, func(rep *m.RepresentationType) bool {
+			return slices.Contains(rc.Drop, strings.TrimSuffix(rep.Id, trickPlayRepSuffix))
+		})
+	}
+	removeEmptyAdaptationSets(period)
+}
+
+// splitRepChangePeriods replaces the single Period of mpd by one Period per state in the
+// time-shift window. The changes must be at segment boundaries.
+func splitRepChangePeriods(mpd *m.MPD, a *asset, cfg *ResponseConfig, wTimes wrapTimes) error {
+	if len(mpd.Periods) != 1 {
+		return fmt.Errorf("not exactly one period in the MPD")
+	}
+	rc := cfg.RepChange
+	for _, s := range []int{rc.CycleS, rc.DurS} {
+		if s*1000%a.SegmentDurMS != 0 {
+			return fmt.Errorf("repchange time %ds not a multiple of segment duration %dms", s, a.SegmentDurMS)
+		}
+	}
+	astMS := cfg.StartTimeS * 1000
+	startRelS := max(wTimes.startTimeMS-astMS, 0) / 1000
+	nowRelMS := wTimes.nowMS - astMS
+	inPeriod := mpd.Periods[0]
+	mpd.Periods = nil
+	for nr := rc.stateNr(startRelS); rc.stateStartS(nr)*1000 <= nowRelMS; nr++ {
+		p, err := cutPeriod(inPeriod, cfg, fmt.Sprintf("P%d", nr), rc.stateStartS(nr), rc.stateStartS(nr+1))
+		if err != nil {
+			return err
+		}
+		if nr%2 == 1 {
+			dropReps(rc, p)
+		}
+		mpd.AppendPeriod(p)
+	}
+	return nil
+}
+
+// repChangeDropped returns true if segmentPart is the init segment of a dropped
+// Representation at nowMS, or a media segment of a Representation dropped at its start.
+// ladderKbps is the rung bitrate if segmentPart has been mapped to the ladder source.
+func repChangeDropped(a *asset, cfg *ResponseConfig, segmentPart string, ladderKbps, nowMS int) bool {
+	rc := cfg.RepChange
+	astMS := cfg.StartTimeS * 1000
+	for _, rep := range a.Reps {
+		if segmentPart == rep.InitURI {
+			return slices.Contains(rc.Drop, mpdRepID(rep, ladderKbps)) && rc.droppedAt(nowMS-astMS)
+		}
+	}
+	rep, _, err := findRepAndSegmentID(a, segmentPart)
+	if err != nil || !slices.Contains(rc.Drop, mpdRepID(rep, ladderKbps)) {
+		return false
+	}
+	sm, err := findSegMeta(a, cfg, segmentPart, nowMS)
+	if err != nil {
+		return false // Let the segment generation report the error
+	}
+	return rc.droppedAt(int(sm.newTime * 1000 / uint64(sm.timescale)))
+}
@@ -0,0 +1,164 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateRepChangeConfig(t *testing.T) {
+	rc, err := CreateRepChangeConfig("60;drop=V300,A48")
+	require.NoError(t, err)
+	assert.Equal(t, &RepChangeConfig{CycleS: 60, DurS: 30, Drop: []string{"V300", "A48"}, Mode: RepChangeModeMPD}, rc)
+	rc, err = CreateRepChangeConfig("60;drop=V300;dur=20;mode=period")
+	require.NoError(t, err)
+	assert.Equal(t, &RepChangeConfig{CycleS: 60, DurS: 20, Drop: []string{"V300"}, Mode: RepChangeModePeriod}, rc)
+	for _, val := range []string{"", "60", "x;drop=V300", "60;drop=", "60;drop=V300,", "60;drop=V300;dur=60",
+		"60;drop=V300;dur=0", "60;drop=V300;mode=other", "60;drop=V300;other=1", "60;drop V300"} {
+		_, err := CreateRepChangeConfig(val)
+		assert.Error(t, err, val)
+	}
+}
+
+func TestRepChangeStates(t *testing.T) {
+	rc := &RepChangeConfig{CycleS: 60, DurS: 20}
+	assert.False(t, rc.droppedAt(39_999))
+	assert.True(t, rc.droppedAt(40_000))
+	assert.False(t, rc.droppedAt(60_000))
+	assert.False(t, rc.droppedAt(-20_000))
+	assert.Equal(t, 2, rc.stateNr(60))
+	assert.Equal(t, 3, rc.stateNr(119))
+	assert.Equal(t, 100, rc.stateStartS(3))
+	assert.Equal(t, 120, rc.stateStartS(4))
+}
+
+func TestRepChange(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	// V300 is present during [0, 30s) and dropped during [30s, 60s) of every minute.
+	for _, prefix := range []string{"", "segtimeline_1/"} {
+		url := "/livesim2/" + prefix + "repchange_60;drop=V300/testpic_2s/"
+		resp, body := testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=80000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		require.Len(t, mpd.Periods, 1)
+		assert.Len(t, mpd.Periods[0].AdaptationSets, 2)
+
+		resp, body = testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		mpd, err = m.ReadFromString(string(body))
+		require.NoError(t, err)
+		require.Len(t, mpd.Periods, 1)
+		require.Len(t, mpd.Periods[0].AdaptationSets, 1, "video AdaptationSet without representations")
+		assert.Equal(t, "audio", string(mpd.Periods[0].AdaptationSets[0].ContentType))
+
+		seg20, seg10 := "20.m4s", "10.m4s"
+		if prefix != "" {
+			seg20, seg10 = "3600000.m4s", "1800000.m4s"
+		}
+		resp, _ = testFullRequest(t, ts, "GET", url+"V300/init.mp4?nowMS=80000", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = testFullRequest(t, ts, "GET", url+"V300/init.mp4?nowMS=100000", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		// Segment 20 starts at 40s, when V300 is dropped, and segment 10 at 20s, when it is present
+		resp, _ = testFullRequest(t, ts, "GET", url+"V300/"+seg20+"?nowMS=80000", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, url)
+		resp, _ = testFullRequest(t, ts, "GET", url+"V300/"+seg10+"?nowMS=50000", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, url)
+	}
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/repchange_60;drop=V300/testpic_2s/A48/20.m4s?nowMS=100000", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Ladder rungs can be dropped
+	url := "/livesim2/ladder_100-1000/repchange_60;drop=V300_1000k/testpic_2s/"
+	resp, body := testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	mpd, err := m.ReadFromString(string(body))
+	require.NoError(t, err)
+	vAS := mpd.Periods[0].AdaptationSets[1]
+	require.Len(t, vAS.Representations, 1)
+	assert.Equal(t, "V300_100k", vAS.Representations[0].Id)
+	resp, _ = testFullRequest(t, ts, "GET", url+"V300_1000k/20.m4s?nowMS=100000", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = testFullRequest(t, ts, "GET", url+"V300_100k/20.m4s?nowMS=100000", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// In period mode, there is a new Period at every change in the time-shift window.
+	url = "/livesim2/tsbd_60/repchange_60;drop=V300;mode=period/testpic_2s/"
+	resp, body = testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	mpd, err = m.ReadFromString(string(body))
+	require.NoError(t, err)
+	require.Len(t, mpd.Periods, 3)
+	for i, p := range mpd.Periods {
+		nr := i + 1 // The time-shift window starts at 40s, in state 1 [30s, 60s)
+		assert.Equal(t, fmt.Sprintf("P%d", nr), p.Id)
+		assert.Equal(t, float64(30*nr), p.Start.Seconds())
+		nrAS := 2
+		if nr%2 == 1 {
+			nrAS = 1
+		}
+		require.Len(t, p.AdaptationSets, nrAS, p.Id)
+		as := p.AdaptationSets[nrAS-1]
+		assert.Equal(t, uint32(15*nr), *as.SegmentTemplate.StartNumber, p.Id)
+	}
+
+	// The trick-mode Representation is dropped together with its source
+	url = "/livesim2/tsbd_60/trickplay_1/repchange_60;drop=V300;mode=period/testpic_2s/"
+	resp, body = testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	mpd, err = m.ReadFromString(string(body))
+	require.NoError(t, err)
+	require.Len(t, mpd.Periods, 3)
+	for i, p := range mpd.Periods {
+		nrAS := 3
+		if i%2 == 0 {
+			nrAS = 1 // P1 and P3 without V300 and V300_trick
+		}
+		assert.Len(t, p.AdaptationSets, nrAS, p.Id)
+	}
+	resp, _ = testFullRequest(t, ts, "GET", url+"V300_trick/20.m4s?nowMS=100000", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = testFullRequest(t, ts, "GET", url+"V300_trick/35.m4s?nowMS=100000", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// In mpd mode, there is no trick-mode AdaptationSet while the video is dropped
+	url = "/livesim2/trickplay_1/repchange_60;drop=V300/testpic_2s/"
+	resp, body = testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	mpd, err = m.ReadFromString(string(body))
+	require.NoError(t, err)
+	assert.Len(t, mpd.Periods[0].AdaptationSets, 1)
+
+	// The changes in period mode must be at segment boundaries
+	url = "/livesim2/repchange_60;drop=V300;mode=period;dur=15/testpic_2s/"
+	resp, _ = testFullRequest(t, ts, "GET", url+"Manifest.mpd", nil)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	for _, opt := range []string{"periods_60", "ssai_30:20"} {
+		url = "/livesim2/" + opt + "/repchange_60;drop=V300/testpic_2s/Manifest.mpd"
+		resp, _ = testFullRequest(t, ts, "GET", url, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, opt)
+	}
+}
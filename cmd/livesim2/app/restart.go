@@ -0,0 +1,225 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/drm"
+	m "github.com/Eyevinn/dash-mpd/mpd"
+)
+
+// Encoder restart emulation. With restart_<cycleS>, the encoder crashes every cycleS seconds
+// after availabilityStartTime and comes back after gap seconds. Each run of the encoder (an
+// incarnation) restarts its timestamps at zero, so it is generated exactly like a stream with
+// availabilityStartTime at the restart. Incarnation i runs from
+//
+//	S_i = AST + i*cycle + gap (S_0 = AST) to E_i = AST + (i+1)*cycle.
+//
+// In period mode (default), the MPD keeps its availabilityStartTime and has one Period per
+// incarnation in the time-shift window, starting at S_i with presentationTimeOffset 0. The
+// Periods get absolute BaseURLs with an rst_<i> path token so that segments with the same
+// time or number in different incarnations can be told apart. In cont mode, the MPD has a
+// single Period and availabilityStartTime moves to S_i.
+
+// RestartMode tells how an encoder restart is signaled in the MPD.
+type RestartMode string
+
+const (
+	RestartModePeriod RestartMode = "period" // new Period per incarnation
+	RestartModeCont   RestartMode = "cont"   // availabilityStartTime changes
+)
+
+const (
+	restartTokenPrefix = "rst_"
+	restartDefaultGapS = 10
+)
+
+// RestartConfig is the schedule of emulated encoder restarts.
+type RestartConfig struct {
+	CycleS  int         `json:"cycleS"`
+	GapS    int         `json:"gapS"`
+	ResetNr bool        `json:"resetNr,omitempty"`
+	Mode    RestartMode `json:"mode"`
+}
+
+// CreateRestartConfig parses <cycleS>[;gap=<s>;snr=0|1;mode=period|cont].
+func CreateRestartConfig(val string) (*RestartConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty restart config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("restart config %q has extra spaces", val)
+	}
+	parts := strings.Split(val, ";")
+	cycle, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("restart cycle %q: %w", parts[0], err)
+	}
+	cfg := &RestartConfig{CycleS: cycle, GapS: restartDefaultGapS, Mode: RestartModePeriod}
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("restart param %q must be key=val", kv)
+		}
+		switch key {
+		case "gap":
+			cfg.GapS, err = strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("restart gap %q: %w", v, err)
+			}
+		case "snr":
+			switch v {
+			case "1", "true":
+				cfg.ResetNr = true
+			case "0", "false":
+				cfg.ResetNr = false
+			default:
+				return nil, fmt.Errorf("restart snr %q: must be 0 or 1", v)
+			}
+		case "mode":
+			switch RestartMode(v) {
+			case RestartModePeriod, RestartModeCont:
+				cfg.Mode = RestartMode(v)
+			default:
+				return nil, fmt.Errorf("restart mode %q: must be period or cont", v)
+			}
+		default:
+			return nil, fmt.Errorf("unknown restart param %q", key)
+		}
+	}
+	if cfg.GapS < 0 {
+		return nil, fmt.Errorf("restart gap %ds must be >= 0", cfg.GapS)
+	}
+	if cfg.CycleS <= cfg.GapS {
+		return nil, fmt.Errorf("restart cycle %ds must be longer than the gap %ds", cfg.CycleS, cfg.GapS)
+	}
+	return cfg, nil
+}
+
+// ParseRestartConfig parses a restart option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseRestartConfig(key, val string) *RestartConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreateRestartConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// startS returns the start S_i of incarnation inc in seconds since epoch.
+func (rc *RestartConfig) startS(astS, inc int) int {
+	if inc == 0 {
+		return astS
+	}
+	return astS + inc*rc.CycleS + rc.GapS
+}
+
+// endS returns the end E_i (the crash) of incarnation inc in seconds since epoch.
+func (rc *RestartConfig) endS(astS, inc int) int {
+	return astS + (inc+1)*rc.CycleS
+}
+
+// lastIncarnation returns the latest incarnation that has started at nowMS. During a gap,
+// that is the one that crashed.
+func (rc *RestartConfig) lastIncarnation(astS, nowMS int) int {
+	inc := (nowMS - astS*1000) / (rc.CycleS * 1000)
+	if inc > 0 && nowMS < rc.startS(astS, inc)*1000 {
+		inc--
+	}
+	return max(inc, 0)
+}
+
+// restartIncarnationCfg returns a configuration that generates incarnation inc as a stream of
+// its own, and the time to generate it at (clamped to the crash). ok is false if the
+// incarnation has not started or is outside the time-shift window at nowMS.
+func restartIncarnationCfg(cfg *ResponseConfig, segDurMS, inc, nowMS int) (incCfg *ResponseConfig, incNowMS int, ok bool) {
+	rc := cfg.Restart
+	if inc < 0 {
+		return nil, 0, false
+	}
+	startS := rc.startS(cfg.StartTimeS, inc)
+	endS := rc.endS(cfg.StartTimeS, inc)
+	c, incNowMS, ok := periodStreamCfg(cfg, startS-cfg.StartTimeS, endS-cfg.StartTimeS, nowMS)
+	if !ok {
+		return nil, 0, false
+	}
+	c.Restart = nil
+	c.RestartInc = nil
+	if !rc.ResetNr && inc > 0 && segDurMS > 0 {
+		// Continue the numbering as if segments had been produced during the gaps.
+		elapsedMS := (startS - cfg.StartTimeS) * 1000
+		c.StartNr = Ptr(cfg.getStartNr() + uint32((elapsedMS+segDurMS-1)/segDurMS))
+	}
+	return c, incNowMS, true
+}
+
+// restartSegmentCfg returns the configuration and time for a segment request. The segment
+// belongs to the incarnation in the rst_ path token or, without token, the latest one.
+func restartSegmentCfg(cfg *ResponseConfig, segDurMS, nowMS int) (*ResponseConfig, int, bool) {
+	inc := cfg.Restart.lastIncarnation(cfg.StartTimeS, nowMS)
+	if cfg.RestartInc != nil {
+		if *cfg.RestartInc > inc {
+			return nil, 0, false
+		}
+		inc = *cfg.RestartInc
+	}
+	return restartIncarnationCfg(cfg, segDurMS, inc, nowMS)
+}
+
+// restartLiveMPD generates the MPD of a stream with encoder restarts.
+func restartLiveMPD(a *asset, mpdName string, cfg *ResponseConfig, drmCfg *drm.DrmConfig, nowMS int) (*m.MPD, error) {
+	rc := cfg.Restart
+	last := rc.lastIncarnation(cfg.StartTimeS, nowMS)
+	first := last
+	if rc.Mode == RestartModePeriod && cfg.TimeShiftBufferDepthS != nil {
+		windowStartMS := nowMS - *cfg.TimeShiftBufferDepthS*1000
+		first = max(0, min(last, (windowStartMS-cfg.StartTimeS*1000)/(rc.CycleS*1000)))
+	}
+	var mpd *m.MPD
+	var periods []*m.Period
+	for inc := first; inc <= last; inc++ {
+		incCfg, incNowMS, ok := restartIncarnationCfg(cfg, a.SegmentDurMS, inc, nowMS)
+		if !ok {
+			continue
+		}
+		incMPD, err := LiveMPD(a, mpdName, incCfg, drmCfg, incNowMS)
+		if err != nil {
+			return nil, fmt.Errorf("incarnation %d: %w", inc, err)
+		}
+		mpd = incMPD
+		if rc.Mode == RestartModeCont {
+			break
+		}
+		p := incMPD.Periods[0]
+		p.Id = fmt.Sprintf("P%d", inc)
+		p.Start = Ptr(m.Duration(int64(incCfg.StartTimeS-cfg.StartTimeS) * 1_000_000_000))
+		p.BaseURLs = []*m.BaseURLType{m.NewBaseURL(tokenBaseURL(cfg, fmt.Sprintf("%s%d", restartTokenPrefix, inc)))}
+		periods = append(periods, p)
+	}
+	if mpd == nil {
+		return nil, fmt.Errorf("no encoder incarnation at %dms", nowMS)
+	}
+	if rc.Mode == RestartModePeriod {
+		mpd.AvailabilityStartTime = m.ConvertToDateTime(float64(cfg.StartTimeS))
+		mpd.Periods = periods
+		if cfg.liveMPDType() == segmentNumber {
+			var err error
+			mpd.PublishTime, err = lastPeriodStartTime(mpd)
+			if err != nil {
+				return nil, fmt.Errorf("lastPeriodStartTime: %w", err)
+			}
+		}
+	}
+	if cfg.AddLocationFlag {
+		mpd.Location = []*m.LocationType{{Value: locationURL(cfg)}}
+	}
+	return mpd, nil
+}
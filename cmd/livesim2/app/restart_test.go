@@ -0,0 +1,153 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateRestartConfig(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    *RestartConfig
+		wantErr string
+	}{
+		{val: "600", want: &RestartConfig{CycleS: 600, GapS: 10, Mode: RestartModePeriod}},
+		{val: "120;gap=0;snr=1;mode=cont", want: &RestartConfig{CycleS: 120, GapS: 0, ResetNr: true, Mode: RestartModeCont}},
+		{val: "", wantErr: "empty restart config"},
+		{val: "often", wantErr: "restart cycle"},
+		{val: "10", wantErr: "must be longer than the gap"},
+		{val: "60;gap=-1", wantErr: "must be >= 0"},
+		{val: "60;snr=2", wantErr: "must be 0 or 1"},
+		{val: "60;mode=ast", wantErr: "must be period or cont"},
+		{val: "60;foo=1", wantErr: "unknown restart param"},
+		{val: "60; gap=5", wantErr: "extra spaces"},
+	}
+	for _, c := range cases {
+		got, err := CreateRestartConfig(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestRestartIncarnations(t *testing.T) {
+	rc := RestartConfig{CycleS: 600, GapS: 10}
+	assert.Equal(t, 0, rc.lastIncarnation(0, 599_000))
+	assert.Equal(t, 0, rc.lastIncarnation(0, 605_000)) // in the gap
+	assert.Equal(t, 1, rc.lastIncarnation(0, 610_000))
+	assert.Equal(t, 610, rc.startS(0, 1))
+	assert.Equal(t, 1200, rc.endS(0, 1))
+
+	cfg := NewResponseConfig()
+	cfg.Restart = &rc
+	incCfg, incNowMS, ok := restartIncarnationCfg(cfg, 2000, 1, 1_230_000)
+	require.True(t, ok)
+	assert.Equal(t, 610, incCfg.StartTimeS)
+	assert.Equal(t, 1_200_000, incNowMS)
+	assert.Equal(t, uint32(305), *incCfg.StartNr)
+	assert.Equal(t, 30, *incCfg.TimeShiftBufferDepthS)
+	assert.Nil(t, incCfg.Restart)
+	_, _, ok = restartIncarnationCfg(cfg, 2000, 2, 1_205_000)
+	assert.False(t, ok, "not started")
+	_, _, ok = restartIncarnationCfg(cfg, 2000, 0, 1_230_000)
+	assert.False(t, ok, "outside time-shift window")
+	rc.ResetNr = true
+	incCfg, _, _ = restartIncarnationCfg(cfg, 2000, 1, 1_230_000)
+	assert.Equal(t, uint32(0), *incCfg.StartNr)
+}
+
+func TestRestartStream(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	getMPD := func(t *testing.T, url string) *m.MPD {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		return mpd
+	}
+
+	t.Run("period mode", func(t *testing.T) {
+		mpd := getMPD(t, "/livesim2/restart_600/testpic_2s/Manifest.mpd?nowMS=1230000")
+		assert.Equal(t, m.DateTime("1970-01-01T00:00:00Z"), mpd.AvailabilityStartTime)
+		require.Len(t, mpd.Periods, 2)
+		wantStarts := []m.Duration{m.Duration(610 * 1_000_000_000), m.Duration(1210 * 1_000_000_000)}
+		wantNrs := []uint32{305, 605}
+		for i, p := range mpd.Periods {
+			assert.Equal(t, wantStarts[i], *p.Start)
+			require.Len(t, p.BaseURLs, 1)
+			assert.True(t, strings.HasSuffix(string(p.BaseURLs[0].Value), "/livesim2/rst_"+p.Id[1:]+"/restart_600/testpic_2s/"))
+			assert.Equal(t, wantNrs[i], *p.AdaptationSets[1].SegmentTemplate.StartNumber)
+		}
+		assert.Equal(t, []string{"P1", "P2"}, []string{mpd.Periods[0].Id, mpd.Periods[1].Id})
+	})
+
+	t.Run("timestamps reset", func(t *testing.T) {
+		mpd := getMPD(t, "/livesim2/segtimeline_1/restart_600;snr=1/testpic_2s/Manifest.mpd?nowMS=1230000")
+		require.Len(t, mpd.Periods, 2)
+		stl := mpd.Periods[1].AdaptationSets[1].SegmentTemplate.SegmentTimeline
+		require.NotNil(t, stl)
+		assert.Equal(t, uint64(0), *stl.S[0].T)
+		assert.Equal(t, 9, stl.S[0].R) // 10 segments since the restart at 1210s
+	})
+
+	t.Run("cont mode", func(t *testing.T) {
+		mpd := getMPD(t, "/livesim2/restart_600;mode=cont/testpic_2s/Manifest.mpd?nowMS=1230000")
+		assert.Equal(t, m.DateTime("1970-01-01T00:20:10Z"), mpd.AvailabilityStartTime)
+		require.Len(t, mpd.Periods, 1)
+		assert.Empty(t, mpd.Periods[0].BaseURLs)
+		// In the gap, the crashed incarnation is still described.
+		mpd = getMPD(t, "/livesim2/restart_600;mode=cont/testpic_2s/Manifest.mpd?nowMS=1205000")
+		assert.Equal(t, m.DateTime("1970-01-01T00:10:10Z"), mpd.AvailabilityStartTime)
+	})
+
+	t.Run("segments", func(t *testing.T) {
+		cases := []struct {
+			url      string
+			wantCode int
+		}{
+			{"/livesim2/rst_1/restart_600/testpic_2s/V300/599.m4s?nowMS=1230000", http.StatusOK},
+			{"/livesim2/rst_1/restart_600/testpic_2s/V300/600.m4s?nowMS=1230000", http.StatusTooEarly},
+			{"/livesim2/rst_2/restart_600/testpic_2s/V300/605.m4s?nowMS=1230000", http.StatusOK},
+			{"/livesim2/rst_2/restart_600/testpic_2s/V300/615.m4s?nowMS=1230000", http.StatusTooEarly},
+			{"/livesim2/rst_2/restart_600/testpic_2s/V300/605.m4s?nowMS=1205000", http.StatusNotFound},
+			{"/livesim2/restart_600/testpic_2s/V300/605.m4s?nowMS=1230000", http.StatusOK},
+		}
+		for _, c := range cases {
+			resp, _ := testFullRequest(t, ts, "GET", c.url, nil)
+			assert.Equal(t, c.wantCode, resp.StatusCode, c.url)
+		}
+	})
+
+	t.Run("bad combination", func(t *testing.T) {
+		resp, _ := testFullRequest(t, ts, "GET", "/livesim2/periods_60/restart_600/testpic_2s/Manifest.mpd", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
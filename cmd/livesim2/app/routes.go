@@ -72,6 +72,14 @@ func (s *Server) Routes(ctx context.Context) error {
 	// steering-manifest endpoint referenced by the MPD's <ContentSteering> element.
 	s.Router.MethodFunc("GET", "/steering/session_status", s.steeringSessionStatusHandlerFunc)
 	s.Router.MethodFunc("GET", "/steering/*", s.steeringManifestHandlerFunc)
+	// CMCD v2 collector for event- and response-mode reports.
+	s.Router.MethodFunc("POST", "/cmcd/report", s.cmcdReportHandlerFunc)
+	s.Router.MethodFunc("GET", "/cmcd/report", s.cmcdReportHandlerFunc)
+	// Built-in time service referenced by UTCTiming when the timesrv_ option is used.
+	for _, p := range []string{timeSrvISOPath, timeSrvXSDatePath} {
+		s.Router.MethodFunc("GET", p, s.timeHandlerFunc)
+		s.Router.MethodFunc("HEAD", p, s.timeHandlerFunc)
+	}
 	s.Router.MethodFunc("GET", "/", s.indexHandlerFunc)
 	s.Router.MethodFunc("POST", "/*", s.laURLHandlerFunc)
 	// LiveRouter is mounted at /livesim2
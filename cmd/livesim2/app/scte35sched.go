@@ -0,0 +1,88 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/scte35"
+)
+
+// SCTE-35 carriage modes (scte35mode_). The default is in-band emsg only.
+const (
+	scte35ModeInband = "inband"
+	scte35ModeMPD    = "mpd"
+	scte35ModeBoth   = "both"
+)
+
+// SCTE35XMLBinScheme is the SCTE 214-1 scheme of MPD events with binary SCTE-35 signals.
+const SCTE35XMLBinScheme = "urn:scte:scte35:2014:xml+bin"
+
+// scte35EventTimescale is the EventStream timescale, that of the SCTE-35 PTS.
+const scte35EventTimescale = 90000
+
+// ParseSCTE35Schedule parses a scte35 schedule (see scte35.ParseSchedule), accumulating any
+// error on the converter.
+func (s *strConvAccErr) ParseSCTE35Schedule(key, val string) *scte35.Schedule {
+	if s.err != nil {
+		return nil
+	}
+	sched, err := scte35.ParseSchedule(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return sched
+}
+
+// scheduleEmsgs returns the emsg boxes of the SCTE-35 messages sent during the segment
+// [startTime, endTime) in timescale. A message is sent in the segment covering its send time.
+func scheduleEmsgs(sched *scte35.Schedule, startTime, endTime, timescale uint64) []*mp4.EmsgBox {
+	fromMS, toMS := int(startTime*1000/timescale), int(endTime*1000/timescale)
+	msgs := sched.Messages(fromMS, toMS)
+	emsgs := make([]*mp4.EmsgBox, 0, len(msgs))
+	for _, msg := range msgs {
+		emsgs = append(emsgs, msg.Emsg(timescale))
+	}
+	return emsgs
+}
+
+// scte35InBand returns true if SCTE-35 messages are carried in-band as emsg boxes.
+func (rc *ResponseConfig) scte35InBand() bool {
+	return (rc.SCTE35PerMinute != nil || rc.SCTE35Schedule != nil) && rc.SCTE35Mode != scte35ModeMPD
+}
+
+// addSCTE35EventStreams adds the SCTE-35 messages of the schedule, that are sent at nowMS and
+// belong to events not ended before the time-shift window, as MPD Events. Each Event is put in
+// the Period containing its splice time, with the Period start as presentationTimeOffset, so
+// that presentationTime is the splice time relative to availabilityStartTime.
+func addSCTE35EventStreams(mpd *m.MPD, cfg *ResponseConfig, nowMS int) {
+	if cfg.SCTE35Schedule == nil || (cfg.SCTE35Mode != scte35ModeMPD && cfg.SCTE35Mode != scte35ModeBoth) {
+		return
+	}
+	fromMS, toMS := cfg.timeShiftWindowMS(nowMS)
+	pes := newPeriodEventStreams(mpd, func(periodStartMS int) *m.EventStreamType {
+		return &m.EventStreamType{
+			SchemeIdUri:            SCTE35XMLBinScheme,
+			Timescale:              Ptr(uint32(scte35EventTimescale)),
+			PresentationTimeOffset: uint64(periodStartMS) * scte35EventTimescale / 1000,
+		}
+	})
+	for _, msg := range cfg.SCTE35Schedule.ActiveMessages(fromMS, toMS+1) {
+		emsg := msg.Emsg(scte35EventTimescale)
+		signal := m.NewSignal()
+		signal.Binary = m.NewBinary(base64.StdEncoding.EncodeToString(msg.Payload))
+		pes.add(msg.SpliceMS, &m.EventType{
+			PresentationTime: emsg.PresentationTime,
+			Duration:         uint64(emsg.EventDuration),
+			Id:               Ptr(uint64(emsg.ID)),
+			Signal:           signal,
+		})
+	}
+}
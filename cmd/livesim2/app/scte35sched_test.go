@@ -0,0 +1,192 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+	"github.com/Dash-Industry-Forum/livesim2/pkg/scte35"
+)
+
+func TestSCTE35ScheduleStream(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	resp, body := testFullRequest(t, ts, "GET", "/livesim2/scte35_10:20,40:10:ts:lead4;cycle=60/testpic_2s/Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	mpd, err := m.ReadFromString(string(body))
+	require.NoError(t, err)
+	vAS := mpd.Periods[0].AdaptationSets[1]
+	require.Len(t, vAS.InbandEventStreams, 1)
+	assert.Equal(t, scte35.SchemeIDURI, string(vAS.InbandEventStreams[0].SchemeIdUri))
+
+	cases := []struct {
+		segNr      int
+		wantIDs    []uint32
+		wantPTimes []uint64
+	}{
+		{31, []uint32{3 << 2}, []uint64{70 * 90000}},                       // splice_insert, announced 7s ahead
+		{48, []uint32{4 << 2}, []uint64{100 * 90000}},                      // time_signal start, 4s ahead
+		{53, []uint32{4<<2 | uint32(scte35.MsgIn)}, []uint64{110 * 90000}}, // time_signal end
+		{32, nil, nil},
+	}
+	for _, c := range cases {
+		url := "/livesim2/scte35_10:20,40:10:ts:lead4;cycle=60/testpic_2s/V300/" + strconv.Itoa(c.segNr) + ".m4s?nowMS=120000"
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		var ids []uint32
+		var pTimes []uint64
+		for _, e := range f.Segments[0].Fragments[0].Emsgs {
+			ids = append(ids, e.ID)
+			pTimes = append(pTimes, e.PresentationTime)
+		}
+		assert.Equal(t, c.wantIDs, ids, url)
+		assert.Equal(t, c.wantPTimes, pTimes, url)
+	}
+
+	// SSAI breaks from the schedule.
+	resp, body = testFullRequest(t, ts, "GET", "/livesim2/scte35_30:20;cycle=120/ssai_scte35/testpic_2s/Manifest.mpd?nowMS=80000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	mpd, err = m.ReadFromString(string(body))
+	require.NoError(t, err)
+	var ids []string
+	for _, p := range mpd.Periods {
+		ids = append(ids, p.Id)
+	}
+	assert.Equal(t, []string{"P0", "AD30", "AD40", "P50"}, ids)
+
+	for _, u := range []string{
+		"/livesim2/scte35_10:20:ret30/testpic_2s/Manifest.mpd",
+		"/livesim2/ssai_scte35/testpic_2s/Manifest.mpd",
+	} {
+		resp, _ := testFullRequest(t, ts, "GET", u, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, u)
+	}
+}
+
+func TestSCTE35MPDEvents(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	getMPD := func(t *testing.T, url string) *m.MPD {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		return mpd
+	}
+	eventIDs := func(p *m.Period) []uint64 {
+		var ids []uint64
+		for _, es := range p.EventStreams {
+			for _, e := range es.Events {
+				ids = append(ids, *e.Id)
+			}
+		}
+		return ids
+	}
+
+	cases := []struct {
+		desc        string
+		nowMS       int
+		wantIDs     []uint64
+		wantPTimes  []uint64
+		wantInband  bool
+		modeSegment string
+	}{
+		{"break at 70s announced, break at 10s out of window", 100_000, []uint64{2 << 2}, []uint64{70 * 90000}, false, "mpd"},
+		{"break at 70s expired, break at 130s announced", 160_000, []uint64{3 << 2}, []uint64{130 * 90000}, true, "both"},
+	}
+	for _, c := range cases {
+		url := "/livesim2/scte35_10:20;cycle=60/scte35mode_" + c.modeSegment + "/testpic_2s/Manifest.mpd?nowMS=" +
+			strconv.Itoa(c.nowMS)
+		mpd := getMPD(t, url)
+		p := mpd.Periods[0]
+		require.Len(t, p.EventStreams, 1, c.desc)
+		es := p.EventStreams[0]
+		assert.Equal(t, SCTE35XMLBinScheme, string(es.SchemeIdUri))
+		assert.Equal(t, c.wantIDs, eventIDs(p), c.desc)
+		var pTimes []uint64
+		for _, e := range es.Events {
+			pTimes = append(pTimes, e.PresentationTime)
+			require.NotNil(t, e.Signal)
+			require.NotNil(t, e.Signal.Binary)
+			si, err := scte35.DecodeBase64(e.Signal.Binary.Value)
+			require.NoError(t, err, c.desc)
+			pts, ok := si.PTS()
+			require.True(t, ok)
+			assert.Equal(t, e.PresentationTime, pts, c.desc)
+		}
+		assert.Equal(t, c.wantPTimes, pTimes, c.desc)
+		assert.Equal(t, c.wantInband, len(p.AdaptationSets[1].InbandEventStreams) == 1, c.desc)
+	}
+
+	// In mpd mode, no emsg is sent in-band.
+	resp, body := testFullRequest(t, ts, "GET",
+		"/livesim2/scte35_10:20;cycle=60/scte35mode_mpd/testpic_2s/V300/31.m4s?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	f, err := mp4.DecodeFile(bytes.NewReader(body))
+	require.NoError(t, err)
+	assert.Empty(t, f.Segments[0].Fragments[0].Emsgs)
+
+	// In-band only, the per-minute layouts use the legacy emsg generator.
+	_, legacy := testFullRequest(t, ts, "GET", "/livesim2/scte35_1/testpic_2s/V300/31.m4s?nowMS=100000", nil)
+	resp, body = testFullRequest(t, ts, "GET", "/livesim2/scte35_1/scte35mode_inband/testpic_2s/V300/31.m4s?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	f, err = mp4.DecodeFile(bytes.NewReader(body))
+	require.NoError(t, err)
+	require.Len(t, f.Segments[0].Fragments[0].Emsgs, 1)
+	assert.Equal(t, legacy, body)
+
+	// The per-minute layouts are also available as MPD events.
+	mpd := getMPD(t, "/livesim2/scte35_2/scte35mode_mpd/testpic_2s/Manifest.mpd?nowMS=100000")
+	assert.Equal(t, []uint64{2 << 2, 3 << 2, 4 << 2}, eventIDs(mpd.Periods[0]), "breaks at 40, 70 and 100s")
+
+	// SSAI puts each event in the Period containing its splice time.
+	mpd = getMPD(t, "/livesim2/scte35_30:20;cycle=120/scte35mode_both/ssai_scte35/testpic_2s/Manifest.mpd?nowMS=80000")
+	require.Len(t, mpd.Periods, 4)
+	assert.Equal(t, []uint64{1 << 2}, eventIDs(mpd.Periods[1]))
+	assert.Equal(t, uint64(30*90000), mpd.Periods[1].EventStreams[0].PresentationTimeOffset)
+	assert.Empty(t, eventIDs(mpd.Periods[0]))
+
+	for _, u := range []string{
+		"/livesim2/scte35mode_mpd/testpic_2s/Manifest.mpd",
+		"/livesim2/scte35_1/scte35mode_xml/testpic_2s/Manifest.mpd",
+		"/livesim2/scte35_1/scte35mode_both/channels/demo/Manifest.mpd",
+	} {
+		resp, _ := testFullRequest(t, ts, "GET", u, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, u)
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math"
+	"math/rand/v2"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Eyevinn/mp4ff/bits"
+	"github.com/Eyevinn/mp4ff/mp4"
+)
+
+// Variable segment durations. With segdurs_, the video samples of every asset loop are
+// regrouped into segments of varying duration. A segment always starts at a sync sample,
+// so the target durations are rounded up to the next sync sample (GOP start). The video
+// representations of the resulting variant asset get the new segment table, and audio
+// follows the new reference segments via the usual audio segmentation.
+
+const (
+	segDursRandom       = "rand"
+	segDursDefaultMinMS = 1000
+	segDursDefaultMaxMS = 4000
+	segDursDefaultSeed  = 1
+	segDursMaxMS        = 3600_000
+	maxSegDursVariants  = 32 // variant assets cached per asset
+)
+
+// SegDursConfig is the segment duration schedule. Either DursMS is a list of target
+// durations that is cycled through, or the durations are drawn uniformly from
+// [MinMS, MaxMS] with a seeded random generator.
+type SegDursConfig struct {
+	DursMS []int  `json:"dursMS,omitempty"`
+	Random bool   `json:"random,omitempty"`
+	MinMS  int    `json:"minMS,omitempty"`
+	MaxMS  int    `json:"maxMS,omitempty"`
+	Seed   uint64 `json:"seed,omitempty"`
+}
+
+// CreateSegDursConfig parses <d1>,<d2>,... (seconds) or rand[;min=<s>;max=<s>;seed=<n>].
+func CreateSegDursConfig(val string) (*SegDursConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty segdurs config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("segdurs config %q has extra spaces", val)
+	}
+	parts := strings.Split(val, ";")
+	cfg := &SegDursConfig{}
+	if parts[0] != segDursRandom {
+		if len(parts) > 1 {
+			return nil, fmt.Errorf("segdurs list %q takes no params", val)
+		}
+		for _, d := range strings.Split(parts[0], ",") {
+			ms, err := parseSegDurMS(d)
+			if err != nil {
+				return nil, err
+			}
+			cfg.DursMS = append(cfg.DursMS, ms)
+		}
+		return cfg, nil
+	}
+	cfg.Random = true
+	cfg.MinMS = segDursDefaultMinMS
+	cfg.MaxMS = segDursDefaultMaxMS
+	cfg.Seed = segDursDefaultSeed
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("segdurs param %q must be key=val", kv)
+		}
+		var err error
+		switch key {
+		case "min":
+			cfg.MinMS, err = parseSegDurMS(v)
+		case "max":
+			cfg.MaxMS, err = parseSegDurMS(v)
+		case "seed":
+			cfg.Seed, err = strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				err = fmt.Errorf("segdurs seed %q: %w", v, err)
+			}
+		default:
+			err = fmt.Errorf("unknown segdurs param %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.MaxMS < cfg.MinMS {
+		return nil, fmt.Errorf("segdurs max %dms is less than min %dms", cfg.MaxMS, cfg.MinMS)
+	}
+	return cfg, nil
+}
+
+// parseSegDurMS parses a duration in seconds to milliseconds.
+func parseSegDurMS(val string) (int, error) {
+	d, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, fmt.Errorf("segdurs duration %q: %w", val, err)
+	}
+	ms := int(math.Round(d * 1000))
+	if ms <= 0 || ms > segDursMaxMS {
+		return 0, fmt.Errorf("segdurs duration %q must be in (0, %d]s", val, segDursMaxMS/1000)
+	}
+	return ms, nil
+}
+
+// ParseSegDursConfig parses a segdurs option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseSegDursConfig(key, val string) *SegDursConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreateSegDursConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// key identifies the schedule in the variant cache.
+func (sd *SegDursConfig) key() string {
+	if sd.Random {
+		return fmt.Sprintf("rand;%d;%d;%d", sd.MinMS, sd.MaxMS, sd.Seed)
+	}
+	return fmt.Sprint(sd.DursMS)
+}
+
+// boundaries returns the segment boundaries of one loop [start, end) given the sync-sample
+// times. Every target duration is rounded up to the next sync sample and the last segment
+// is cut at the loop end.
+func (sd *SegDursConfig) boundaries(syncTimes []uint64, start, end uint64, timescale int) []uint64 {
+	rng := rand.New(rand.NewPCG(sd.Seed, sd.Seed))
+	bounds := []uint64{start}
+	t := start
+	for i := 0; t < end; i++ {
+		var durMS int
+		if sd.Random {
+			durMS = sd.MinMS + rng.IntN(sd.MaxMS-sd.MinMS+1)
+		} else {
+			durMS = sd.DursMS[i%len(sd.DursMS)]
+		}
+		target := t + uint64(durMS)*uint64(timescale)/1000
+		idx := sort.Search(len(syncTimes), func(j int) bool { return syncTimes[j] >= target && syncTimes[j] > t })
+		if idx == len(syncTimes) {
+			t = end
+		} else {
+			t = syncTimes[idx]
+		}
+		bounds = append(bounds, t)
+	}
+	return bounds
+}
+
+// withSegDurs returns the variant of the asset with video segments regrouped following sd.
+// Variants are cached on the asset.
+func (a *asset) withSegDurs(vodFS fs.FS, sd *SegDursConfig) (*asset, error) {
+	a.variantMu.Lock()
+	defer a.variantMu.Unlock()
+	key := sd.key()
+	if v, ok := a.segDurVariants[key]; ok {
+		return v, nil
+	}
+	refRep := a.refRep
+	if refRep.ContentType != "video" {
+		return nil, fmt.Errorf("segdurs needs a video reference representation")
+	}
+	if err := a.loadSyncTimesLocked(vodFS); err != nil {
+		return nil, err
+	}
+	start := refRep.Segments[0].StartTime
+	end := refRep.Segments[len(refRep.Segments)-1].EndTime
+	bounds := sd.boundaries(a.syncTimes, start, end, refRep.MediaTimescale)
+
+	v := &asset{
+		AssetPath: a.AssetPath,
+		MPDs:      a.MPDs,
+		LoopDurMS: a.LoopDurMS,
+		Reps:      make(map[string]*RepData, len(a.Reps)),
+		syncTimes: a.syncTimes,
+		gen:       a.gen,
+	}
+	for id, rep := range a.Reps {
+		if rep.ContentType != "video" {
+			v.Reps[id] = rep
+			continue
+		}
+		if rep.PreEncrypted {
+			return nil, fmt.Errorf("segdurs does not support pre-encrypted rep %s", id)
+		}
+		if !sameSegmentTimes(rep, refRep) {
+			return nil, fmt.Errorf("segdurs: video rep %s is not aligned with %s", id, refRep.ID)
+		}
+		vRep := *rep
+		vRep.srcSegments = rep.Segments
+		vRep.Segments = make([]Segment, 0, len(bounds)-1)
+		for i := 1; i < len(bounds); i++ {
+			vRep.Segments = append(vRep.Segments,
+				Segment{StartTime: bounds[i-1], EndTime: bounds[i], Nr: rep.Segments[0].Nr + uint32(i-1)})
+		}
+		v.Reps[id] = &vRep
+	}
+	v.refRep = v.Reps[refRep.ID]
+	v.SegmentDurMS = a.LoopDurMS / len(v.refRep.Segments)
+	v.maxSegDurMS = variantMaxSegDurMS(v)
+	if a.segDurVariants == nil || len(a.segDurVariants) >= maxSegDursVariants {
+		a.segDurVariants = make(map[string]*asset)
+	}
+	a.segDurVariants[key] = v
+	return v, nil
+}
+
+// variantMaxSegDurMS returns the longest segment duration in ms of a variant asset. Audio
+// segments follow the video segments, but may be up to one audio frame longer.
+func variantMaxSegDurMS(v *asset) int {
+	ref := v.refRep
+	var maxDur uint64
+	for _, s := range ref.Segments {
+		maxDur = max(maxDur, s.dur())
+	}
+	maxMS := ceilDiv(int(maxDur)*1000, ref.MediaTimescale)
+	audioMS := 0
+	for _, rep := range v.Reps {
+		if rep.ContentType == "audio" && rep.MediaTimescale > 0 {
+			audioMS = max(audioMS, ceilDiv(int(rep.sampleDur())*1000, rep.MediaTimescale))
+		}
+	}
+	return maxMS + audioMS
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// sameSegmentTimes returns true if the two representations have the same segment times.
+func sameSegmentTimes(r1, r2 *RepData) bool {
+	if r1.MediaTimescale != r2.MediaTimescale || len(r1.Segments) != len(r2.Segments) {
+		return false
+	}
+	for i := range r1.Segments {
+		if r1.Segments[i].StartTime != r2.Segments[i].StartTime || r1.Segments[i].EndTime != r2.Segments[i].EndTime {
+			return false
+		}
+	}
+	return true
+}
+
+// loadSyncTimes reads the decode times of the sync samples of the reference representation
+// once, and keeps them in the asset.
+func (a *asset) loadSyncTimes(vodFS fs.FS) error {
+	a.variantMu.Lock()
+	defer a.variantMu.Unlock()
+	return a.loadSyncTimesLocked(vodFS)
+}
+
+// loadSyncTimesLocked is loadSyncTimes with a.variantMu held.
+func (a *asset) loadSyncTimesLocked(vodFS fs.FS) error {
+	if a.syncTimes != nil {
+		return nil
+	}
+	syncTimes, err := readSyncTimes(vodFS, a.AssetPath, a.refRep)
+	if err != nil {
+		return fmt.Errorf("sync samples of rep %s: %w", a.refRep.ID, err)
+	}
+	a.syncTimes = syncTimes
+	return nil
+}
+
+// readSyncTimes returns the decode times of all sync samples of a representation.
+func readSyncTimes(vodFS fs.FS, assetPath string, rep *RepData) ([]uint64, error) {
+	var syncTimes []uint64
+	for _, s := range rep.Segments {
+		fss, _, err := readSegmentSamples(vodFS, assetPath, rep, s)
+		if err != nil {
+			return nil, err
+		}
+		for _, smp := range fss {
+			if smp.IsSync() {
+				syncTimes = append(syncTimes, smp.DecodeTime)
+			}
+		}
+	}
+	return syncTimes, nil
+}
+
+// readSegmentSamples reads a VoD segment and returns its samples and the decoded segment.
+func readSegmentSamples(vodFS fs.FS, assetPath string, rep *RepData, s Segment) ([]mp4.FullSample, *mp4.MediaSegment, error) {
+	segPath := path.Join(assetPath, replaceTimeAndNr(rep.MediaURI, s.StartTime, s.Nr))
+	data, err := fs.ReadFile(vodFS, segPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read segment: %w", err)
+	}
+	fSeg, err := mp4.DecodeFileSR(bits.NewFixedSliceReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode segment: %w", err)
+	}
+	if len(fSeg.Segments) != 1 {
+		return nil, nil, fmt.Errorf("file has %d segments, expected 1", len(fSeg.Segments))
+	}
+	seg := fSeg.Segments[0]
+	trex := getTrex(rep.initSeg)
+	var fss []mp4.FullSample
+	for _, frag := range seg.Fragments {
+		ffs, err := frag.GetFullSamples(trex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("getFullSamples: %w", err)
+		}
+		fss = append(fss, ffs...)
+	}
+	return fss, seg, nil
+}
+
+// createRegroupedSeg assembles a regrouped segment from the samples in [startTime, startTime+dur)
+// of the source segments. The result keeps the source timestamps, just like a VoD segment file.
+func createRegroupedSeg(vodFS fs.FS, a *asset, rep *RepData, startTime uint64, dur uint32, nr uint32) ([]byte, error) {
+	endTime := startTime + uint64(dur)
+	src := rep.srcSegments
+	idx := sort.Search(len(src), func(i int) bool { return src[i].EndTime > startTime })
+	var outSamples []mp4.FullSample
+	var outSeg *mp4.MediaSegment
+	for ; idx < len(src) && src[idx].StartTime < endTime; idx++ {
+		fss, seg, err := readSegmentSamples(vodFS, a.AssetPath, rep, src[idx])
+		if err != nil {
+			return nil, err
+		}
+		if outSeg == nil {
+			outSeg = seg
+		}
+		for _, smp := range fss {
+			if smp.DecodeTime >= startTime && smp.DecodeTime < endTime {
+				outSamples = append(outSamples, smp)
+			}
+		}
+	}
+	if len(outSamples) == 0 {
+		return nil, fmt.Errorf("no samples in [%d, %d)", startTime, endTime)
+	}
+	if !outSamples[0].IsSync() {
+		return nil, fmt.Errorf("rep %s has no sync sample at %d", rep.ID, startTime)
+	}
+	outSeg.Sidx = nil
+	outSeg.Sidxs = nil
+	resetSegmentToNewSamples(outSeg, outSamples, nr, startTime)
+	var buf bytes.Buffer
+	if err := outSeg.Encode(&buf); err != nil {
+		return nil, fmt.Errorf("encode segment: %w", err)
+	}
+	return buf.Bytes(), nil
+}
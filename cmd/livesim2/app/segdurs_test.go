@@ -0,0 +1,132 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateSegDursConfig(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    *SegDursConfig
+		wantErr string
+	}{
+		{val: "1,3,2", want: &SegDursConfig{DursMS: []int{1000, 3000, 2000}}},
+		{val: "0.5", want: &SegDursConfig{DursMS: []int{500}}},
+		{val: "rand", want: &SegDursConfig{Random: true, MinMS: 1000, MaxMS: 4000, Seed: 1}},
+		{val: "rand;min=2;max=6;seed=42", want: &SegDursConfig{Random: true, MinMS: 2000, MaxMS: 6000, Seed: 42}},
+		{val: "", wantErr: "empty segdurs config"},
+		{val: "1,x", wantErr: "segdurs duration"},
+		{val: "1,0", wantErr: "must be in"},
+		{val: "1,2;seed=1", wantErr: "takes no params"},
+		{val: "rand;min=3;max=2", wantErr: "less than min"},
+		{val: "rand;seed=-1", wantErr: "segdurs seed"},
+		{val: "rand;foo=1", wantErr: "unknown segdurs param"},
+		{val: "rand;min", wantErr: "must be key=val"},
+		{val: "rand; min=1", wantErr: "extra spaces"},
+	}
+	for _, c := range cases {
+		got, err := CreateSegDursConfig(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestSegDursBoundaries(t *testing.T) {
+	var syncTimes []uint64 // one GOP per second
+	for i := uint64(0); i < 8; i++ {
+		syncTimes = append(syncTimes, i*90000)
+	}
+	sd := SegDursConfig{DursMS: []int{1000, 3000, 2000}}
+	assert.Equal(t, []uint64{0, 90000, 360000, 540000, 630000, 720000}, sd.boundaries(syncTimes, 0, 720000, 90000))
+	sd = SegDursConfig{DursMS: []int{1500}} // rounded up to the next sync sample
+	assert.Equal(t, []uint64{0, 180000, 360000, 540000, 720000}, sd.boundaries(syncTimes, 0, 720000, 90000))
+
+	sd = SegDursConfig{Random: true, MinMS: 1000, MaxMS: 3000, Seed: 7}
+	bounds := sd.boundaries(syncTimes, 0, 720000, 90000)
+	assert.Equal(t, bounds, sd.boundaries(syncTimes, 0, 720000, 90000), "same seed, same durations")
+	for i := 1; i < len(bounds); i++ {
+		d := bounds[i] - bounds[i-1]
+		assert.True(t, d >= 90000 && d <= 270000, "duration %d", d)
+	}
+	assert.Equal(t, uint64(720000), bounds[len(bounds)-1])
+}
+
+func TestSegDursStream(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	// testpic_2s has an 8s loop with a GOP per second, so 1,3,2 gives 1,3,2,1,1s per loop.
+	resp, body := testFullRequest(t, ts, "GET", "/livesim2/segtimeline_1/segdurs_1,3,2/testpic_2s/Manifest.mpd?nowMS=100000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	mpd, err := m.ReadFromString(string(body))
+	require.NoError(t, err)
+	assert.Equal(t, m.Duration(3_022_000_000), *mpd.MaxSegmentDuration)
+	vAS := mpd.Periods[0].AdaptationSets[1]
+	require.Equal(t, "video", string(vAS.ContentType))
+	var durs []uint64
+	for _, s := range vAS.SegmentTemplate.SegmentTimeline.S {
+		for range s.R + 1 {
+			durs = append(durs, s.D/90000)
+		}
+	}
+	assert.Equal(t, []uint64{1, 1, 3, 2, 1, 1, 1, 3, 2}, durs[:9])
+
+	aAS := mpd.Periods[0].AdaptationSets[0]
+	assert.Greater(t, len(aAS.SegmentTemplate.SegmentTimeline.S), 1, "audio follows the irregular video segments")
+
+	cases := []struct {
+		url         string
+		wantTfdt    uint64
+		wantSamples int
+	}{
+		{"/livesim2/segtimeline_1/segdurs_1,3,2/testpic_2s/V300/8640000.m4s?nowMS=100000", 8640000, 30},
+		{"/livesim2/segtimeline_1/segdurs_1,3,2/testpic_2s/V300/8730000.m4s?nowMS=100000", 8730000, 90},
+	}
+	for _, c := range cases {
+		resp, body := testFullRequest(t, ts, "GET", c.url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, c.url)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Len(t, f.Segments, 1)
+		frag := f.Segments[0].Fragments[0]
+		assert.Equal(t, c.wantTfdt, frag.Moof.Traf.Tfdt.BaseMediaDecodeTime(), c.url)
+		fss, err := frag.GetFullSamples(nil)
+		require.NoError(t, err)
+		assert.Len(t, fss, c.wantSamples, c.url)
+		assert.True(t, fss[0].IsSync(), c.url)
+	}
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/segtimeline_1/segdurs_1,3,2/testpic_2s/A48/4608000.m4s?nowMS=100000", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/segdurs_1,3,2/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "needs SegmentTimeline")
+}
@@ -30,6 +30,8 @@ type Server struct {
 	sgaiAds          *adCatalog
 	sgaiAdsMu        sync.Mutex
 	steeringSessions *SteeringSessionMgr
+	cmcdSessions     *CmcdSessionMgr
+	timeService      *TimeService
 	textTemplates    *ttmpl.Template
 	reqLimiter       *IPRequestLimiter
 }
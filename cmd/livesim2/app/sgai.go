@@ -86,43 +86,10 @@ func CreateSGAIConfig(val string) (*SGAIConfig, error) {
 		ExecuteOnce:    true,
 	}
 	parts := strings.Split(val, ";")
-	if spec, ok := strings.CutPrefix(parts[0], "p"); ok {
-		// Periodic: p<period>:<dur> — a break of <dur> at every wall-clock multiple of <period>.
-		if strings.Contains(spec, ",") {
-			return nil, fmt.Errorf("sgai periodic %q cannot be combined with more breaks", parts[0])
-		}
-		per, dur, ok := strings.Cut(spec, ":")
-		if !ok {
-			return nil, fmt.Errorf("sgai periodic %q must be p<period>:<dur>", parts[0])
-		}
-		perS, err := strconv.Atoi(per)
-		if err != nil || perS <= 0 {
-			return nil, fmt.Errorf("sgai periodic %q: bad period", parts[0])
-		}
-		durS, err := strconv.Atoi(dur)
-		if err != nil || durS <= 0 {
-			return nil, fmt.Errorf("sgai periodic %q: bad duration", parts[0])
-		}
-		if durS >= perS {
-			return nil, fmt.Errorf("sgai periodic %q: duration must be less than the period", parts[0])
-		}
-		cfg.Periodic = &SGAIPeriodic{PeriodS: perS, DurationS: durS}
-	} else {
-		for bs := range strings.SplitSeq(parts[0], ",") {
-			off, dur, ok := strings.Cut(bs, ":")
-			if !ok {
-				return nil, fmt.Errorf("sgai break %q must be <off>:<dur>", bs)
-			}
-			offS, err := strconv.Atoi(off)
-			if err != nil || offS < 0 {
-				return nil, fmt.Errorf("sgai break %q: bad offset", bs)
-			}
-			durS, err := strconv.Atoi(dur)
-			if err != nil || durS <= 0 {
-				return nil, fmt.Errorf("sgai break %q: bad duration", bs)
-			}
-			cfg.Breaks = append(cfg.Breaks, SGAIBreak{OffsetS: offS, DurationS: durS})
-		}
+	var err error
+	cfg.Breaks, cfg.Periodic, err = parseAdBreaks("sgai", parts[0])
+	if err != nil {
+		return nil, err
 	}
 	for _, kv := range parts[1:] {
 		key, v, ok := strings.Cut(kv, "=")
@@ -174,6 +141,50 @@ func CreateSGAIConfig(val string) (*SGAIConfig, error) {
 	return cfg, nil
 }
 
+// parseAdBreaks parses the break part of an ad option: <off>:<dur>[,<off>:<dur>...] or
+// p<period>:<dur>. name prefixes the error messages.
+func parseAdBreaks(name, val string) ([]SGAIBreak, *SGAIPeriodic, error) {
+	if spec, ok := strings.CutPrefix(val, "p"); ok {
+		// Periodic: p<period>:<dur> — a break of <dur> at every wall-clock multiple of <period>.
+		if strings.Contains(spec, ",") {
+			return nil, nil, fmt.Errorf("%s periodic %q cannot be combined with more breaks", name, val)
+		}
+		per, dur, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, nil, fmt.Errorf("%s periodic %q must be p<period>:<dur>", name, val)
+		}
+		perS, err := strconv.Atoi(per)
+		if err != nil || perS <= 0 {
+			return nil, nil, fmt.Errorf("%s periodic %q: bad period", name, val)
+		}
+		durS, err := strconv.Atoi(dur)
+		if err != nil || durS <= 0 {
+			return nil, nil, fmt.Errorf("%s periodic %q: bad duration", name, val)
+		}
+		if durS >= perS {
+			return nil, nil, fmt.Errorf("%s periodic %q: duration must be less than the period", name, val)
+		}
+		return nil, &SGAIPeriodic{PeriodS: perS, DurationS: durS}, nil
+	}
+	var breaks []SGAIBreak
+	for bs := range strings.SplitSeq(val, ",") {
+		off, dur, ok := strings.Cut(bs, ":")
+		if !ok {
+			return nil, nil, fmt.Errorf("%s break %q must be <off>:<dur>", name, bs)
+		}
+		offS, err := strconv.Atoi(off)
+		if err != nil || offS < 0 {
+			return nil, nil, fmt.Errorf("%s break %q: bad offset", name, bs)
+		}
+		durS, err := strconv.Atoi(dur)
+		if err != nil || durS <= 0 {
+			return nil, nil, fmt.Errorf("%s break %q: bad duration", name, bs)
+		}
+		breaks = append(breaks, SGAIBreak{OffsetS: offS, DurationS: durS})
+	}
+	return breaks, nil, nil
+}
+
 // isCleanAbsPath reports whether p is a safe absolute path (no scheme, authority, userinfo
 // or backslashes), so that AdEndpoint cannot change the authority of the @uri when spliced
 // after scheme://host (e.g. "@evil.com" or "//evil.com" would redirect the ad request).
@@ -74,7 +74,18 @@ func buildAdCatalog(vodFS fs.FS, durFn func(mpdPath string) int) *adCatalog {
 // unfilled and the viewer keeps the underlying break content (the AD BREAK slate, i.e. the
 // base ad).
 func (c *adCatalog) selectPod(sid string, interests []string, maxDurMS int) []adEntry {
-	ordered := c.steerOrder(sid, interests)
+	return fitPod(c.steerOrder(sid, interests), maxDurMS)
+}
+
+// fillPod chooses an ad pod without interest steering: all ads rotated by session id and
+// trimmed to fit maxDurMS. It is used where a break must always be filled (SSAI).
+func (c *adCatalog) fillPod(sid string, maxDurMS int) []adEntry {
+	return fitPod(rotateBySid(c.ads, sid), maxDurMS)
+}
+
+// fitPod trims the ordered ads to fit maxDurMS, keeping at least the lead ad.
+// maxDurMS <= 0 means no duration limit.
+func fitPod(ordered []adEntry, maxDurMS int) []adEntry {
 	if maxDurMS <= 0 {
 		return ordered
 	}
@@ -94,9 +94,16 @@ func newSlateGen(initData []byte) (*slateGen, error) {
 
 // buildPlane renders the slate background with the text overlay (e.g. "AD BREAK\n17").
 func (g *slateGen) buildPlane(text string) (*yuv.PlaneGrid, error) {
+	// Half of the largest scale at which the text fits.
+	return buildTextPlane(g.params.Width, g.params.Height, text, 2)
+}
+
+// buildTextPlane renders text on the slate background in a plane of width x height pixels.
+// The text is drawn at 1/scaleDiv of the largest scale at which it fits, but at least 1.
+func buildTextPlane(width, height int, text string, scaleDiv int) (*yuv.PlaneGrid, error) {
 	bs := slateBlockSize
-	bw := (g.params.Width + bs - 1) / bs
-	bh := (g.params.Height + bs - 1) / bs
+	bw := (width + bs - 1) / bs
+	bh := (height + bs - 1) / bs
 	pg := yuv.NewPlaneGrid(bw, bh, bs)
 	for row := range bh {
 		for col := range bw {
@@ -105,12 +112,12 @@ func (g *slateGen) buildPlane(text string) (*yuv.PlaneGrid, error) {
 			pg.Cr[row][col] = slateBgColor.Cr
 		}
 	}
-	// Half of the largest scale at which the text fits (8-px blocks use the 2x font).
+	// 8-px blocks use the 2x font.
 	tw, th := yuv.TextWidth2x(text), yuv.TextHeight2x(text)
 	if tw == 0 || th == 0 {
 		return pg, nil
 	}
-	scale := max(min(bw/tw, bh/th)/2, 1)
+	scale := max(min(bw/tw, bh/th)/scaleDiv, 1)
 	if err := yuv.OverlayTextOnPlane(pg, text, scale, slateTextColor, &slateTextBg); err != nil {
 		return nil, fmt.Errorf("overlay text: %w", err)
 	}
@@ -0,0 +1,341 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/drm"
+	"github.com/Dash-Industry-Forum/livesim2/pkg/scte35"
+	m "github.com/Eyevinn/dash-mpd/mpd"
+)
+
+// Server-side ad insertion (SSAI). With ssai_, the content Period is split at the ad breaks
+// and the ad creatives of the ad catalog are stitched in as Periods of their own. The ads
+// replace the content during the break, as on a broadcast channel, so the content resumes at
+// its live position after the pod, signaled by a presentationTimeOffset.
+//
+// Each ad Period is generated as a live stream of the ad asset with availabilityStartTime at
+// the ad start, so its timestamps are rebased to start at zero. Ad Periods get absolute
+// BaseURLs with an adp_<startS> path token, which makes the segment requests independent of
+// the session. The pod of a break is chosen per session by the catalog's selectPod, with
+// interests from the MPD request. Without interests (or no match), the break is filled
+// with all ads rotated by session id.
+//
+// An imperfect splice is emulated by vgap/agap: the video/audio of the resumed content
+// starts that many milliseconds late, rounded up to the next segment.
+
+const (
+	ssaiTokenPrefix = "adp_"
+	maxSSAIGapMS    = 10_000
+)
+
+// SSAIConfig configures the ad breaks of server-side ad insertion.
+type SSAIConfig struct {
+	Breaks     []SGAIBreak   `json:"breaks,omitempty"`   // fixed breaks (offset from AST)
+	Periodic   *SGAIPeriodic `json:"periodic,omitempty"` // recurring breaks anchored to wall-clock
+	SCTE35     bool          `json:"scte35,omitempty"`   // breaks from the scte35_ schedule
+	VideoGapMS int           `json:"videoGapMS,omitempty"`
+	AudioGapMS int           `json:"audioGapMS,omitempty"`
+}
+
+// CreateSSAIConfig parses ( <off>:<dur>[,<off>:<dur>...] | p<period>:<dur> | scte35 )[;vgap=<ms>;agap=<ms>].
+func CreateSSAIConfig(val string) (*SSAIConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty ssai config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("ssai config %q has extra spaces", val)
+	}
+	parts := strings.Split(val, ";")
+	cfg := &SSAIConfig{}
+	if parts[0] == "scte35" {
+		cfg.SCTE35 = true
+	} else {
+		var err error
+		cfg.Breaks, cfg.Periodic, err = parseAdBreaks("ssai", parts[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("ssai param %q must be key=val", kv)
+		}
+		var gap *int
+		switch key {
+		case "vgap":
+			gap = &cfg.VideoGapMS
+		case "agap":
+			gap = &cfg.AudioGapMS
+		default:
+			return nil, fmt.Errorf("unknown ssai param %q", key)
+		}
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 || ms > maxSSAIGapMS {
+			return nil, fmt.Errorf("ssai %s %q: must be in [0, %d]ms", key, v, maxSSAIGapMS)
+		}
+		*gap = ms
+	}
+	return cfg, nil
+}
+
+// ParseSSAIConfig parses an ssai option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseSSAIConfig(key, val string) *SSAIConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreateSSAIConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// ssaiBreak is one break occurrence.
+type ssaiBreak struct {
+	id     uint64
+	startS int // relative to availabilityStartTime
+	durS   int
+}
+
+// breaksBefore returns the breaks that start before toS (relative to availabilityStartTime
+// astS), in time order. Periodic breaks are returned from one period before windowStartS,
+// so that the break preceding the window start is included. With SCTE35, the breaks are
+// the splice outs of sched, rounded to whole seconds.
+func (c *SSAIConfig) breaksBefore(windowStartS, toS, astS int, sched *scte35.Schedule) []ssaiBreak {
+	var out []ssaiBreak
+	if c.SCTE35 {
+		fromMS := 0
+		if sched.CycleMS > 0 {
+			fromMS = max(0, windowStartS*1000-sched.CycleMS)
+		}
+		for _, b := range sched.Breaks(fromMS, toS*1000) {
+			out = append(out, ssaiBreak{id: uint64(b.EventID), startS: b.StartMS / 1000,
+				durS: (b.StartMS%1000 + b.DurationMS + 999) / 1000})
+		}
+		return out
+	}
+	if c.Periodic == nil {
+		for i, b := range c.Breaks {
+			if b.OffsetS < toS {
+				out = append(out, ssaiBreak{id: uint64(i + 1), startS: b.OffsetS, durS: b.DurationS})
+			}
+		}
+		sort.SliceStable(out, func(i, j int) bool { return out[i].startS < out[j].startS })
+		return out
+	}
+	p := c.Periodic.PeriodS
+	from := max(astS+windowStartS-p, astS)
+	for t := (from + p - 1) / p * p; t < astS+toS; t += p {
+		out = append(out, ssaiBreak{id: uint64(t/p) + 1, startS: t - astS, durS: c.Periodic.DurationS})
+	}
+	return out
+}
+
+// adStartInBreak returns true if an ad Period can start at startS, that is within a
+// configured break. The ads of a pod start before the end of the break, since only
+// the lead ad may be longer than the break.
+func (c *SSAIConfig) adStartInBreak(startS, astS int, sched *scte35.Schedule) bool {
+	for _, b := range c.breaksBefore(startS, startS+1, astS, sched) {
+		if b.startS <= startS && (startS == b.startS || startS < b.startS+b.durS) {
+			return true
+		}
+	}
+	return false
+}
+
+// ssaiAd is an ad of a pod, resolved to its asset.
+type ssaiAd struct {
+	a       *asset
+	mpdName string
+	durS    int // the ad Period duration (media duration rounded up to whole seconds)
+}
+
+// ssaiPod returns the ads of break b for a session. Ads that are not available as
+// live assets are skipped.
+func ssaiPod(am *assetMgr, cat *adCatalog, sid string, interests []string, b ssaiBreak) []ssaiAd {
+	podSid := fmt.Sprintf("%s/%d", sid, b.id) // vary the lead ad between breaks
+	entries := cat.selectPod(podSid, interests, b.durS*1000)
+	if len(entries) == 0 {
+		entries = cat.fillPod(podSid, b.durS*1000)
+	}
+	ads := make([]ssaiAd, 0, len(entries))
+	for _, e := range entries {
+		a, ok := am.findAsset(e.Path)
+		if !ok || a.LoopDurMS == 0 {
+			continue
+		}
+		ads = append(ads, ssaiAd{a: a, mpdName: path.Base(e.MPDPath), durS: (a.LoopDurMS + 999) / 1000})
+	}
+	return ads
+}
+
+// ssaiLiveMPD generates the MPD with content and ad Periods for a session.
+func ssaiLiveMPD(am *assetMgr, cat *adCatalog, sid string, interests []string, a *asset, mpdName string,
+	cfg *ResponseConfig, drmCfg *drm.DrmConfig, nowMS int) (*m.MPD, error) {
+	mpd, err := LiveMPD(a, mpdName, cfg, drmCfg, nowMS)
+	if err != nil {
+		return nil, err
+	}
+	windowStartMS, nowRelMS := cfg.timeShiftWindowMS(nowMS)
+	windowStartS, nowS := windowStartMS/1000, nowRelMS/1000
+	inWindow := func(startS, endS int) bool {
+		return startS <= nowS && endS > windowStartS
+	}
+	var merged periodsMerger
+	merged.add(mpd)
+	inPeriod := mpd.Periods[0]
+	var periods []*m.Period
+	contentStartS, afterBreak := 0, false
+	for _, b := range cfg.SSAI.breaksBefore(windowStartS, nowS+1, cfg.StartTimeS, cfg.SCTE35Schedule) {
+		if b.startS < contentStartS {
+			continue // overlapped by the previous pod
+		}
+		ads := ssaiPod(am, cat, sid, interests, b)
+		if len(ads) == 0 {
+			continue
+		}
+		if b.startS > contentStartS && inWindow(contentStartS, b.startS) {
+			p, err := ssaiContentPeriod(inPeriod, cfg, contentStartS, b.startS, afterBreak)
+			if err != nil {
+				return nil, err
+			}
+			periods = append(periods, p)
+		}
+		t := b.startS
+		for _, ad := range ads {
+			if inWindow(t, t+ad.durS) {
+				adCfg, adNowMS, ok := periodStreamCfg(cfg, t, t+ad.durS, nowMS)
+				if ok {
+					clearContentOptions(adCfg)
+					adMPD, err := LiveMPD(ad.a, ad.mpdName, adCfg, drmCfg, adNowMS)
+					if err != nil {
+						return nil, fmt.Errorf("ad %s: %w", ad.a.AssetPath, err)
+					}
+					merged.add(adMPD)
+					p := adMPD.Periods[0]
+					p.Id = fmt.Sprintf("AD%d", t)
+					p.Start = m.Seconds2DurPtr(t)
+					p.BaseURLs = []*m.BaseURLType{m.NewBaseURL(ssaiAdBaseURL(cfg, t, ad.a.AssetPath))}
+					periods = append(periods, p)
+				}
+			}
+			t += ad.durS
+		}
+		contentStartS, afterBreak = t, true
+	}
+	if contentStartS <= nowS {
+		// The last content Period is open, so it ends beyond the latest segment.
+		p, err := ssaiContentPeriod(inPeriod, cfg, contentStartS, nowS+3600, afterBreak)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	mpd.Periods = periods
+	merged.apply(mpd)
+	addSCTE35EventStreams(mpd, cfg, nowMS)
+	addDashEventStreams(mpd, a, cfg, nowMS)
+	if cfg.liveMPDType() == segmentNumber {
+		mpd.PublishTime, err = lastPeriodStartTime(mpd)
+		if err != nil {
+			return nil, fmt.Errorf("lastPeriodStartTime: %w", err)
+		}
+	}
+	return mpd, nil
+}
+
+// ssaiContentPeriod returns the content Period from startS to endS, with the media
+// timeline continuing through the breaks. After a break, the configured gaps delay
+// the start of the video and audio.
+func ssaiContentPeriod(in *m.Period, cfg *ResponseConfig, startS, endS int, afterBreak bool) (*m.Period, error) {
+	p := in.Clone()
+	p.Id = fmt.Sprintf("P%d", startS)
+	p.Start = m.Seconds2DurPtr(startS)
+	p.EventStreams = nil // SCTE-35 and generated events are added per Period by the caller
+	endMS := uint64(endS) * 1000
+	for _, as := range p.AdaptationSets {
+		timescale := int(as.SegmentTemplate.GetTimescale())
+		pto := Ptr(uint64(startS * timescale))
+		startMS := uint64(startS) * 1000
+		if afterBreak {
+			if as.ContentType == "audio" {
+				startMS += uint64(cfg.SSAI.AudioGapMS)
+			} else {
+				startMS += uint64(cfg.SSAI.VideoGapMS)
+			}
+		}
+		templateType := cfg.liveMPDType()
+		if as.ContentType == "image" {
+			templateType = segmentNumber
+		}
+		as.SegmentTemplate.PresentationTimeOffset = pto
+		switch templateType {
+		case segmentNumber:
+			segDur := int(*as.SegmentTemplate.Duration)
+			as.SegmentTemplate.StartNumber = Ptr(cfg.getStartNr() + uint32(startS*timescale/segDur))
+		case timeLineTime:
+			as.SegmentTemplate.SegmentTimeline.S, _ = reduceS(as.SegmentTemplate.SegmentTimeline.S, nil,
+				timescale, startMS, endMS)
+		case timeLineNumber:
+			as.SegmentTemplate.SegmentTimeline.S, as.SegmentTemplate.StartNumber = reduceS(
+				as.SegmentTemplate.SegmentTimeline.S, as.SegmentTemplate.StartNumber, timescale, startMS, endMS)
+		default:
+			return nil, fmt.Errorf("unknown mpd type")
+		}
+	}
+	return p, nil
+}
+
+// ssaiAdBaseURL returns the absolute BaseURL of an ad Period starting at startS: the
+// ad asset directory with adp_<startS> injected after the /livesim2 mount, and the
+// options of the stream.
+func ssaiAdBaseURL(cfg *ResponseConfig, startS int, adPath string) string {
+	var b strings.Builder
+	b.WriteString(cfg.Host)
+	b.WriteByte('/')
+	b.WriteString(cfg.URLParts[1]) // "livesim2"
+	fmt.Fprintf(&b, "/%s%d", ssaiTokenPrefix, startS)
+	for _, p := range cfg.URLParts[2:cfg.URLContentIdx] {
+		b.WriteByte('/')
+		b.WriteString(p)
+	}
+	b.WriteByte('/')
+	b.WriteString(adPath)
+	b.WriteByte('/')
+	return b.String()
+}
+
+// ssaiAdSegmentCfg returns the configuration and time for a segment of the ad Period
+// starting at cfg.SSAIAdStartS, generated from ad asset a.
+func ssaiAdSegmentCfg(cfg *ResponseConfig, a *asset, nowMS int) (*ResponseConfig, int, bool) {
+	startS := *cfg.SSAIAdStartS
+	if !cfg.SSAI.adStartInBreak(startS, cfg.StartTimeS, cfg.SCTE35Schedule) {
+		return nil, 0, false
+	}
+	adCfg, adNowMS, ok := periodStreamCfg(cfg, startS, startS+(a.LoopDurMS+999)/1000, nowMS)
+	if !ok {
+		return nil, 0, false
+	}
+	clearContentOptions(adCfg)
+	adCfg.SSAIAdStartS = nil
+	return adCfg, adNowMS, true
+}
+
+// clearContentOptions removes the options of an ad Period configuration that only apply
+// to the content: the breaks, the SCTE-35 and DASH events, and the trick-mode
+// AdaptationSet, since the sync samples of the ads are not loaded.
+func clearContentOptions(adCfg *ResponseConfig) {
+	adCfg.SSAI = nil
+	adCfg.SCTE35PerMinute, adCfg.SCTE35Schedule, adCfg.Events = nil, nil, nil
+	adCfg.TrickPlayFlag = false
+}
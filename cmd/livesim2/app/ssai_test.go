@@ -0,0 +1,158 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateSSAIConfig(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    *SSAIConfig
+		wantErr string
+	}{
+		{val: "30:20", want: &SSAIConfig{Breaks: []SGAIBreak{{OffsetS: 30, DurationS: 20}}}},
+		{val: "p60:20;vgap=40;agap=500",
+			want: &SSAIConfig{Periodic: &SGAIPeriodic{PeriodS: 60, DurationS: 20}, VideoGapMS: 40, AudioGapMS: 500}},
+		{val: "", wantErr: "empty ssai config"},
+		{val: "30", wantErr: "ssai"},
+		{val: "30:20;vgap=20000", wantErr: "must be in"},
+		{val: "30:20;agap=x", wantErr: "must be in"},
+		{val: "30:20;seed=1", wantErr: "unknown ssai param"},
+		{val: "30:20;vgap", wantErr: "must be key=val"},
+		{val: "30:20; vgap=1", wantErr: "extra spaces"},
+	}
+	for _, c := range cases {
+		got, err := CreateSSAIConfig(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestSSAIBreaks(t *testing.T) {
+	c := SSAIConfig{Breaks: []SGAIBreak{{OffsetS: 90, DurationS: 10}, {OffsetS: 30, DurationS: 20}}}
+	assert.Equal(t, []ssaiBreak{{id: 2, startS: 30, durS: 20}}, c.breaksBefore(0, 60, 0, nil))
+	assert.Equal(t, []ssaiBreak{{id: 2, startS: 30, durS: 20}, {id: 1, startS: 90, durS: 10}},
+		c.breaksBefore(40, 100, 0, nil))
+
+	// Periodic breaks are anchored to wall-clock, also with a later availabilityStartTime.
+	c = SSAIConfig{Periodic: &SGAIPeriodic{PeriodS: 60, DurationS: 20}}
+	assert.Equal(t, []ssaiBreak{{id: 2, startS: 60, durS: 20}, {id: 3, startS: 120, durS: 20}},
+		c.breaksBefore(70, 131, 0, nil))
+	assert.Equal(t, []ssaiBreak{{id: 2, startS: 30, durS: 20}, {id: 3, startS: 90, durS: 20}},
+		c.breaksBefore(0, 100, 30, nil))
+}
+
+func TestSSAIStream(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	getMPD := func(t *testing.T, url string) *m.MPD {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		return mpd
+	}
+
+	// The 20s break at 30s is filled with the two 10s ads.
+	mpd := getMPD(t, "/livesim2/segtimeline_1/ssai_30:20;agap=500/testpic_2s/Manifest.mpd?nowMS=80000")
+	require.Len(t, mpd.Periods, 4)
+	wantIDs := []string{"P0", "AD30", "AD40", "P50"}
+	for i, p := range mpd.Periods {
+		assert.Equal(t, wantIDs[i], p.Id)
+	}
+	for _, p := range mpd.Periods[1:3] {
+		require.Len(t, p.BaseURLs, 1)
+		assert.Contains(t, string(p.BaseURLs[0].Value), "/livesim2/adp_"+p.Id[2:]+"/segtimeline_1/ssai_30:20;agap=500/ads/")
+		for _, as := range p.AdaptationSets {
+			assert.Equal(t, uint64(0), *as.SegmentTemplate.SegmentTimeline.S[0].T, "ad timeline rebased")
+		}
+	}
+	resumed := mpd.Periods[3]
+	assert.Equal(t, m.Duration(50*1_000_000_000), *resumed.Start)
+	for _, as := range resumed.AdaptationSets {
+		stpl := as.SegmentTemplate
+		ts := uint64(stpl.GetTimescale())
+		assert.Equal(t, 50*ts, *stpl.PresentationTimeOffset)
+		t0 := *stpl.SegmentTimeline.S[0].T
+		if as.ContentType == "audio" {
+			assert.GreaterOrEqual(t, t0, 50*ts+ts/2, "audio gap")
+		} else {
+			assert.Equal(t, 50*ts, t0)
+		}
+	}
+
+	// Sessions get different lead ads.
+	lead := func(sid string) string {
+		mpd := getMPD(t, "/livesim2/ssai_30:10/testpic_2s/Manifest.mpd?nowMS=60000&sid="+sid)
+		require.Len(t, mpd.Periods, 3)
+		return string(mpd.Periods[1].BaseURLs[0].Value)
+	}
+	leads := map[string]bool{}
+	for _, sid := range []string{"a", "b", "c", "d", "e", "f"} {
+		leads[lead(sid)] = true
+	}
+	assert.Len(t, leads, 2)
+
+	cases := []struct {
+		url      string
+		wantCode int
+		wantTfdt uint64
+	}{
+		{"/livesim2/adp_30/segtimeline_1/ssai_30:20/ads/train_ad/V1/0.m4s?nowMS=80000", http.StatusOK, 0},
+		{"/livesim2/adp_30/segtimeline_1/ssai_30:20/ads/train_ad/V1/30720.m4s?nowMS=80000", http.StatusOK, 30720},
+		{"/livesim2/adp_30/segtimeline_1/ssai_30:20/ads/train_ad/V1/0.m4s?nowMS=31000", http.StatusTooEarly, 0},
+		{"/livesim2/adp_90/segtimeline_1/ssai_30:20/ads/train_ad/V1/0.m4s?nowMS=80000", http.StatusNotFound, 0},
+		{"/livesim2/adp_60/segtimeline_1/ssai_30:20/ads/train_ad/V1/0.m4s?nowMS=80000", http.StatusNotFound, 0},
+		{"/livesim2/segtimeline_1/ssai_30:20/testpic_2s/V300/4500000.m4s?nowMS=80000", http.StatusOK, 4500000},
+	}
+	for _, c := range cases {
+		resp, body := testFullRequest(t, ts, "GET", c.url, nil)
+		require.Equal(t, c.wantCode, resp.StatusCode, c.url)
+		if c.wantCode != http.StatusOK {
+			continue
+		}
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		assert.Equal(t, c.wantTfdt, f.Segments[0].Fragments[0].Moof.Traf.Tfdt.BaseMediaDecodeTime(), c.url)
+	}
+
+	for _, u := range []string{
+		"/livesim2/ssai_30:20;vgap=40/testpic_2s/Manifest.mpd",
+		"/livesim2/ssai_30:20/periods_60/testpic_2s/Manifest.mpd",
+		"/livesim2/ssai_30:20/channels/demo/Manifest.mpd",
+		"/livesim2/adp_10/testpic_2s/V300/5.m4s",
+	} {
+		resp, _ := testFullRequest(t, ts, "GET", u, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, u)
+	}
+}
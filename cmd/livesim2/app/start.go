@@ -72,6 +72,16 @@ func SetupServer(ctx context.Context, cfg *ServerConfig) (*Server, error) {
 		reqLimiter:       reqLimiter,
 		sgaiSessions:     NewSgaiSessionMgr(),
 		steeringSessions: NewSteeringSessionMgr(),
+		cmcdSessions:     NewCmcdSessionMgr(),
+		timeService:      NewTimeService(),
+	}
+
+	if cfg.NtpPort > 0 {
+		ntpAddr, err := server.timeService.ListenNTP(ctx, fmt.Sprintf(":%d", cfg.NtpPort))
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("NTP server started", "addr", ntpAddr.String())
 	}
 
 	r.Route("/api", createRouteAPI(&server))
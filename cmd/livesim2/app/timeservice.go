@@ -0,0 +1,446 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+// Built-in time service. The UTCTiming methods normally point at public time servers,
+// which are not reachable offline and cannot be told to lie. With the timesrv_ URL option,
+// the UTCTiming elements instead point at the livesim2 server itself:
+//
+//   - /time/iso and /time/xsdate return the (virtual) server time as ISO 8601 / xs:dateTime,
+//     with second or millisecond (?ms=1) precision, and a matching Date header for HEAD;
+//   - an NTP/SNTP listener on UDP (enabled with --ntpport) answers NTPv1-4 client requests.
+//
+// Each session can get its own clock skew, random jitter and leap-second signaling. For the
+// HTTP endpoints they are carried as query parameters in the UTCTiming URL. NTP packets
+// carry no session information, so the configuration of the latest MPD request from the
+// same client IP is used. Leap seconds are signaled in the MPD LeapSecondInformation
+// element and in the NTP leap indicator during the last 24 hours before the change.
+
+const (
+	timeSrvISOPath     = "/time/iso"
+	timeSrvXSDatePath  = "/time/xsdate"
+	timeSrvQuerySkew   = "skew"
+	timeSrvQueryJitter = "jitter"
+	timeSrvQueryMillis = "ms"
+	timeSrvMaxSkewMS   = 24 * 3600 * 1000
+	timeSrvMaxJitterMS = 3600 * 1000
+	timeSrvMaxLeap     = 1000
+	timeSrvDefaultTTL  = 30 * time.Minute
+	timeSrvMaxClients  = 2000
+	isoSecondsFormat   = "2006-01-02T15:04:05Z"
+	isoMillisFormat    = "2006-01-02T15:04:05.000Z"
+)
+
+const (
+	ntpPacketLen     = 48
+	ntpReadBufferLen = 512
+	ntpDefaultPort   = 123
+	ntpEpochOffsetS  = 2208988800 // seconds from 1900-01-01 (NTP epoch) to 1970-01-01
+	ntpModeClient    = 3
+	ntpModeServer    = 4
+	ntpLeapNone      = 0
+	ntpLeapInsert    = 1 // last minute of the day has 61 seconds
+	ntpLeapDelete    = 2 // last minute of the day has 59 seconds
+	ntpLeapWarningMS = 24 * 3600 * 1000
+	ntpStratum       = 1
+	ntpPrecisionLog2 = -10 // about 1ms, the resolution of the server clock
+	ntpReferenceID   = "LOCL"
+)
+
+// TimeSrvConfig is the per-session configuration of the built-in time service.
+type TimeSrvConfig struct {
+	SkewMS   int `json:"skewMS"`
+	JitterMS int `json:"jitterMS,omitempty"`
+	// Leap is the TAI-UTC offset (availabilityStartLeapOffset), nil if not signaled.
+	Leap *int `json:"leap,omitempty"`
+	// NextLeap is the TAI-UTC offset after the leap second at LeapAtS (seconds since epoch).
+	NextLeap *int `json:"nextLeap,omitempty"`
+	LeapAtS  int  `json:"leapAtS,omitempty"`
+}
+
+// CreateTimeSrvConfig parses <skewMS>[;jitter=<ms>;leap=<offset>;nextleap=<offset>;leapat=<s>].
+func CreateTimeSrvConfig(val string) (*TimeSrvConfig, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty timesrv config")
+	}
+	if hasExtraSpaces(val) {
+		return nil, fmt.Errorf("timesrv config %q has extra spaces", val)
+	}
+	parts := strings.Split(val, ";")
+	skew, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("timesrv skew %q: %w", parts[0], err)
+	}
+	if skew < -timeSrvMaxSkewMS || skew > timeSrvMaxSkewMS {
+		return nil, fmt.Errorf("timesrv skew %dms: must be within ±%dms", skew, timeSrvMaxSkewMS)
+	}
+	cfg := &TimeSrvConfig{SkewMS: skew}
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("timesrv param %q must be key=val", kv)
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("timesrv %s %q: %w", key, v, err)
+		}
+		switch key {
+		case "jitter":
+			if n < 0 || n > timeSrvMaxJitterMS {
+				return nil, fmt.Errorf("timesrv jitter %dms: must be in 0-%dms", n, timeSrvMaxJitterMS)
+			}
+			cfg.JitterMS = n
+		case "leap", "nextleap":
+			if n < 0 || n > timeSrvMaxLeap {
+				return nil, fmt.Errorf("timesrv %s %d: must be in 0-%d", key, n, timeSrvMaxLeap)
+			}
+			if key == "leap" {
+				cfg.Leap = Ptr(n)
+			} else {
+				cfg.NextLeap = Ptr(n)
+			}
+		case "leapat":
+			if n <= 0 {
+				return nil, fmt.Errorf("timesrv leapat %d: must be > 0", n)
+			}
+			cfg.LeapAtS = n
+		default:
+			return nil, fmt.Errorf("unknown timesrv param %q", key)
+		}
+	}
+	switch {
+	case cfg.NextLeap == nil && cfg.LeapAtS == 0:
+	case cfg.NextLeap == nil || cfg.LeapAtS == 0 || cfg.Leap == nil:
+		return nil, fmt.Errorf("timesrv nextleap and leapat must be used together with leap")
+	case *cfg.NextLeap == *cfg.Leap:
+		return nil, fmt.Errorf("timesrv nextleap must differ from leap")
+	}
+	return cfg, nil
+}
+
+// ParseTimeSrvConfig parses a timesrv option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseTimeSrvConfig(key, val string) *TimeSrvConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreateTimeSrvConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// offsetMS returns the skew plus a random jitter in [-JitterMS, JitterMS].
+func (c *TimeSrvConfig) offsetMS(randIntN func(n int) int) int64 {
+	offset := int64(c.SkewMS)
+	if c.JitterMS > 0 {
+		offset += int64(randIntN(2*c.JitterMS+1) - c.JitterMS)
+	}
+	return offset
+}
+
+// leapIndicator returns the NTP leap indicator at nowMS. It is set during the last
+// 24 hours before the leap second.
+func (c *TimeSrvConfig) leapIndicator(nowMS int64) uint8 {
+	if c.NextLeap == nil || c.Leap == nil {
+		return ntpLeapNone
+	}
+	untilMS := int64(c.LeapAtS)*1000 - nowMS
+	if untilMS <= 0 || untilMS > ntpLeapWarningMS {
+		return ntpLeapNone
+	}
+	if *c.NextLeap > *c.Leap {
+		return ntpLeapInsert
+	}
+	return ntpLeapDelete
+}
+
+// query returns the query string for the HTTP endpoints, including ms=1 if withMS is set.
+func (c *TimeSrvConfig) query(withMS bool) string {
+	q := url.Values{}
+	if withMS {
+		q.Set(timeSrvQueryMillis, "1")
+	}
+	if c.SkewMS != 0 {
+		q.Set(timeSrvQuerySkew, strconv.Itoa(c.SkewMS))
+	}
+	if c.JitterMS != 0 {
+		q.Set(timeSrvQueryJitter, strconv.Itoa(c.JitterMS))
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// leapSecondInformation returns the MPD LeapSecondInformation element, or nil if not configured.
+func (c *TimeSrvConfig) leapSecondInformation() *m.LeapSecondInformationType {
+	if c.Leap == nil {
+		return nil
+	}
+	lsi := &m.LeapSecondInformationType{AvailabilityStartLeapOffset: *c.Leap}
+	if c.NextLeap != nil {
+		lsi.NextAvailabilityStartLeapOffset = *c.NextLeap
+		lsi.NextLeapChangeTime = m.ConvertToDateTime(float64(c.LeapAtS))
+	}
+	return lsi
+}
+
+// timeClient is the time-service configuration registered for a client IP.
+type timeClient struct {
+	cfg      TimeSrvConfig
+	lastSeen time.Time
+}
+
+// TimeService serves the built-in HTTP and NTP time endpoints and keeps the per-client
+// configuration used by NTP. Bounded and time-limited like CmcdSessionMgr.
+type TimeService struct {
+	mu         sync.RWMutex
+	clients    map[string]*timeClient
+	maxClients int
+	ttl        time.Duration
+	ntpPort    int              // port of the NTP listener, 0 if not running
+	now        func() time.Time // injectable for tests
+	nowMS      func() int64     // server (virtual) time
+	randIntN   func(n int) int  // jitter source
+}
+
+// NewTimeService creates a time service that follows the server clock.
+func NewTimeService() *TimeService {
+	return &TimeService{
+		clients:    make(map[string]*timeClient),
+		maxClients: timeSrvMaxClients,
+		ttl:        timeSrvDefaultTTL,
+		now:        time.Now,
+		nowMS:      func() int64 { return serverClock.NowMS("") },
+		randIntN:   rand.IntN,
+	}
+}
+
+// Register stores cfg as the NTP configuration for the client ip.
+func (ts *TimeService) Register(ip string, cfg TimeSrvConfig) {
+	if ip == "" {
+		return
+	}
+	now := ts.now()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.clients[ip] = &timeClient{cfg: cfg, lastSeen: now}
+	ts.evictLocked(now)
+}
+
+// configFor returns the configuration registered for ip, if any and not expired.
+func (ts *TimeService) configFor(ip string) (TimeSrvConfig, bool) {
+	now := ts.now()
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	c, ok := ts.clients[ip]
+	if !ok || (ts.ttl > 0 && now.Sub(c.lastSeen) > ts.ttl) {
+		return TimeSrvConfig{}, false
+	}
+	return c.cfg, true
+}
+
+// evictLocked drops expired clients and enforces maxClients (oldest first). Caller must hold mu.
+func (ts *TimeService) evictLocked(now time.Time) {
+	if ts.ttl > 0 {
+		for ip, c := range ts.clients {
+			if now.Sub(c.lastSeen) > ts.ttl {
+				delete(ts.clients, ip)
+			}
+		}
+	}
+	for ts.maxClients > 0 && len(ts.clients) > ts.maxClients {
+		var oldestIP string
+		var oldest time.Time
+		first := true
+		for ip, c := range ts.clients {
+			if first || c.lastSeen.Before(oldest) {
+				oldestIP, oldest, first = ip, c.lastSeen, false
+			}
+		}
+		delete(ts.clients, oldestIP)
+	}
+}
+
+// NTPAddr returns the NTP server address for an MPD served from host (scheme://host[:port]),
+// or "" if no NTP listener is running. The port is omitted if it is the standard 123.
+func (ts *TimeService) NTPAddr(host string) string {
+	ts.mu.RLock()
+	port := ts.ntpPort
+	ts.mu.RUnlock()
+	if port == 0 {
+		return ""
+	}
+	hostname := host
+	if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+		hostname = u.Hostname()
+	}
+	if port == ntpDefaultPort {
+		return hostname
+	}
+	return net.JoinHostPort(hostname, strconv.Itoa(port))
+}
+
+// ListenNTP starts an NTP/SNTP listener on the UDP address addr. It serves until ctx is done.
+func (ts *TimeService) ListenNTP(ctx context.Context, addr string) (net.Addr, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("ntp listen: %w", err)
+	}
+	if udpAddr, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+		ts.mu.Lock()
+		ts.ntpPort = udpAddr.Port
+		ts.mu.Unlock()
+	}
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	go ts.serveNTP(conn)
+	return conn.LocalAddr(), nil
+}
+
+// serveNTP answers NTP client requests on conn until it is closed.
+func (ts *TimeService) serveNTP(conn net.PacketConn) {
+	buf := make([]byte, ntpReadBufferLen)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Error("ntp read", "err", err)
+			}
+			return
+		}
+		recvMS := ts.nowMS()
+		var cfg TimeSrvConfig
+		if udpAddr, ok := from.(*net.UDPAddr); ok {
+			cfg, _ = ts.configFor(udpAddr.IP.String())
+		}
+		offset := cfg.offsetMS(ts.randIntN)
+		recvMS += offset
+		txMS := ts.nowMS() + offset
+		resp, err := ntpResponse(buf[:n], recvMS, txMS, cfg.leapIndicator(txMS))
+		if err != nil {
+			slog.Debug("ntp bad request", "from", from.String(), "err", err)
+			continue
+		}
+		if _, err := conn.WriteTo(resp, from); err != nil {
+			slog.Warn("ntp write", "to", from.String(), "err", err)
+		}
+	}
+}
+
+// ntpResponse builds a server reply to an NTP client request. recvMS and txMS are the
+// receive and transmit times in ms since the Unix epoch.
+func ntpResponse(req []byte, recvMS, txMS int64, leap uint8) ([]byte, error) {
+	if len(req) < ntpPacketLen {
+		return nil, fmt.Errorf("ntp packet too short: %d bytes", len(req))
+	}
+	version := (req[0] >> 3) & 0x07
+	mode := req[0] & 0x07
+	if version < 1 || version > 4 {
+		return nil, fmt.Errorf("unsupported ntp version %d", version)
+	}
+	if mode != ntpModeClient {
+		return nil, fmt.Errorf("ntp mode %d is not client", mode)
+	}
+	resp := make([]byte, ntpPacketLen)
+	resp[0] = leap<<6 | version<<3 | ntpModeServer
+	resp[1] = ntpStratum
+	resp[2] = req[2] // poll
+	precision := int8(ntpPrecisionLog2)
+	resp[3] = byte(precision)
+	// root delay and root dispersion (bytes 4-11) are zero for a reference clock
+	copy(resp[12:16], ntpReferenceID)
+	binary.BigEndian.PutUint64(resp[16:24], ntpTimestamp(txMS-txMS%1000))
+	copy(resp[24:32], req[40:48]) // origin timestamp is the client's transmit timestamp
+	binary.BigEndian.PutUint64(resp[32:40], ntpTimestamp(recvMS))
+	binary.BigEndian.PutUint64(resp[40:48], ntpTimestamp(txMS))
+	return resp, nil
+}
+
+// ntpTimestamp converts ms since the Unix epoch to a 64-bit NTP timestamp.
+func ntpTimestamp(unixMS int64) uint64 {
+	secs := uint64(unixMS/1000 + ntpEpochOffsetS)
+	frac := (uint64(unixMS%1000) << 32) / 1000
+	return secs<<32 | frac
+}
+
+// ntpTimeMS converts a 64-bit NTP timestamp to ms since the Unix epoch (rounded).
+func ntpTimeMS(ts uint64) int64 {
+	secs := int64(ts>>32) - ntpEpochOffsetS
+	return secs*1000 + int64(((ts&0xffffffff)*1000+1<<31)>>32) // rounded to nearest ms
+}
+
+// timeHandlerFunc serves /time/iso and /time/xsdate. The query parameters skew and jitter
+// (ms) shift the returned time, and ms=1 gives millisecond precision. The Date header
+// carries the same time, so the endpoints also work for the http-head method.
+func (s *Server) timeHandlerFunc(w http.ResponseWriter, r *http.Request) {
+	log := logging.SubLoggerWithRequestID(slog.Default(), r)
+	q := r.URL.Query()
+	var cfg TimeSrvConfig
+	for _, p := range []struct {
+		key  string
+		dst  *int
+		minV int
+		maxV int
+	}{
+		{timeSrvQuerySkew, &cfg.SkewMS, -timeSrvMaxSkewMS, timeSrvMaxSkewMS},
+		{timeSrvQueryJitter, &cfg.JitterMS, 0, timeSrvMaxJitterMS},
+	} {
+		v := q.Get(p.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < p.minV || n > p.maxV {
+			log.Debug("bad time query", "key", p.key, "val", v)
+			http.Error(w, fmt.Sprintf("%s=%s: must be an integer in [%d, %d]", p.key, v, p.minV, p.maxV),
+				http.StatusBadRequest)
+			return
+		}
+		*p.dst = n
+	}
+	nowMS := s.timeService.nowMS() + cfg.offsetMS(s.timeService.randIntN)
+	t := time.UnixMilli(nowMS).UTC()
+	format := isoSecondsFormat
+	if q.Has(timeSrvQueryMillis) && q.Get(timeSrvQueryMillis) != "0" {
+		format = isoMillisFormat
+	}
+	body := t.Format(format)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Date", t.Format(http.TimeFormat))
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write([]byte(body)); err != nil {
+		log.Error("could not write time response", "err", err)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateTimeSrvConfig(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    *TimeSrvConfig
+		wantErr string
+	}{
+		{val: "0", want: &TimeSrvConfig{}},
+		{val: "-1500;jitter=20", want: &TimeSrvConfig{SkewMS: -1500, JitterMS: 20}},
+		{val: "0;leap=37", want: &TimeSrvConfig{Leap: Ptr(37)}},
+		{
+			val:  "250;leap=37;nextleap=38;leapat=1798761600",
+			want: &TimeSrvConfig{SkewMS: 250, Leap: Ptr(37), NextLeap: Ptr(38), LeapAtS: 1798761600},
+		},
+		{val: "", wantErr: "empty timesrv config"},
+		{val: "fast", wantErr: "timesrv skew"},
+		{val: "100000000", wantErr: "must be within"},
+		{val: "0;jitter=-1", wantErr: "timesrv jitter"},
+		{val: "0;nextleap=38;leapat=100", wantErr: "together with leap"},
+		{val: "0;leap=37;nextleap=38", wantErr: "together with leap"},
+		{val: "0;leap=37;nextleap=37;leapat=100", wantErr: "must differ"},
+		{val: "0;foo=1", wantErr: "unknown timesrv param"},
+		{val: "0;skew", wantErr: "must be key=val"},
+		{val: "0; jitter=5", wantErr: "extra spaces"},
+	}
+	for _, c := range cases {
+		got, err := CreateTimeSrvConfig(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestTimeSrvLeapIndicator(t *testing.T) {
+	leapAtS := 1798761600
+	ins := TimeSrvConfig{Leap: Ptr(37), NextLeap: Ptr(38), LeapAtS: leapAtS}
+	del := TimeSrvConfig{Leap: Ptr(37), NextLeap: Ptr(36), LeapAtS: leapAtS}
+	leapMS := int64(leapAtS) * 1000
+	assert.Equal(t, uint8(ntpLeapNone), ins.leapIndicator(leapMS-ntpLeapWarningMS-1))
+	assert.Equal(t, uint8(ntpLeapInsert), ins.leapIndicator(leapMS-1000))
+	assert.Equal(t, uint8(ntpLeapDelete), del.leapIndicator(leapMS-1000))
+	assert.Equal(t, uint8(ntpLeapNone), ins.leapIndicator(leapMS))
+	assert.Equal(t, uint8(ntpLeapNone), (&TimeSrvConfig{}).leapIndicator(leapMS-1000))
+
+	lsi := ins.leapSecondInformation()
+	require.NotNil(t, lsi)
+	assert.Equal(t, 37, lsi.AvailabilityStartLeapOffset)
+	assert.Equal(t, 38, lsi.NextAvailabilityStartLeapOffset)
+	assert.Equal(t, m.DateTime("2027-01-01T00:00:00Z"), lsi.NextLeapChangeTime)
+}
+
+func TestNTPTimestamp(t *testing.T) {
+	for _, ms := range []int64{0, 999, 1_700_000_000_123, 1_798_761_600_000} {
+		assert.Equal(t, ms, ntpTimeMS(ntpTimestamp(ms)), ms)
+	}
+	assert.Equal(t, uint64(ntpEpochOffsetS)<<32|1<<31, ntpTimestamp(500))
+}
+
+func TestNTPResponse(t *testing.T) {
+	req := make([]byte, ntpPacketLen)
+	req[0] = 4<<3 | ntpModeClient
+	req[2] = 6
+	binary.BigEndian.PutUint64(req[40:48], 0x0102030405060708)
+	resp, err := ntpResponse(req, 1_000_000, 1_000_250, ntpLeapInsert)
+	require.NoError(t, err)
+	require.Len(t, resp, ntpPacketLen)
+	assert.Equal(t, byte(ntpLeapInsert<<6|4<<3|ntpModeServer), resp[0])
+	assert.Equal(t, byte(ntpStratum), resp[1])
+	assert.Equal(t, byte(6), resp[2])
+	assert.Equal(t, ntpReferenceID, string(resp[12:16]))
+	assert.Equal(t, req[40:48], resp[24:32])
+	assert.Equal(t, int64(1_000_000), ntpTimeMS(binary.BigEndian.Uint64(resp[32:40])))
+	assert.Equal(t, int64(1_000_250), ntpTimeMS(binary.BigEndian.Uint64(resp[40:48])))
+
+	_, err = ntpResponse(req[:20], 0, 0, 0)
+	assert.ErrorContains(t, err, "too short")
+	req[0] = 4<<3 | ntpModeServer
+	_, err = ntpResponse(req, 0, 0, 0)
+	assert.ErrorContains(t, err, "not client")
+	req[0] = 7<<3 | ntpModeClient
+	_, err = ntpResponse(req, 0, 0, 0)
+	assert.ErrorContains(t, err, "unsupported ntp version")
+}
+
+// ntpQuery sends an SNTP request to addr and returns the response packet.
+func ntpQuery(t *testing.T, addr string) []byte {
+	t.Helper()
+	conn, err := net.Dial("udp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	req := make([]byte, ntpPacketLen)
+	req[0] = 3<<3 | ntpModeClient
+	_, err = conn.Write(req)
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	resp := make([]byte, ntpReadBufferLen)
+	n, err := conn.Read(resp)
+	require.NoError(t, err)
+	return resp[:n]
+}
+
+func TestTimeService(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, err := SetupServer(ctx, &cfg)
+	require.NoError(t, err)
+	const nowMS = int64(1_798_758_000_000) // one hour before 2027-01-01
+	server.timeService.nowMS = func() int64 { return nowMS }
+	server.timeService.randIntN = func(n int) int { return n - 1 } // max positive jitter
+	ntpAddr, err := server.timeService.ListenNTP(ctx, "127.0.0.1:0")
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	t.Run("http endpoints", func(t *testing.T) {
+		cases := []struct {
+			path     string
+			wantCode int
+			wantBody string
+		}{
+			{path: "/time/iso", wantCode: http.StatusOK, wantBody: "2026-12-31T23:00:00Z"},
+			{path: "/time/xsdate?ms=1&skew=-1500", wantCode: http.StatusOK, wantBody: "2026-12-31T22:59:58.500Z"},
+			{path: "/time/iso?ms&skew=100&jitter=20", wantCode: http.StatusOK, wantBody: "2026-12-31T23:00:00.120Z"},
+			{path: "/time/iso?skew=fast", wantCode: http.StatusBadRequest},
+			{path: "/time/iso?jitter=-5", wantCode: http.StatusBadRequest},
+		}
+		for _, c := range cases {
+			resp, body := testFullRequest(t, ts, "GET", c.path, nil)
+			require.Equal(t, c.wantCode, resp.StatusCode, c.path)
+			if c.wantCode == http.StatusOK {
+				assert.Equal(t, c.wantBody, string(body), c.path)
+				assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+			}
+		}
+		resp, body := testFullRequest(t, ts, "HEAD", "/time/iso?skew=-60000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, body)
+		assert.Equal(t, "Thu, 31 Dec 2026 22:59:00 GMT", resp.Header.Get("Date"))
+	})
+
+	t.Run("ntp without session", func(t *testing.T) {
+		resp := ntpQuery(t, ntpAddr.String())
+		require.Len(t, resp, ntpPacketLen)
+		assert.Equal(t, byte(ntpLeapNone<<6|3<<3|ntpModeServer), resp[0])
+		assert.Equal(t, nowMS, ntpTimeMS(binary.BigEndian.Uint64(resp[40:48])))
+	})
+
+	t.Run("mpd and ntp session", func(t *testing.T) {
+		leapAtS := nowMS/1000 + 3600
+		mpdURL := fmt.Sprintf("/livesim2/timesrv_2000;jitter=10;leap=37;nextleap=38;leapat=%d/"+
+			"utc_ntp-httpxsdatems-httpiso-head/testpic_2s/Manifest.mpd", leapAtS)
+		resp, body := testFullRequest(t, ts, "GET", mpdURL, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		_, ntpPort, err := net.SplitHostPort(ntpAddr.String())
+		require.NoError(t, err)
+		wantValues := []string{
+			"127.0.0.1:" + ntpPort,
+			ts.URL + "/time/xsdate?jitter=10&ms=1&skew=2000",
+			ts.URL + "/time/iso?jitter=10&skew=2000",
+			ts.URL + "/time/iso?jitter=10&skew=2000",
+		}
+		require.Len(t, mpd.UTCTimings, len(wantValues))
+		for i, ut := range mpd.UTCTimings {
+			assert.Equal(t, wantValues[i], ut.Value)
+		}
+		require.NotNil(t, mpd.LeapSecondInformation)
+		assert.Equal(t, 37, mpd.LeapSecondInformation.AvailabilityStartLeapOffset)
+		assert.Equal(t, 38, mpd.LeapSecondInformation.NextAvailabilityStartLeapOffset)
+		assert.Equal(t, m.DateTime("2027-01-01T00:00:00Z"), mpd.LeapSecondInformation.NextLeapChangeTime)
+
+		// The NTP response now follows the skew, jitter and leap of the MPD request.
+		ntpResp := ntpQuery(t, ntpAddr.String())
+		assert.Equal(t, byte(ntpLeapInsert<<6|3<<3|ntpModeServer), ntpResp[0])
+		assert.Equal(t, nowMS+2010, ntpTimeMS(binary.BigEndian.Uint64(ntpResp[40:48])))
+	})
+}
@@ -0,0 +1,197 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/hi264/pkg/yuv"
+)
+
+// Time thumbnails (timethumbs_) are generated thumbnail tiles in an image AdaptationSet of
+// their own. Every segment is one JPEG image with a grid of tiles, and each tile shows the
+// UTC time of its start and the segment number, so that scrubbing previews can be checked
+// against the timeline. The tiles are rendered with the SGAI slate text plane. As for other
+// thumbnails, the segments use $Number$ with the average video segment duration.
+
+const (
+	ThumbnailTileSchemeIdUri   = "http://dashif.org/thumbnail_tile"
+	TimeThumbsRepID            = "timethumbs"
+	timeThumbsASId             = 400
+	timeThumbsTileWidth        = 256
+	timeThumbsTileHeight       = 144
+	timeThumbsMaxTiles         = 10 // max columns and rows
+	timeThumbsJPEGQuality      = 75
+	typicalTimeThumbsTileBytes = 3000
+)
+
+// TimeThumbsConfig is the tile grid of the generated thumbnails.
+type TimeThumbsConfig struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+// CreateTimeThumbsConfig parses a timethumbs value <cols>x<rows>, such as 5x2.
+func CreateTimeThumbsConfig(val string) (*TimeThumbsConfig, error) {
+	colsStr, rowsStr, ok := strings.Cut(val, "x")
+	if !ok {
+		return nil, fmt.Errorf("timethumbs %q: must be <cols>x<rows>", val)
+	}
+	cols, err := strconv.Atoi(colsStr)
+	if err != nil {
+		return nil, fmt.Errorf("timethumbs cols %q: %w", colsStr, err)
+	}
+	rows, err := strconv.Atoi(rowsStr)
+	if err != nil {
+		return nil, fmt.Errorf("timethumbs rows %q: %w", rowsStr, err)
+	}
+	if cols < 1 || cols > timeThumbsMaxTiles || rows < 1 || rows > timeThumbsMaxTiles {
+		return nil, fmt.Errorf("timethumbs %q: cols and rows must be in [1, %d]", val, timeThumbsMaxTiles)
+	}
+	return &TimeThumbsConfig{Cols: cols, Rows: rows}, nil
+}
+
+// ParseTimeThumbsConfig parses a timethumbs option value, accumulating any error on the converter.
+func (s *strConvAccErr) ParseTimeThumbsConfig(key, val string) *TimeThumbsConfig {
+	if s.err != nil {
+		return nil
+	}
+	cfg, err := CreateTimeThumbsConfig(val)
+	if err != nil {
+		s.err = fmt.Errorf("key=%s, err=%w", key, err)
+		return nil
+	}
+	return cfg
+}
+
+// tiles returns the number of tiles per image.
+func (c *TimeThumbsConfig) tiles() int {
+	return c.Cols * c.Rows
+}
+
+// addTimeThumbsAdaptationSet adds the image AdaptationSet of the generated thumbnails.
+func addTimeThumbsAdaptationSet(cfg *ResponseConfig, a *asset, period *m.Period) {
+	tc := cfg.TimeThumbs
+	as := m.NewAdaptationSet()
+	as.Id = Ptr(uint32(timeThumbsASId))
+	as.ContentType = "image"
+	as.MimeType = "image/jpeg"
+	st := m.NewSegmentTemplate()
+	st.Media = "$RepresentationID$/$Number$.jpg"
+	st.SetTimescale(SUBS_TIME_TIMESCALE)
+	st.Duration = Ptr(uint32(a.SegmentDurMS))
+	st.StartNumber = Ptr(cfg.getStartNr())
+	as.SegmentTemplate = st
+	rep := m.NewRepresentation()
+	rep.Id = TimeThumbsRepID
+	rep.Width = uint32(tc.Cols * timeThumbsTileWidth)
+	rep.Height = uint32(tc.Rows * timeThumbsTileHeight)
+	rep.Bandwidth = uint32(tc.tiles()*typicalTimeThumbsTileBytes*8*1000) / uint32(a.SegmentDurMS)
+	rep.EssentialProperties = append(rep.EssentialProperties, &m.DescriptorType{
+		SchemeIdUri: ThumbnailTileSchemeIdUri,
+		Value:       fmt.Sprintf("%dx%d", tc.Cols, tc.Rows),
+	})
+	as.AppendRepresentation(rep)
+	period.AppendAdaptationSet(as)
+}
+
+// timeThumbsSegmentPart returns the segment number of a generated thumbnail URL.
+func timeThumbsSegmentPart(cfg *ResponseConfig, segmentPart string) (string, bool) {
+	if cfg.TimeThumbs == nil {
+		return "", false
+	}
+	seg, ok := strings.CutPrefix(segmentPart, TimeThumbsRepID+"/")
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(seg, ".jpg")
+}
+
+// timeThumbsLabel returns the text of a tile starting at utcMS in segment segNr.
+func timeThumbsLabel(utcMS int64, segNr uint32) string {
+	return fmt.Sprintf("%s\n#%d", time.UnixMilli(utcMS).UTC().Format("15:04:05.0"), segNr)
+}
+
+// createTimeThumbsImage returns the JPEG image of the tiles of segment segNr, which starts
+// at utcMS and lasts durMS.
+func createTimeThumbsImage(tc *TimeThumbsConfig, utcMS int64, durMS int, segNr uint32) ([]byte, error) {
+	img := image.NewYCbCr(image.Rect(0, 0, tc.Cols*timeThumbsTileWidth, tc.Rows*timeThumbsTileHeight),
+		image.YCbCrSubsampleRatio420)
+	nrTiles := tc.tiles()
+	for i := range nrTiles {
+		tileUTCMS := utcMS + int64(i*durMS/nrTiles)
+		pg, err := buildTextPlane(timeThumbsTileWidth, timeThumbsTileHeight, timeThumbsLabel(tileUTCMS, segNr), 1)
+		if err != nil {
+			return nil, fmt.Errorf("tile %d: %w", i, err)
+		}
+		x0 := (i % tc.Cols) * timeThumbsTileWidth
+		y0 := (i / tc.Cols) * timeThumbsTileHeight
+		drawPlane(img, pg, x0, y0, timeThumbsTileWidth, timeThumbsTileHeight)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: timeThumbsJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("encode jpeg: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawPlane draws the top-left width x height pixels of the plane pg with slateBlockSize
+// blocks at (x0, y0) in img.
+func drawPlane(img *image.YCbCr, pg *yuv.PlaneGrid, x0, y0, width, height int) {
+	bs := slateBlockSize
+	for y := range height {
+		for x := range width {
+			img.Y[img.YOffset(x0+x, y0+y)] = pg.Y[y/bs][x/bs]
+			if x%2 == 0 && y%2 == 0 {
+				cOffset := img.COffset(x0+x, y0+y)
+				img.Cb[cOffset] = pg.Cb[y/bs][x/bs]
+				img.Cr[cOffset] = pg.Cr[y/bs][x/bs]
+			}
+		}
+	}
+}
+
+// writeTimeThumbsSegment returns true and tries to write a generated thumbnail image if the
+// URL matches.
+func writeTimeThumbsSegment(w http.ResponseWriter, cfg *ResponseConfig, a *asset, segmentPart string,
+	nowMS int) (bool, error) {
+	seg, ok := timeThumbsSegmentPart(cfg, segmentPart)
+	if !ok {
+		return false, nil
+	}
+	nr, err := strconv.ParseUint(seg, 10, 32)
+	if err != nil {
+		return true, fmt.Errorf("bad seg nr %s: %w", seg, errNotFound)
+	}
+	if uint32(nr) < cfg.getStartNr() {
+		return true, errNotFound
+	}
+	ref, err := findSegMetaFromNr(a, a.refRep, uint32(nr), cfg, nowMS)
+	if err != nil {
+		return true, fmt.Errorf("findSegMetaFromNr: %w", err)
+	}
+	utcMS := int64(cfg.StartTimeS)*1000 + int64(ref.newTime*1000/uint64(ref.timescale))
+	durMS := int(uint64(ref.newDur) * 1000 / uint64(ref.timescale))
+	data, err := createTimeThumbsImage(cfg.TimeThumbs, utcMS, durMS, ref.newNr)
+	if err != nil {
+		return true, fmt.Errorf("createTimeThumbsImage: %w", err)
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if _, err := w.Write(data); err != nil {
+		slog.Error("write thumbnail response", "error", err)
+		return true, err
+	}
+	return true, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestCreateTimeThumbsConfig(t *testing.T) {
+	tc, err := CreateTimeThumbsConfig("5x2")
+	require.NoError(t, err)
+	assert.Equal(t, TimeThumbsConfig{Cols: 5, Rows: 2}, *tc)
+	assert.Equal(t, 10, tc.tiles())
+	for _, val := range []string{"5", "0x1", "1x11", "ax1", "1x"} {
+		_, err := CreateTimeThumbsConfig(val)
+		assert.Error(t, err, val)
+	}
+}
+
+func TestTimeThumbsLabel(t *testing.T) {
+	assert.Equal(t, "22:13:20.4\n#42", timeThumbsLabel(1_700_000_000_400, 42))
+}
+
+func TestTimeThumbs(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	for _, prefix := range []string{"", "segtimeline_1/"} {
+		url := "/livesim2/" + prefix + "timethumbs_5x2/testpic_2s/"
+		resp, body := testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		p := mpd.Periods[0]
+		require.Len(t, p.AdaptationSets, 3, url)
+		iAS := p.AdaptationSets[2]
+		assert.Equal(t, "image", string(iAS.ContentType))
+		assert.Equal(t, "image/jpeg", iAS.MimeType)
+		st := iAS.SegmentTemplate
+		assert.Equal(t, "$RepresentationID$/$Number$.jpg", st.Media, url)
+		assert.Equal(t, uint32(2000), *st.Duration)
+		assert.Equal(t, uint32(1000), st.GetTimescale())
+		require.Len(t, iAS.Representations, 1)
+		rep := iAS.Representations[0]
+		assert.Equal(t, TimeThumbsRepID, rep.Id)
+		assert.Equal(t, uint32(5*timeThumbsTileWidth), rep.Width)
+		assert.Equal(t, uint32(2*timeThumbsTileHeight), rep.Height)
+		require.Len(t, rep.EssentialProperties, 1)
+		assert.Equal(t, ThumbnailTileSchemeIdUri, string(rep.EssentialProperties[0].SchemeIdUri))
+		assert.Equal(t, "5x2", rep.EssentialProperties[0].Value)
+
+		resp, body = testFullRequest(t, ts, "GET", url+"timethumbs/20.jpg?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+		img, err := jpeg.Decode(bytes.NewReader(body))
+		require.NoError(t, err)
+		assert.Equal(t, 5*timeThumbsTileWidth, img.Bounds().Dx())
+		assert.Equal(t, 2*timeThumbsTileHeight, img.Bounds().Dy())
+
+		// Thumbnails in the future are too early
+		resp, _ = testFullRequest(t, ts, "GET", url+"timethumbs/20.jpg?nowMS=30000", nil)
+		assert.Equal(t, http.StatusTooEarly, resp.StatusCode, url)
+	}
+
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/timethumbs_0x2/testpic_2s/Manifest.mpd", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
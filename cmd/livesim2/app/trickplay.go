@@ -0,0 +1,191 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/drm"
+)
+
+// Trick play (trickplay_) adds a trick-mode video AdaptationSet, signalled by the DASH-IF
+// trickmode EssentialProperty, to the MPD. Its Representation is generated from the first
+// video Representation by keeping only the sync samples of each segment. Each sync sample
+// lasts until the next one, so the segments have the same timing as the source segments.
+// The source and trick-mode Representations share the init segment.
+
+const (
+	TrickModeSchemeIdUri = "http://dashif.org/guidelines/trickmode"
+	// trickPlayRepSuffix is added to the source Representation id to get the trick-mode id.
+	trickPlayRepSuffix = "_trick"
+	trickPlayASId      = 300
+)
+
+// addTrickPlayAdaptationSet adds a trick-mode AdaptationSet for the first video AdaptationSet.
+// The segment URLs must contain the Representation id, so that the trick-mode segments can
+// be told apart. The sync times of the asset must have been loaded. Nothing is added if there
+// is no video AdaptationSet left, e.g. after as_ filtering or a repchange_ drop.
+func addTrickPlayAdaptationSet(a *asset, period *m.Period) error {
+	vAS := firstVideoAdaptationSet(period)
+	if vAS == nil {
+		return nil
+	}
+	if vAS.SegmentTemplate == nil || !strings.Contains(vAS.SegmentTemplate.Media, "$RepresentationID$") {
+		return fmt.Errorf("video segment template without $RepresentationID$")
+	}
+	srcRep := vAS.Representations[0]
+	rd, ok := a.Reps[srcRep.Id]
+	if !ok || rd.PreEncrypted {
+		return fmt.Errorf("no clear video representation %s", srcRep.Id)
+	}
+	nrSyncs := len(a.syncTimes)
+	if nrSyncs == 0 {
+		return fmt.Errorf("no sync samples for video representation %s", srcRep.Id)
+	}
+	frameRate := videoFrameRate(rd, vAS, srcRep)
+	if frameRate == 0 {
+		return fmt.Errorf("no frame rate for video representation %s", srcRep.Id)
+	}
+
+	srcASId := uint32(0)
+	if vAS.Id != nil {
+		srcASId = *vAS.Id
+	}
+
+	as := vAS.Clone()
+	as.Id = Ptr(uint32(trickPlayASId))
+	as.InbandEventStreams = nil // No events in the trick-mode segments
+	as.ProducerReferenceTimes = nil
+	as.Roles = nil
+	as.FrameRate = ""
+	as.MaxFrameRate = ""
+	as.EssentialProperties = append(as.EssentialProperties, &m.DescriptorType{
+		SchemeIdUri: TrickModeSchemeIdUri,
+		Value:       fmt.Sprintf("%d", srcASId),
+	})
+	rep := as.Representations[0]
+	rep.Id = srcRep.Id + trickPlayRepSuffix
+	// The trick-mode frame rate is the sync-sample rate, and at maxPlayoutRate that is
+	// played out at the frame rate of the source.
+	num, den := uint64(nrSyncs*1000), uint64(a.LoopDurMS)
+	g := gcd(num, den)
+	rep.FrameRate = m.FrameRateType(fmt.Sprintf("%d/%d", num/g, den/g))
+	rate := frameRate * float64(a.LoopDurMS) / (1000 * float64(nrSyncs))
+	rep.MaxPlayoutRate = math.Round(rate*1000) / 1000
+	rep.CodingDependency = Ptr(false)
+	as.Representations = as.Representations[:1]
+	period.AppendAdaptationSet(as)
+	return nil
+}
+
+// videoFrameRate returns the frame rate of a video representation from its sample duration,
+// or from the MPD if the sample duration varies. It returns 0 if unknown.
+func videoFrameRate(rd *RepData, as *m.AdaptationSetType, rep *m.RepresentationType) float64 {
+	if rd.ConstantSampleDuration != nil && *rd.ConstantSampleDuration > 0 {
+		return float64(rd.MediaTimescale) / float64(*rd.ConstantSampleDuration)
+	}
+	for _, fr := range []string{string(rep.FrameRate), string(as.FrameRate), as.MaxFrameRate} {
+		if fr == "" {
+			continue
+		}
+		num, den, found := strings.Cut(fr, "/")
+		n, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			continue
+		}
+		d := 1.0
+		if found {
+			if d, err = strconv.ParseFloat(den, 64); err != nil || d == 0 {
+				continue
+			}
+		}
+		return n / d
+	}
+	return 0
+}
+
+// trickPlaySourcePart returns the segment part of the source Representation if segmentPart
+// is a trick-mode init or media segment.
+func trickPlaySourcePart(a *asset, segmentPart string) (string, bool) {
+	for _, rep := range a.Reps {
+		if rep.ContentType != "video" || rep.PreEncrypted {
+			continue
+		}
+		trickID := rep.ID + trickPlayRepSuffix
+		if !strings.Contains(segmentPart, trickID) {
+			continue
+		}
+		srcPart := strings.ReplaceAll(segmentPart, trickID, rep.ID)
+		if srcPart == rep.InitURI || rep.mediaRegexp.MatchString(srcPart) {
+			return srcPart, true
+		}
+	}
+	return segmentPart, false
+}
+
+// writeTrickPlaySegment writes the trick-mode segment generated from the source segment
+// segmentPart.
+func writeTrickPlaySegment(log *slog.Logger, w http.ResponseWriter, cfg *ResponseConfig, drmCfg *drm.DrmConfig,
+	vodFS fs.FS, a *asset, segmentPart string, nowMS int, isLast bool) error {
+	outSeg, err := genLiveSegment(log, vodFS, a, cfg, drmCfg, segmentPart, nowMS, isLast)
+	if err != nil {
+		return fmt.Errorf("convertToLive: %w", err)
+	}
+	if outSeg.seg == nil {
+		return fmt.Errorf("no media segment for %s", segmentPart)
+	}
+	outSeg.seg, err = trickPlaySegment(outSeg.seg, outSeg.meta)
+	if err != nil {
+		return fmt.Errorf("trickPlaySegment: %w", err)
+	}
+	return writeOutSeg(log, w, cfg, drmCfg, outSeg)
+}
+
+// trickPlaySegment returns a segment with one fragment with the sync samples of seg.
+// Each sync sample lasts until the next one, and the last one until the end of the segment.
+func trickPlaySegment(seg *mp4.MediaSegment, meta segMeta) (*mp4.MediaSegment, error) {
+	trex := meta.rep.initSeg.Moov.Mvex.Trex
+	var syncs []mp4.FullSample
+	for _, f := range seg.Fragments {
+		fss, err := f.GetFullSamples(trex)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range fss {
+			if s.IsSync() {
+				syncs = append(syncs, s)
+			}
+		}
+	}
+	if len(syncs) == 0 {
+		return nil, fmt.Errorf("no sync sample in segment %d", meta.newNr)
+	}
+	endTime := meta.newTime + uint64(meta.newDur)
+	trackID := seg.Fragments[0].Moof.Traf.Tfhd.TrackID
+	frag, err := mp4.CreateFragment(meta.newNr, trackID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range syncs {
+		next := endTime
+		if i+1 < len(syncs) {
+			next = syncs[i+1].DecodeTime
+		}
+		syncs[i].Dur = uint32(next - syncs[i].DecodeTime)
+		frag.AddFullSample(syncs[i])
+	}
+	out := mp4.NewMediaSegmentWithStyp(seg.Styp)
+	out.AddFragment(frag)
+	return out, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	m "github.com/Eyevinn/dash-mpd/mpd"
+	"github.com/Eyevinn/mp4ff/mp4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+)
+
+func TestTrickPlay(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+
+	getSamples := func(t *testing.T, url string) (*mp4.Fragment, []mp4.FullSample) {
+		resp, body := testFullRequest(t, ts, "GET", url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		f, err := mp4.DecodeFile(bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Len(t, f.Segments, 1)
+		var samples []mp4.FullSample
+		for _, frag := range f.Segments[0].Fragments {
+			fss, err := frag.GetFullSamples(nil)
+			require.NoError(t, err)
+			samples = append(samples, fss...)
+		}
+		return f.Segments[0].Fragments[0], samples
+	}
+
+	for _, prefix := range []string{"", "segtimeline_1/"} {
+		url := "/livesim2/" + prefix + "trickplay_1/testpic_2s/"
+		resp, body := testFullRequest(t, ts, "GET", url+"Manifest.mpd?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, url)
+		mpd, err := m.ReadFromString(string(body))
+		require.NoError(t, err)
+		p := mpd.Periods[0]
+		require.Len(t, p.AdaptationSets, 3, url)
+		vAS, tAS := p.AdaptationSets[1], p.AdaptationSets[2]
+		assert.Equal(t, uint32(trickPlayASId), *tAS.Id)
+		assert.Equal(t, "video", string(tAS.ContentType))
+		require.Len(t, tAS.EssentialProperties, 1)
+		assert.Equal(t, TrickModeSchemeIdUri, string(tAS.EssentialProperties[0].SchemeIdUri))
+		assert.Equal(t, "2", tAS.EssentialProperties[0].Value)
+		assert.Empty(t, tAS.Roles)
+		assert.Equal(t, vAS.SegmentTemplate.Media, tAS.SegmentTemplate.Media, url)
+		require.Len(t, tAS.Representations, 1)
+		rep := tAS.Representations[0]
+		assert.Equal(t, "V300_trick", rep.Id)
+		require.NotNil(t, rep.CodingDependency)
+		assert.False(t, *rep.CodingDependency)
+		// One sync sample per second at 30 frames per second
+		assert.Equal(t, m.FrameRateType("1/1"), rep.FrameRate)
+		assert.Equal(t, 30.0, rep.MaxPlayoutRate)
+
+		// The trick-mode init segment is the source init segment
+		resp, srcInit := testFullRequest(t, ts, "GET", url+"V300/init.mp4?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, trickInit := testFullRequest(t, ts, "GET", url+"V300_trick/init.mp4?nowMS=100000", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, srcInit, trickInit)
+
+		segName := "20.m4s"
+		if prefix != "" {
+			segName = "3600000.m4s"
+		}
+		_, srcSamples := getSamples(t, url+"V300/"+segName+"?nowMS=100000")
+		nrSyncs := 0
+		for _, s := range srcSamples {
+			if s.IsSync() {
+				nrSyncs++
+			}
+		}
+		frag, samples := getSamples(t, url+"V300_trick/"+segName+"?nowMS=100000")
+		assert.Equal(t, uint32(20), frag.Moof.Mfhd.SequenceNumber)
+		assert.Equal(t, uint64(40*90000), frag.Moof.Traf.Tfdt.BaseMediaDecodeTime())
+		require.Len(t, samples, nrSyncs, url)
+		var totDur uint32
+		for _, s := range samples {
+			assert.True(t, s.IsSync())
+			totDur += s.Dur
+		}
+		assert.Equal(t, uint32(2*90000), totDur)
+		assert.Equal(t, srcSamples[0].Data, samples[0].Data)
+
+		// Segments in the future are too early
+		resp, _ = testFullRequest(t, ts, "GET", url+"V300_trick/"+segName+"?nowMS=30000", nil)
+		assert.Equal(t, http.StatusTooEarly, resp.StatusCode, url)
+	}
+
+	// Without trickplay_, there are no trick-mode segments
+	resp, _ := testFullRequest(t, ts, "GET", "/livesim2/testpic_2s/V300_trick/20.m4s?nowMS=100000", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The ad Periods of SSAI have no trick-mode AdaptationSet
+	resp, body := testFullRequest(t, ts, "GET", "/livesim2/trickplay_1/ssai_30:20/testpic_2s/Manifest.mpd?nowMS=45000", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	mpd, err := m.ReadFromString(string(body))
+	require.NoError(t, err)
+	require.Greater(t, len(mpd.Periods), 1)
+	assert.Len(t, mpd.Periods[0].AdaptationSets, 3)
+	for _, p := range mpd.Periods[1:] {
+		assert.True(t, strings.HasPrefix(p.Id, "AD"), p.Id)
+		for _, as := range p.AdaptationSets {
+			assert.False(t, as.Id != nil && *as.Id == trickPlayASId, p.Id)
+		}
+	}
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/adp_30/trickplay_1/ssai_30:20/ads/train_ad/V1/0.m4s?nowMS=45000", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The trick-mode segments are not generated in chunks
+	for _, opt := range []string{"chunkdur_0.5/ato_1.5", "ato_inf", "ssras_1"} {
+		resp, _ = testFullRequest(t, ts, "GET", "/livesim2/trickplay_1/"+opt+"/testpic_2s/Manifest.mpd", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, opt)
+	}
+}
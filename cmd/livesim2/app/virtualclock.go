@@ -0,0 +1,252 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Virtual clock. By default livesim2 follows the wall clock, and a single request can be
+// moved in time with ?nowMS= or ?nowDate=. The virtual clock instead moves the server time
+// for all requests: it can be frozen, jumped forward or back, and run at N× speed, either
+// globally or for one stream (an asset path such as testpic_2s). Long-running behavior,
+// such as time-shift buffers of many hours, period transitions and SGAI breaks, can then be
+// tested in minutes. It is controlled via the /api/clock API, which is only registered
+// when the server is started with --clockapi.
+//
+// A clock is an affine map from real time to virtual time, anchored at the last change:
+// virtual = anchorVirt + (real - anchorReal) * speed, and a frozen clock stays at anchorVirt.
+
+// clockState is the virtual clock of the server or of one stream.
+type clockState struct {
+	anchorRealMS int64
+	anchorVirtMS int64
+	speed        float64
+	frozen       bool
+}
+
+func (c clockState) at(realMS int64) int64 {
+	if c.frozen {
+		return c.anchorVirtMS
+	}
+	return c.anchorVirtMS + int64(float64(realMS-c.anchorRealMS)*c.speed)
+}
+
+// rebase moves the anchor to realMS so that speed changes apply from now on.
+func (c *clockState) rebase(realMS int64) {
+	c.anchorVirtMS = c.at(realMS)
+	c.anchorRealMS = realMS
+}
+
+// ClockChange is a change of a virtual clock. The fields are applied in the order
+// reset, setTime, jumpMS, speed, freeze.
+type ClockChange struct {
+	Stream  string   `json:"stream,omitempty" doc:"Stream id (asset path, e.g. testpic_2s). Empty for the global clock"`
+	Reset   bool     `json:"reset,omitempty" doc:"Go back to following the global clock (stream) or wall clock (global)"`
+	SetTime string   `json:"setTime,omitempty" doc:"Set the clock to this RFC3339 time" example:"2026-01-01T00:00:00Z"`
+	JumpMS  *int64   `json:"jumpMS,omitempty" doc:"Jump the clock this many milliseconds (negative is back in time)"`
+	Speed   *float64 `json:"speed,omitempty" doc:"Run the clock at this speed relative to real time (1 is normal)"`
+	Freeze  *bool    `json:"freeze,omitempty" doc:"Freeze (true) or resume (false) the clock"`
+}
+
+// ClockInfo describes the current state of a virtual clock.
+type ClockInfo struct {
+	Stream  string    `json:"stream,omitempty" doc:"Stream id, empty for the global clock"`
+	Now     time.Time `json:"now" doc:"Current virtual time"`
+	NowMS   int64     `json:"nowMS" doc:"Current virtual time in milliseconds since epoch"`
+	Speed   float64   `json:"speed" doc:"Speed relative to real time when running"`
+	Frozen  bool      `json:"frozen" doc:"True if the clock is frozen"`
+	Virtual bool      `json:"virtual" doc:"False if the clock follows the wall clock"`
+}
+
+// VirtualClock holds the global and per-stream virtual clocks.
+type VirtualClock struct {
+	mu      sync.RWMutex
+	global  *clockState // nil follows the wall clock
+	streams map[string]*clockState
+	now     func() time.Time // real clock, injectable for tests
+}
+
+// NewVirtualClock returns a clock that follows the wall clock until changed.
+func NewVirtualClock() *VirtualClock {
+	return &VirtualClock{streams: make(map[string]*clockState), now: time.Now}
+}
+
+// clockPollInterval is how long to wait for a frozen clock before checking it again.
+const clockPollInterval = 100 * time.Millisecond
+
+// serverClock is the clock used by unixMS() and the live handlers.
+var serverClock = NewVirtualClock()
+
+// NowMS returns the virtual time in ms for a request path. A stream clock applies if its
+// id is a path segment sequence in urlPath (the longest match wins), otherwise the global clock.
+func (vc *VirtualClock) NowMS(urlPath string) int64 {
+	realMS := vc.now().UnixMilli()
+	vc.mu.RLock()
+	defer vc.mu.RUnlock()
+	if cs := vc.streamFor(urlPath); cs != nil {
+		return cs.at(realMS)
+	}
+	if vc.global != nil {
+		return vc.global.at(realMS)
+	}
+	return realMS
+}
+
+// RealDuration returns the real time it takes for the clock of urlPath to advance virtMS.
+// For a frozen clock, it returns clockPollInterval, after which the caller checks again.
+func (vc *VirtualClock) RealDuration(urlPath string, virtMS int64) time.Duration {
+	vc.mu.RLock()
+	defer vc.mu.RUnlock()
+	cs := vc.streamFor(urlPath)
+	if cs == nil {
+		cs = vc.global
+	}
+	if cs == nil {
+		return time.Duration(virtMS) * time.Millisecond
+	}
+	if cs.frozen || cs.speed == 0 {
+		return clockPollInterval
+	}
+	return time.Duration(float64(virtMS) * float64(time.Millisecond) / cs.speed)
+}
+
+// streamFor returns the clock of the stream matching urlPath, or nil. Caller must hold mu.
+func (vc *VirtualClock) streamFor(urlPath string) *clockState {
+	if len(vc.streams) == 0 || urlPath == "" {
+		return nil
+	}
+	p := "/" + strings.Trim(urlPath, "/") + "/"
+	var best *clockState
+	bestLen := 0
+	for id, cs := range vc.streams {
+		if len(id) > bestLen && strings.Contains(p, "/"+id+"/") {
+			best, bestLen = cs, len(id)
+		}
+	}
+	return best
+}
+
+// Apply changes a clock and returns its new state.
+func (vc *VirtualClock) Apply(ch ClockChange) (ClockInfo, error) {
+	stream := strings.Trim(ch.Stream, "/")
+	realMS := vc.now().UnixMilli()
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if ch.Reset {
+		if stream == "" {
+			vc.global = nil
+		} else {
+			delete(vc.streams, stream)
+		}
+	}
+	if ch.SetTime == "" && ch.JumpMS == nil && ch.Speed == nil && ch.Freeze == nil {
+		return vc.infoLocked(stream, realMS), nil
+	}
+	if ch.Speed != nil && *ch.Speed < 0 {
+		return ClockInfo{}, fmt.Errorf("speed %g must be >= 0", *ch.Speed)
+	}
+	var setMS int64
+	if ch.SetTime != "" {
+		t, err := time.Parse(time.RFC3339, ch.SetTime)
+		if err != nil {
+			return ClockInfo{}, fmt.Errorf("setTime: %w", err)
+		}
+		setMS = t.UnixMilli()
+		if setMS < 0 {
+			return ClockInfo{}, fmt.Errorf("setTime %s is before 1970", ch.SetTime)
+		}
+	}
+	cs := vc.stateLocked(stream, realMS)
+	cs.rebase(realMS)
+	if ch.SetTime != "" {
+		cs.anchorVirtMS = setMS
+	}
+	if ch.JumpMS != nil {
+		cs.anchorVirtMS += *ch.JumpMS
+	}
+	if ch.Speed != nil {
+		cs.speed = *ch.Speed
+	}
+	if ch.Freeze != nil {
+		cs.frozen = *ch.Freeze
+	}
+	if cs.anchorVirtMS < 0 {
+		cs.anchorVirtMS = 0
+	}
+	return vc.infoLocked(stream, realMS), nil
+}
+
+// stateLocked returns the clock to modify, creating it from the clock it currently
+// follows. Caller must hold mu for writing.
+func (vc *VirtualClock) stateLocked(stream string, realMS int64) *clockState {
+	if stream == "" {
+		if vc.global == nil {
+			vc.global = &clockState{anchorRealMS: realMS, anchorVirtMS: realMS, speed: 1}
+		}
+		return vc.global
+	}
+	cs, ok := vc.streams[stream]
+	if !ok {
+		virtMS := realMS
+		speed := 1.0
+		if vc.global != nil {
+			virtMS = vc.global.at(realMS)
+			speed = vc.global.speed
+		}
+		frozen := vc.global != nil && vc.global.frozen
+		cs = &clockState{anchorRealMS: realMS, anchorVirtMS: virtMS, speed: speed, frozen: frozen}
+		vc.streams[stream] = cs
+	}
+	return cs
+}
+
+// Info returns the state of the global clock and all stream clocks (sorted by id).
+func (vc *VirtualClock) Info() []ClockInfo {
+	realMS := vc.now().UnixMilli()
+	vc.mu.RLock()
+	defer vc.mu.RUnlock()
+	out := []ClockInfo{vc.infoLocked("", realMS)}
+	ids := make([]string, 0, len(vc.streams))
+	for id := range vc.streams {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		out = append(out, vc.infoLocked(id, realMS))
+	}
+	return out
+}
+
+// ResetAll makes all clocks follow the wall clock again.
+func (vc *VirtualClock) ResetAll() {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	vc.global = nil
+	vc.streams = make(map[string]*clockState)
+}
+
+// infoLocked describes a clock, falling back to what it follows. Caller must hold mu.
+func (vc *VirtualClock) infoLocked(stream string, realMS int64) ClockInfo {
+	cs := vc.global
+	if stream != "" {
+		if s, ok := vc.streams[stream]; ok {
+			cs = s
+		}
+	}
+	info := ClockInfo{Stream: stream, NowMS: realMS, Speed: 1}
+	if cs != nil {
+		info.NowMS = cs.at(realMS)
+		info.Speed = cs.speed
+		info.Frozen = cs.frozen
+		info.Virtual = true
+	}
+	info.Now = time.UnixMilli(info.NowMS).UTC()
+	return info
+}
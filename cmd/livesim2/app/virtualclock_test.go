@@ -0,0 +1,115 @@
+// Copyright 2026, DASH-Industry Forum. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE.md file.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualClock(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	now, adv := fixedClock(start)
+	vc := NewVirtualClock()
+	vc.now = now
+	startMS := start.UnixMilli()
+
+	assert.Equal(t, startMS, vc.NowMS("/livesim2/testpic_2s/Manifest.mpd"), "wall clock by default")
+
+	// Global speed 10: 1 s real is 10 s virtual.
+	_, err := vc.Apply(ClockChange{Speed: Ptr(10.0)})
+	require.NoError(t, err)
+	adv(time.Second)
+	assert.Equal(t, startMS+10_000, vc.NowMS(""))
+
+	// A stream clock starts from the global clock and is then independent.
+	_, err = vc.Apply(ClockChange{Stream: "testpic_2s", Freeze: Ptr(true)})
+	require.NoError(t, err)
+	adv(time.Second)
+	assert.Equal(t, startMS+10_000, vc.NowMS("/livesim2/segtimeline_1/testpic_2s/V300/1.m4s"))
+	assert.Equal(t, startMS+20_000, vc.NowMS("/livesim2/testpic_6s/Manifest.mpd"))
+	assert.Equal(t, startMS+20_000, vc.NowMS("/livesim2/testpic_2s_av1/Manifest.mpd"), "path segment match only")
+
+	// Jump back and resume.
+	info, err := vc.Apply(ClockChange{Stream: "testpic_2s", JumpMS: Ptr(int64(-5_000)), Freeze: Ptr(false)})
+	require.NoError(t, err)
+	assert.Equal(t, startMS+5_000, info.NowMS)
+	assert.Equal(t, 10.0, info.Speed, "resume keeps the speed it had before the freeze")
+	assert.True(t, info.Frozen == false && info.Virtual)
+
+	// Set an absolute time.
+	info, err = vc.Apply(ClockChange{SetTime: "1970-01-01T00:01:40Z", Freeze: Ptr(true)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100_000), info.NowMS)
+	assert.True(t, info.Frozen)
+
+	assert.Equal(t, clockPollInterval, vc.RealDuration("", 1_000), "frozen clock is polled")
+	_, err = vc.Apply(ClockChange{Stream: "testpic_2s", Speed: Ptr(4.0)})
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, vc.RealDuration("/livesim2/testpic_2s/V300/1.m4s", 1_000))
+
+	infos := vc.Info()
+	require.Len(t, infos, 2)
+	assert.Equal(t, "testpic_2s", infos[1].Stream)
+
+	_, err = vc.Apply(ClockChange{Speed: Ptr(-1.0)})
+	require.Error(t, err)
+	_, err = vc.Apply(ClockChange{SetTime: "yesterday"})
+	require.Error(t, err)
+
+	_, err = vc.Apply(ClockChange{Stream: "testpic_2s", Reset: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100_000), vc.NowMS("/livesim2/testpic_2s/Manifest.mpd"), "follows global again")
+	vc.ResetAll()
+	assert.Equal(t, now().UnixMilli(), vc.NowMS(""))
+}
+
+func TestClockAPI(t *testing.T) {
+	cfg := ServerConfig{
+		VodRoot:   "testdata/assets",
+		TimeoutS:  0,
+		LogFormat: logging.LogDiscard,
+		ClockAPI:  true,
+	}
+	err := logging.InitSlog(cfg.LogLevel, cfg.LogFormat)
+	require.NoError(t, err)
+	server, err := SetupServer(context.Background(), &cfg)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+	t.Cleanup(serverClock.ResetAll)
+
+	body := `{"stream":"testpic_2s","setTime":"1970-01-01T00:01:40Z","freeze":true}`
+	resp, respBody := testFullRequest(t, ts, "POST", "/api/clock", strings.NewReader(body))
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(respBody))
+	assert.Contains(t, string(respBody), `"nowMS":100000`)
+
+	// Segment 49 ends at 100s and is available, segment 51 is not yet.
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/testpic_2s/V300/49.m4s", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/testpic_2s/V300/51.m4s", nil)
+	assert.Equal(t, http.StatusTooEarly, resp.StatusCode)
+
+	// An explicit nowMS still wins.
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/testpic_2s/V300/51.m4s?nowMS=110000", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = testFullRequest(t, ts, "POST", "/api/clock", strings.NewReader(`{"speed":-2}`))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, respBody = testFullRequest(t, ts, "POST", "/api/clock/reset", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(respBody), `"virtual":false`)
+	resp, _ = testFullRequest(t, ts, "GET", "/livesim2/testpic_2s/V300/49.m4s", nil)
+	assert.Equal(t, http.StatusGone, resp.StatusCode, "back to wall clock")
+}
@@ -0,0 +1,379 @@
+package scte35
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Eyevinn/mp4ff/mp4"
+)
+
+// Command types of a scheduled event.
+const (
+	CmdSpliceInsert = "splice_insert"
+	CmdTimeSignal   = "time_signal"
+)
+
+// Segmentation types of a time_signal event.
+const (
+	SegPlacementOpportunity = "po"      // provider placement opportunity start/end (0x34/0x35)
+	SegProgram              = "program" // program start/end (0x10/0x11)
+)
+
+// DefaultLeadMS is the announcement lead time, as for CreateEmsgAhead.
+const DefaultLeadMS = 7000
+
+// Event is a scheduled ad break (or program) of a Schedule.
+type Event struct {
+	StartMS    int    `json:"startMS"` // splice time relative to the schedule (cycle) start
+	DurationMS int    `json:"durationMS"`
+	LeadMS     *int   `json:"leadMS,omitempty"`       // announcement lead time, Schedule.LeadMS if nil
+	Command    string `json:"command,omitempty"`      // splice_insert (default) or time_signal
+	Seg        string `json:"segmentation,omitempty"` // po (default) or program, for time_signal
+	Cancel     bool   `json:"cancel,omitempty"`       // canceled halfway between announcement and start
+	ReturnMS   int    `json:"returnMS,omitempty"`     // early return this long after start, 0 for none
+}
+
+// Schedule is a list of SCTE-35 events, optionally repeated every CycleMS.
+// Times are media times in milliseconds, so 0 is the availabilityStartTime of a live stream.
+type Schedule struct {
+	Events  []Event `json:"events"`
+	CycleMS int     `json:"cycleMS,omitempty"` // 0 for a one-time schedule
+	LeadMS  int     `json:"leadMS"`            // default announcement lead time
+}
+
+// ParseSchedule parses a schedule from the grammar
+//
+//	<event>[,<event>...][;cycle=<s>][;lead=<s>]
+//
+// where an event is <startS>:<durS>[:<mod>...] and the modifiers are ts (time_signal with
+// placement opportunity start/end), prog (time_signal with program start/end), lead<s>,
+// cancel and ret<s> (early return). Times are in seconds with up to millisecond precision.
+func ParseSchedule(val string) (*Schedule, error) {
+	if val == "" {
+		return nil, fmt.Errorf("empty scte35 schedule")
+	}
+	parts := strings.Split(val, ";")
+	s := Schedule{LeadMS: DefaultLeadMS}
+	for _, kv := range parts[1:] {
+		key, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("scte35 param %q must be key=val", kv)
+		}
+		ms, err := ParseMS(v)
+		if err != nil {
+			return nil, fmt.Errorf("scte35 %s: %w", key, err)
+		}
+		switch key {
+		case "cycle":
+			s.CycleMS = ms
+		case "lead":
+			s.LeadMS = ms
+		default:
+			return nil, fmt.Errorf("unknown scte35 param %q", key)
+		}
+	}
+	for _, ev := range strings.Split(parts[0], ",") {
+		fields := strings.Split(ev, ":")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("scte35 event %q: must be <startS>:<durS>[:<mod>...]", ev)
+		}
+		var e Event
+		var err error
+		if e.StartMS, err = ParseMS(fields[0]); err != nil {
+			return nil, fmt.Errorf("scte35 event %q start: %w", ev, err)
+		}
+		if e.DurationMS, err = ParseMS(fields[1]); err != nil {
+			return nil, fmt.Errorf("scte35 event %q duration: %w", ev, err)
+		}
+		for _, mod := range fields[2:] {
+			switch {
+			case mod == "ts":
+				e.Command, e.Seg = CmdTimeSignal, SegPlacementOpportunity
+			case mod == "prog":
+				e.Command, e.Seg = CmdTimeSignal, SegProgram
+			case mod == "cancel":
+				e.Cancel = true
+			case strings.HasPrefix(mod, "lead"):
+				ms, err := ParseMS(mod[len("lead"):])
+				if err != nil {
+					return nil, fmt.Errorf("scte35 event %q lead: %w", ev, err)
+				}
+				e.LeadMS = &ms
+			case strings.HasPrefix(mod, "ret"):
+				if e.ReturnMS, err = ParseMS(mod[len("ret"):]); err != nil {
+					return nil, fmt.Errorf("scte35 event %q ret: %w", ev, err)
+				}
+			default:
+				return nil, fmt.Errorf("scte35 event %q: unknown modifier %q", ev, mod)
+			}
+		}
+		s.Events = append(s.Events, e)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ParseMS parses a non-negative number of seconds, such as 2.5, to milliseconds.
+func ParseMS(v string) (int, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f > 1e7 || math.IsNaN(f) {
+		return 0, fmt.Errorf("%q is not a valid time in seconds", v)
+	}
+	return int(math.Round(f * 1000)), nil
+}
+
+// Validate checks the events and that they fit in the cycle.
+func (s *Schedule) Validate() error {
+	if len(s.Events) == 0 {
+		return fmt.Errorf("scte35 schedule has no events")
+	}
+	for i, e := range s.Events {
+		switch {
+		case e.DurationMS <= 0:
+			return fmt.Errorf("scte35 event %d: duration must be positive", i)
+		case e.Command != "" && e.Command != CmdSpliceInsert && e.Command != CmdTimeSignal:
+			return fmt.Errorf("scte35 event %d: unknown command %q", i, e.Command)
+		case e.Seg != "" && e.Seg != SegPlacementOpportunity && e.Seg != SegProgram:
+			return fmt.Errorf("scte35 event %d: unknown segmentation %q", i, e.Seg)
+		case e.ReturnMS >= e.DurationMS:
+			return fmt.Errorf("scte35 event %d: early return must be before the end", i)
+		case e.Cancel && e.ReturnMS > 0:
+			return fmt.Errorf("scte35 event %d: a canceled event cannot return early", i)
+		}
+		if s.CycleMS > 0 {
+			if e.StartMS+e.DurationMS > s.CycleMS {
+				return fmt.Errorf("scte35 event %d: ends after the cycle of %dms", i, s.CycleMS)
+			}
+			if s.leadMS(e) >= s.CycleMS {
+				return fmt.Errorf("scte35 event %d: lead time must be shorter than the cycle", i)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schedule) leadMS(e Event) int {
+	if e.LeadMS != nil {
+		return *e.LeadMS
+	}
+	return s.LeadMS
+}
+
+// MessageKind is the kind of a SCTE-35 message of an event.
+type MessageKind int
+
+const (
+	MsgOut    MessageKind = iota // splice out or segmentation start
+	MsgIn                        // early return or segmentation end
+	MsgCancel                    // cancellation of the event
+)
+
+// Message is one SCTE-35 message of a scheduled event. It is sent at SendMS and refers to
+// the splice time SpliceMS.
+type Message struct {
+	Kind       MessageKind
+	EventID    uint32
+	SendMS     int
+	SpliceMS   int
+	DurationMS int // event duration for MsgOut, otherwise 0
+	Payload    []byte
+}
+
+// Messages returns the messages sent in [fromMS, toMS), in time order.
+func (s *Schedule) Messages(fromMS, toMS int) []Message {
+	var msgs []Message
+	kFirst, kLast := 0, 0
+	if s.CycleMS > 0 {
+		maxLeadMS := 0
+		for _, e := range s.Events {
+			maxLeadMS = max(maxLeadMS, s.leadMS(e))
+		}
+		kFirst = max(0, fromMS/s.CycleMS-1)
+		kLast = (toMS + maxLeadMS) / s.CycleMS
+	}
+	for k := kFirst; k <= kLast; k++ {
+		for i, e := range s.Events {
+			id := uint32(k*len(s.Events) + i + 1)
+			for _, msg := range s.eventMessages(e, id, k*s.CycleMS) {
+				if fromMS <= msg.SendMS && msg.SendMS < toMS {
+					msgs = append(msgs, msg)
+				}
+			}
+		}
+	}
+	sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].SendMS < msgs[j].SendMS })
+	return msgs
+}
+
+// ActiveMessages returns the messages sent before toMS whose events have not ended before
+// fromMS, as for an MPD with a time-shift window [fromMS, toMS).
+func (s *Schedule) ActiveMessages(fromMS, toMS int) []Message {
+	spanMS := 0
+	for _, e := range s.Events {
+		spanMS = max(spanMS, s.leadMS(e)+e.DurationMS)
+	}
+	var msgs []Message
+	for _, msg := range s.Messages(max(0, fromMS-spanMS), toMS) {
+		if msg.SpliceMS+msg.DurationMS >= fromMS {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// PerMinuteSchedule returns the schedule of the fixed layouts of CreateEmsgAhead.
+func PerMinuteSchedule(perMinute int) (*Schedule, error) {
+	if err := IsValidSCTE35Interval(perMinute); err != nil {
+		return nil, err
+	}
+	s := Schedule{CycleMS: 60_000, LeadMS: DefaultLeadMS}
+	switch perMinute {
+	case 1:
+		s.Events = []Event{{StartMS: 10_000, DurationMS: 20_000}}
+	case 2:
+		s.Events = []Event{{StartMS: 10_000, DurationMS: 10_000}, {StartMS: 40_000, DurationMS: 10_000}}
+	case 3:
+		s.Events = []Event{{StartMS: 10_000, DurationMS: 10_000}, {StartMS: 36_000, DurationMS: 10_000},
+			{StartMS: 46_000, DurationMS: 10_000}}
+	}
+	return &s, nil
+}
+
+// Break is the span of a splice out, up to the scheduled or early return.
+type Break struct {
+	EventID    uint32
+	StartMS    int
+	DurationMS int
+}
+
+// Breaks returns the breaks that start in [fromMS, toMS), in time order.
+// Canceled events are not breaks.
+func (s *Schedule) Breaks(fromMS, toMS int) []Break {
+	var breaks []Break
+	kFirst, kLast := 0, 0
+	if s.CycleMS > 0 {
+		kFirst = max(0, fromMS/s.CycleMS-1)
+		kLast = toMS / s.CycleMS
+	}
+	for k := kFirst; k <= kLast; k++ {
+		for i, e := range s.Events {
+			startMS := k*s.CycleMS + e.StartMS
+			if e.Cancel || startMS < fromMS || startMS >= toMS {
+				continue
+			}
+			b := Break{EventID: uint32(k*len(s.Events) + i + 1), StartMS: startMS, DurationMS: e.DurationMS}
+			if e.ReturnMS > 0 {
+				b.DurationMS = e.ReturnMS
+			}
+			breaks = append(breaks, b)
+		}
+	}
+	sort.SliceStable(breaks, func(i, j int) bool { return breaks[i].StartMS < breaks[j].StartMS })
+	return breaks
+}
+
+// eventMessages returns the messages of event e with id, for the cycle starting at cycleStartMS.
+func (s *Schedule) eventMessages(e Event, id uint32, cycleStartMS int) []Message {
+	startMS := cycleStartMS + e.StartMS
+	leadMS := s.leadMS(e)
+	out := Message{Kind: MsgOut, EventID: id, SendMS: max(0, startMS-leadMS), SpliceMS: startMS,
+		DurationMS: e.DurationMS}
+	msgs := []Message{out}
+	switch {
+	case e.Cancel:
+		msgs = append(msgs, Message{Kind: MsgCancel, EventID: id, SendMS: max(0, startMS-leadMS/2),
+			SpliceMS: startMS})
+	case e.ReturnMS > 0 || e.Command == CmdTimeSignal:
+		// time_signal events have no auto-return, so their end is always signaled.
+		endMS := startMS + e.DurationMS
+		if e.ReturnMS > 0 {
+			endMS = startMS + e.ReturnMS
+		}
+		msgs = append(msgs, Message{Kind: MsgIn, EventID: id, SendMS: max(out.SendMS, endMS-leadMS),
+			SpliceMS: endMS})
+	}
+	for i := range msgs {
+		msgs[i].Payload = eventPayload(e, msgs[i])
+	}
+	return msgs
+}
+
+// eventPayload returns the splice_info_section of a message.
+func eventPayload(e Event, msg Message) []byte {
+	pts := uint64(msg.SpliceMS) * 90 % (1 << 33)
+	if e.Command != CmdTimeSignal {
+		if msg.Kind == MsgCancel {
+			return CreateSpliceInsertPayload(SpliceInsertParams{SpliceEventID: msg.EventID, Tier: 4095,
+				SpliceEventCancelIndicator: true})
+		}
+		p := SpliceInsertParams{
+			PtsTime:               pts,
+			SpliceEventID:         msg.EventID,
+			Tier:                  4095,
+			OutOfNetworkIndicator: msg.Kind == MsgOut,
+		}
+		if msg.Kind == MsgOut {
+			p.Duration = uint64(msg.DurationMS) * 90
+			p.AutoReturn = e.ReturnMS == 0
+		}
+		return CreateSpliceInsertPayload(p)
+	}
+	startType, endType := uint8(SegTypeProviderPOStart), uint8(SegTypeProviderPOEnd)
+	if e.Seg == SegProgram {
+		startType, endType = SegTypeProgramStart, SegTypeProgramEnd
+	}
+	p := TimeSignalParams{
+		PtsTime:          pts,
+		SegmentationID:   msg.EventID,
+		SegmentationType: startType,
+		Canceled:         msg.Kind == MsgCancel,
+	}
+	switch msg.Kind {
+	case MsgOut:
+		p.Duration = uint64(msg.DurationMS) * 90
+	case MsgIn:
+		p.SegmentationType = endType
+	}
+	return CreateTimeSignalPayload(p)
+}
+
+// Emsg returns msg as an emsg box (version 1) with times in timescale.
+func (msg Message) Emsg(timescale uint64) *mp4.EmsgBox {
+	return &mp4.EmsgBox{
+		Version:          1,
+		TimeScale:        uint32(timescale),
+		PresentationTime: uint64(msg.SpliceMS) * timescale / 1000,
+		EventDuration:    uint32(uint64(msg.DurationMS) * timescale / 1000),
+		ID:               msg.EventID<<2 | uint32(msg.Kind),
+		SchemeIDURI:      SchemeIDURI,
+		MessageData:      msg.Payload,
+	}
+}
+
+// TimeSignalParams are the parameters of a time_signal with one segmentation descriptor.
+type TimeSignalParams struct {
+	PtsTime          uint64
+	Duration         uint64 // segmentation duration in 90kHz ticks, 0 for none
+	SegmentationID   uint32 // segmentation_event_id
+	SegmentationType uint8  // segmentation_type_id
+	Canceled         bool   // segmentation_event_cancel_indicator
+}
+
+// CreateTimeSignalPayload creates a SCTE-35 time_signal splice_info_section including CRC.
+func CreateTimeSignalPayload(p TimeSignalParams) []byte {
+	d := SegmentationDescriptor{EventID: p.SegmentationID, Cancel: p.Canceled}
+	if !p.Canceled {
+		d.TypeID = p.SegmentationType
+		d.SegmentNum, d.SegmentsExpected = 1, 1
+		if p.Duration != 0 {
+			d.Duration = &p.Duration
+		}
+	}
+	data, _ := NewTimeSignal(p.PtsTime, d).Encode() // cannot fail for this descriptor
+	return data
+}
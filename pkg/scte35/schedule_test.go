@@ -0,0 +1,152 @@
+package scte35_test
+
+import (
+	"testing"
+
+	gscte35 "github.com/Comcast/gots/v2/scte35"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Dash-Industry-Forum/livesim2/pkg/scte35"
+)
+
+func TestParseSchedule(t *testing.T) {
+	lead := 4000
+	cases := []struct {
+		val     string
+		want    *scte35.Schedule
+		wantErr string
+	}{
+		{val: "10:20;cycle=60", want: &scte35.Schedule{
+			Events: []scte35.Event{{StartMS: 10_000, DurationMS: 20_000}}, CycleMS: 60_000, LeadMS: 7000}},
+		{val: "30.5:15:ts:lead4,90:30:prog:ret10,200:10:cancel;lead=5", want: &scte35.Schedule{
+			Events: []scte35.Event{
+				{StartMS: 30_500, DurationMS: 15_000, Command: scte35.CmdTimeSignal,
+					Seg: scte35.SegPlacementOpportunity, LeadMS: &lead},
+				{StartMS: 90_000, DurationMS: 30_000, Command: scte35.CmdTimeSignal, Seg: scte35.SegProgram,
+					ReturnMS: 10_000},
+				{StartMS: 200_000, DurationMS: 10_000, Cancel: true},
+			}, LeadMS: 5000}},
+		{val: "", wantErr: "empty scte35 schedule"},
+		{val: "10", wantErr: "must be <startS>:<durS>"},
+		{val: "10:x", wantErr: "duration"},
+		{val: "10:0", wantErr: "duration must be positive"},
+		{val: "10:20:ret20", wantErr: "early return must be before the end"},
+		{val: "10:20:ret5:cancel", wantErr: "cannot return early"},
+		{val: "10:20:foo", wantErr: "unknown modifier"},
+		{val: "50:20;cycle=60", wantErr: "ends after the cycle"},
+		{val: "10:20;cycle=60;lead=60", wantErr: "shorter than the cycle"},
+		{val: "10:20;seed=1", wantErr: "unknown scte35 param"},
+	}
+	for _, c := range cases {
+		got, err := scte35.ParseSchedule(c.val)
+		if c.wantErr != "" {
+			require.Error(t, err, c.val)
+			assert.Contains(t, err.Error(), c.wantErr)
+			continue
+		}
+		require.NoError(t, err, c.val)
+		assert.Equal(t, c.want, got, c.val)
+	}
+}
+
+func TestScheduleMessages(t *testing.T) {
+	s, err := scte35.ParseSchedule("10:20,30:10:ts:ret5,45:10:cancel;cycle=60")
+	require.NoError(t, err)
+	type msgTimes struct {
+		kind             scte35.MessageKind
+		id               uint32
+		sendMS, spliceMS int
+	}
+	var got []msgTimes
+	for _, msg := range s.Messages(60_000, 120_000) {
+		got = append(got, msgTimes{msg.Kind, msg.EventID, msg.SendMS, msg.SpliceMS})
+	}
+	want := []msgTimes{
+		{scte35.MsgOut, 4, 63_000, 70_000},
+		{scte35.MsgOut, 5, 83_000, 90_000},
+		{scte35.MsgIn, 5, 88_000, 95_000}, // early return
+		{scte35.MsgOut, 6, 98_000, 105_000},
+		{scte35.MsgCancel, 6, 101_500, 105_000},
+	}
+	assert.Equal(t, want, got)
+
+	// The announcement of the first cycle's event is in the previous cycle.
+	s, err = scte35.ParseSchedule("2:10;cycle=30")
+	require.NoError(t, err)
+	msgs := s.Messages(55_000, 60_000)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, 62_000, msgs[0].SpliceMS)
+	assert.Empty(t, s.Messages(0, 0))
+	assert.Equal(t, 0, s.Messages(0, 1)[0].SendMS, "announcement clamped to 0")
+}
+
+func TestSchedulePayloads(t *testing.T) {
+	s, err := scte35.ParseSchedule("10:20:ret5,40:10:prog,60:10:cancel,80:10:ts:cancel")
+	require.NoError(t, err)
+	msgs := s.Messages(0, 200_000)
+	require.Len(t, msgs, 8)
+
+	decode := func(msg scte35.Message) gscte35.SCTE35 {
+		sc, err := gscte35.NewSCTE35(append([]byte{0}, msg.Payload...))
+		require.NoError(t, err)
+		return sc
+	}
+
+	out := decode(msgs[0])
+	ins, ok := out.CommandInfo().(gscte35.SpliceInsertCommand)
+	require.True(t, ok)
+	assert.True(t, ins.IsOut())
+	assert.False(t, ins.IsAutoReturn())
+	assert.Equal(t, uint32(1), ins.EventID())
+	assert.Equal(t, uint64(10*90000), uint64(out.CommandInfo().PTS()))
+
+	ret := decode(msgs[1])
+	ins = ret.CommandInfo().(gscte35.SpliceInsertCommand)
+	assert.False(t, ins.IsOut())
+	assert.Equal(t, uint64(15*90000), uint64(ret.CommandInfo().PTS()))
+
+	progStart, progEnd := decode(msgs[2]), decode(msgs[3])
+	require.Len(t, progStart.Descriptors(), 1)
+	assert.Equal(t, gscte35.SegDescType(gscte35.SegDescProgramStart), progStart.Descriptors()[0].TypeID())
+	assert.Equal(t, uint64(10*90000), uint64(progStart.Descriptors()[0].Duration()))
+	assert.Equal(t, gscte35.SegDescType(gscte35.SegDescProgramEnd), progEnd.Descriptors()[0].TypeID())
+	assert.Equal(t, uint64(50*90000), uint64(progEnd.CommandInfo().PTS()))
+
+	cancel := decode(msgs[5])
+	ins = cancel.CommandInfo().(gscte35.SpliceInsertCommand)
+	assert.True(t, ins.IsEventCanceled())
+	assert.Equal(t, uint32(3), ins.EventID())
+
+	tsCancel := decode(msgs[7])
+	assert.True(t, tsCancel.Descriptors()[0].IsEventCanceled())
+
+	emsg := msgs[0].Emsg(90000)
+	assert.Equal(t, uint64(900_000), emsg.PresentationTime)
+	assert.Equal(t, uint32(1_800_000), emsg.EventDuration)
+	assert.Equal(t, scte35.SchemeIDURI, emsg.SchemeIDURI)
+}
+
+func TestScheduleBreaks(t *testing.T) {
+	s, err := scte35.ParseSchedule("10:20,30:10:ts:ret5,45:10:cancel;cycle=60")
+	require.NoError(t, err)
+	want := []scte35.Break{
+		{EventID: 2, StartMS: 30_000, DurationMS: 5000},
+		{EventID: 4, StartMS: 70_000, DurationMS: 20_000},
+		{EventID: 5, StartMS: 90_000, DurationMS: 5000},
+	}
+	assert.Equal(t, want, s.Breaks(20_000, 100_000))
+}
+
+func TestScheduleActiveMessages(t *testing.T) {
+	s, err := scte35.PerMinuteSchedule(2)
+	require.NoError(t, err)
+	// Window [75s, 100s): the break at 70s lasts until 80s and the one at 100s is announced at 93s.
+	var splices []int
+	for _, msg := range s.ActiveMessages(75_000, 100_000) {
+		splices = append(splices, msg.SpliceMS)
+	}
+	assert.Equal(t, []int{70_000, 100_000}, splices)
+	_, err = scte35.PerMinuteSchedule(4)
+	assert.Error(t, err)
+}
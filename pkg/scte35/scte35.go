@@ -4,8 +4,6 @@ package scte35
 import (
 	"errors"
 
-	"github.com/Comcast/gots/v2"
-	"github.com/Comcast/gots/v2/scte35"
 	"github.com/Eyevinn/mp4ff/mp4"
 )
 
@@ -103,23 +101,21 @@ type SpliceInsertParams struct {
 
 // CreateSpliceInsertPayload creates a SCTE-35 splice_info_section including CRC.
 func CreateSpliceInsertPayload(p SpliceInsertParams) []byte {
-	s := scte35.CreateSCTE35()
-	s.SetTier(uint16(p.Tier))
-	cmd := scte35.CreateSpliceInsertCommand()
-	cmd.SetUniqueProgramId(p.UniqueProgramID)
-	cmd.SetEventID(p.SpliceEventID)
-	cmd.SetAvailNum(p.AvailNum)
-	cmd.SetAvailsExpected(p.AvailsExpected)
-	cmd.SetIsEventCanceled(p.SpliceEventCancelIndicator)
-	if p.Duration != 0 {
-		cmd.SetHasDuration(true)
-		cmd.SetDuration(gots.PTS(p.Duration))
-		cmd.SetIsAutoReturn(p.AutoReturn)
+	c := SpliceInsert{EventID: p.SpliceEventID, Cancel: p.SpliceEventCancelIndicator}
+	if !p.SpliceEventCancelIndicator {
+		c.OutOfNetwork = p.OutOfNetworkIndicator
+		c.SpliceImmediate = p.SpliceImmediateFlag
+		if !p.SpliceImmediateFlag {
+			c.PTSTime = &p.PtsTime
+		}
+		if p.Duration != 0 {
+			c.BreakDuration = &BreakDuration{AutoReturn: p.AutoReturn, Duration: p.Duration}
+		}
+		c.UniqueProgramID = p.UniqueProgramID
+		c.AvailNum = p.AvailNum
+		c.AvailsExpected = p.AvailsExpected
 	}
-	cmd.SetHasPTS(true)
-	cmd.SetPTS(gots.PTS(p.PtsTime))
-	cmd.SetIsOut(p.OutOfNetworkIndicator)
-	cmd.SetSpliceImmediate(p.SpliceImmediateFlag)
-	s.SetCommandInfo(cmd)
-	return s.UpdateData()
+	s := SpliceInfo{SAPType: SAPTypeNotSpecified, Tier: p.Tier, SpliceInsert: &c}
+	data, _ := s.Encode() // cannot fail without descriptors
+	return data
 }
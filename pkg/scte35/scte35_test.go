@@ -1,6 +1,7 @@
 package scte35_test
 
 import (
+	"encoding/hex"
 	"testing"
 
 	"github.com/Dash-Industry-Forum/livesim2/pkg/scte35"
@@ -69,3 +70,39 @@ func TestSCTE35Generation(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateSpliceInsertPayloadCancel(t *testing.T) {
+	data := scte35.CreateSpliceInsertPayload(scte35.SpliceInsertParams{PtsTime: 900_000, Duration: 1_800_000,
+		SpliceEventID: 10, Tier: 4095, SpliceEventCancelIndicator: true, OutOfNetworkIndicator: true})
+	s, err := scte35.DecodeSpliceInfo(data)
+	require.NoError(t, err)
+	require.NotNil(t, s.SpliceInsert)
+	assert.Equal(t, scte35.SpliceInsert{EventID: 10, Cancel: true}, *s.SpliceInsert)
+}
+
+// TestCreateSpliceInsertPayloadBytes pins the encoded splice_info_sections, with
+// pts_adjustment 0 and the splice time as absolute PTS.
+func TestCreateSpliceInsertPayloadBytes(t *testing.T) {
+	testCases := []struct {
+		desc string
+		p    scte35.SpliceInsertParams
+		want string
+	}{
+		{
+			desc: "out with auto-return",
+			p: scte35.SpliceInsertParams{PtsTime: 900_000, Duration: 1_800_000, SpliceEventID: 10, Tier: 4095,
+				OutOfNetworkIndicator: true, AutoReturn: true},
+			want: "fc302500000000000000fff014050000000a7feffe000dbba0fe001b7740000000000000bae6dae3",
+		},
+		{
+			desc: "cancel",
+			p: scte35.SpliceInsertParams{PtsTime: 900_000, Duration: 1_800_000, SpliceEventID: 10, Tier: 4095,
+				SpliceEventCancelIndicator: true, OutOfNetworkIndicator: true},
+			want: "fc301600000000000000fff005050000000aff00002c75a9f2",
+		},
+	}
+	for _, tc := range testCases {
+		got := scte35.CreateSpliceInsertPayload(tc.p)
+		assert.Equal(t, tc.want, hex.EncodeToString(got), tc.desc)
+	}
+}
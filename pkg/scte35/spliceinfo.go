@@ -0,0 +1,722 @@
+package scte35
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Eyevinn/mp4ff/bits"
+	"github.com/Eyevinn/mp4ff/mp4"
+)
+
+const (
+	tableID         = 0xfc
+	cueIdentifier   = 0x43554549 // "CUEI"
+	ptsMask         = 1<<33 - 1
+	sectionHdrLen   = 3  // table_id and section_length
+	sectionFixedLen = 11 // protocol_version to splice_command_type
+	crcLen          = 4
+)
+
+// Splice command types.
+const (
+	SpliceNullType   = 0x00
+	SpliceInsertType = 0x05
+	TimeSignalType   = 0x06
+)
+
+// Splice descriptor tags.
+const (
+	AvailDescriptorTag        = 0x00
+	SegmentationDescriptorTag = 0x02
+)
+
+// SAPTypeNotSpecified is the sap_type of a section without SAP information.
+const SAPTypeNotSpecified = 3
+
+// Segmentation types (segmentation_type_id) from SCTE-35 Table 23.
+const (
+	SegTypeNotIndicated                = 0x00
+	SegTypeProgramStart                = 0x10
+	SegTypeProgramEnd                  = 0x11
+	SegTypeChapterStart                = 0x20
+	SegTypeChapterEnd                  = 0x21
+	SegTypeBreakStart                  = 0x22
+	SegTypeBreakEnd                    = 0x23
+	SegTypeProviderAdStart             = 0x30
+	SegTypeProviderAdEnd               = 0x31
+	SegTypeDistributorAdStart          = 0x32
+	SegTypeDistributorAdEnd            = 0x33
+	SegTypeProviderPOStart             = 0x34
+	SegTypeProviderPOEnd               = 0x35
+	SegTypeDistributorPOStart          = 0x36
+	SegTypeDistributorPOEnd            = 0x37
+	SegTypeProviderOverlayPOStart      = 0x38
+	SegTypeProviderOverlayPOEnd        = 0x39
+	SegTypeDistributorOverlayPOStart   = 0x3a
+	SegTypeDistributorOverlayPOEnd     = 0x3b
+	SegTypeUnscheduledEventStart       = 0x40
+	SegTypeUnscheduledEventEnd         = 0x41
+	SegTypeAlternateContentOpportunity = 0x42
+	SegTypeProviderAdBlockStart        = 0x44
+	SegTypeProviderAdBlockEnd          = 0x45
+	SegTypeDistributorAdBlockStart     = 0x46
+	SegTypeDistributorAdBlockEnd       = 0x47
+	SegTypeNetworkStart                = 0x50
+	SegTypeNetworkEnd                  = 0x51
+)
+
+// SpliceInfo is a splice_info_section. At most one of SpliceInsert, TimeSignal and
+// OtherCommand is set. With none set, the command is a splice_null.
+// Encrypted sections are not supported.
+type SpliceInfo struct {
+	SAPType       uint8
+	PTSAdjustment uint64
+	Tier          uint16
+	SpliceInsert  *SpliceInsert
+	TimeSignal    *TimeSignal
+	OtherCommand  *RawCommand
+	// Descriptors are written in the order avail, segmentation, other.
+	AvailDescriptors        []AvailDescriptor
+	SegmentationDescriptors []SegmentationDescriptor
+	OtherDescriptors        []RawDescriptor
+}
+
+// SpliceInsert is a splice_insert command. The other fields are only present
+// if Cancel is false. Without Components, the splice is a program splice.
+type SpliceInsert struct {
+	EventID         uint32
+	Cancel          bool
+	OutOfNetwork    bool
+	SpliceImmediate bool
+	PTSTime         *uint64 // splice_time of a program splice, nil if not specified
+	Components      []SpliceComponent
+	BreakDuration   *BreakDuration
+	UniqueProgramID uint16
+	AvailNum        uint8
+	AvailsExpected  uint8
+}
+
+// SpliceComponent is a component of a component splice.
+type SpliceComponent struct {
+	Tag     uint8
+	PTSTime *uint64 // nil if not specified or splice_immediate
+}
+
+// BreakDuration is the break_duration of a splice_insert in 90kHz ticks.
+type BreakDuration struct {
+	AutoReturn bool
+	Duration   uint64
+}
+
+// TimeSignal is a time_signal command.
+type TimeSignal struct {
+	PTSTime *uint64 // nil if not specified
+}
+
+// RawCommand is a splice command that is not interpreted, such as splice_schedule.
+type RawCommand struct {
+	Type uint8
+	Data []byte
+}
+
+// AvailDescriptor is an avail_descriptor.
+type AvailDescriptor struct {
+	ProviderAvailID uint32
+}
+
+// SegmentationDescriptor is a segmentation_descriptor. The other fields are only
+// present if Cancel is false. Without Components, the segmentation is a program segmentation.
+type SegmentationDescriptor struct {
+	EventID              uint32
+	Cancel               bool
+	DeliveryRestrictions *DeliveryRestrictions // nil if delivery is not restricted
+	Components           []SegmentationComponent
+	Duration             *uint64 // segmentation_duration in 90kHz ticks
+	UPIDType             uint8
+	UPID                 []byte
+	TypeID               uint8
+	SegmentNum           uint8
+	SegmentsExpected     uint8
+	SubSegmentNum        uint8 // only for placement opportunity and ad block starts
+	SubSegmentsExpected  uint8 // only for placement opportunity and ad block starts
+}
+
+// DeliveryRestrictions are the restriction flags of a segmentation_descriptor.
+type DeliveryRestrictions struct {
+	WebDeliveryAllowed bool
+	NoRegionalBlackout bool
+	ArchiveAllowed     bool
+	DeviceRestrictions uint8
+}
+
+// SegmentationComponent is a component of a component segmentation.
+type SegmentationComponent struct {
+	Tag       uint8
+	PTSOffset uint64
+}
+
+// RawDescriptor is a splice descriptor that is not interpreted. Data starts after the identifier.
+type RawDescriptor struct {
+	Tag        uint8
+	Identifier uint32
+	Data       []byte
+}
+
+// NewTimeSignal returns a time_signal section at ptsTime with segmentation descriptors.
+func NewTimeSignal(ptsTime uint64, descs ...SegmentationDescriptor) *SpliceInfo {
+	return &SpliceInfo{
+		SAPType:                 SAPTypeNotSpecified,
+		Tier:                    4095,
+		TimeSignal:              &TimeSignal{PTSTime: &ptsTime},
+		SegmentationDescriptors: descs,
+	}
+}
+
+// NewSpliceInsert returns a splice_insert section with a program splice at ptsTime.
+// A non-zero duration adds a break_duration.
+func NewSpliceInsert(eventID uint32, outOfNetwork bool, ptsTime, duration uint64, autoReturn bool) *SpliceInfo {
+	si := SpliceInsert{EventID: eventID, OutOfNetwork: outOfNetwork, PTSTime: &ptsTime}
+	if duration != 0 {
+		si.BreakDuration = &BreakDuration{AutoReturn: autoReturn, Duration: duration}
+	}
+	return &SpliceInfo{SAPType: SAPTypeNotSpecified, Tier: 4095, SpliceInsert: &si}
+}
+
+// CommandType returns the splice_command_type.
+func (s *SpliceInfo) CommandType() uint8 {
+	switch {
+	case s.SpliceInsert != nil:
+		return SpliceInsertType
+	case s.TimeSignal != nil:
+		return TimeSignalType
+	case s.OtherCommand != nil:
+		return s.OtherCommand.Type
+	default:
+		return SpliceNullType
+	}
+}
+
+// PTS returns the splice time of the command plus pts_adjustment, and false if the
+// command has no (program) splice time.
+func (s *SpliceInfo) PTS() (uint64, bool) {
+	var pts *uint64
+	switch {
+	case s.SpliceInsert != nil && !s.SpliceInsert.Cancel:
+		pts = s.SpliceInsert.PTSTime
+	case s.TimeSignal != nil:
+		pts = s.TimeSignal.PTSTime
+	}
+	if pts == nil {
+		return 0, false
+	}
+	return (*pts + s.PTSAdjustment) & ptsMask, true
+}
+
+// Encode returns the splice_info_section including CRC_32.
+func (s *SpliceInfo) Encode() ([]byte, error) {
+	cmd, err := s.encodeCommand()
+	if err != nil {
+		return nil, err
+	}
+	descs, err := s.encodeDescriptors()
+	if err != nil {
+		return nil, err
+	}
+	if len(cmd) >= 1<<12 {
+		return nil, fmt.Errorf("splice command too long: %d", len(cmd))
+	}
+	if len(descs) >= 1<<16 {
+		return nil, fmt.Errorf("descriptors too long: %d", len(descs))
+	}
+	sectionLen := sectionFixedLen + len(cmd) + 2 + len(descs) + crcLen
+	if sectionLen >= 1<<12 {
+		return nil, fmt.Errorf("section too long: %d", sectionLen)
+	}
+	var buf bytes.Buffer
+	w := bits.NewWriter(&buf)
+	w.Write(tableID, 8)
+	w.Write(0, 1) // section_syntax_indicator
+	w.Write(0, 1) // private_indicator
+	w.Write(uint(s.SAPType), 2)
+	w.Write(uint(sectionLen), 12)
+	w.Write(0, 8) // protocol_version
+	w.Write(0, 1) // encrypted_packet
+	w.Write(0, 6) // encryption_algorithm
+	w.Write(uint(s.PTSAdjustment&ptsMask), 33)
+	w.Write(0, 8) // cw_index
+	w.Write(uint(s.Tier), 12)
+	w.Write(uint(len(cmd)), 12)
+	w.Write(uint(s.CommandType()), 8)
+	if err := w.AccError(); err != nil {
+		return nil, err
+	}
+	buf.Write(cmd)
+	buf.Write([]byte{byte(len(descs) >> 8), byte(len(descs))})
+	buf.Write(descs)
+	crc := crc32MPEG2(buf.Bytes())
+	buf.Write([]byte{byte(crc >> 24), byte(crc >> 16), byte(crc >> 8), byte(crc)})
+	return buf.Bytes(), nil
+}
+
+func (s *SpliceInfo) encodeCommand() ([]byte, error) {
+	n := 0
+	for _, set := range []bool{s.SpliceInsert != nil, s.TimeSignal != nil, s.OtherCommand != nil} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return nil, errors.New("more than one splice command")
+	}
+	var buf bytes.Buffer
+	w := bits.NewWriter(&buf)
+	switch {
+	case s.SpliceInsert != nil:
+		c := s.SpliceInsert
+		w.Write(uint(c.EventID), 32)
+		writeFlag(w, c.Cancel)
+		w.Write(0x7f, 7)
+		if c.Cancel {
+			break
+		}
+		programSplice := len(c.Components) == 0
+		writeFlag(w, c.OutOfNetwork)
+		writeFlag(w, programSplice)
+		writeFlag(w, c.BreakDuration != nil)
+		writeFlag(w, c.SpliceImmediate)
+		w.Write(0xf, 4) // event_id_compliance_flag and reserved
+		if programSplice && !c.SpliceImmediate {
+			writeSpliceTime(w, c.PTSTime)
+		}
+		if !programSplice {
+			if len(c.Components) > 255 {
+				return nil, fmt.Errorf("too many components: %d", len(c.Components))
+			}
+			w.Write(uint(len(c.Components)), 8)
+			for _, comp := range c.Components {
+				w.Write(uint(comp.Tag), 8)
+				if !c.SpliceImmediate {
+					writeSpliceTime(w, comp.PTSTime)
+				}
+			}
+		}
+		if c.BreakDuration != nil {
+			writeFlag(w, c.BreakDuration.AutoReturn)
+			w.Write(0x3f, 6)
+			w.Write(uint(c.BreakDuration.Duration&ptsMask), 33)
+		}
+		w.Write(uint(c.UniqueProgramID), 16)
+		w.Write(uint(c.AvailNum), 8)
+		w.Write(uint(c.AvailsExpected), 8)
+	case s.TimeSignal != nil:
+		writeSpliceTime(w, s.TimeSignal.PTSTime)
+	case s.OtherCommand != nil:
+		return s.OtherCommand.Data, nil
+	}
+	return buf.Bytes(), w.AccError()
+}
+
+func (s *SpliceInfo) encodeDescriptors() ([]byte, error) {
+	var buf bytes.Buffer
+	add := func(tag uint8, identifier uint32, data []byte) error {
+		if len(data)+4 > 255 {
+			return fmt.Errorf("descriptor with tag %d too long: %d", tag, len(data)+4)
+		}
+		buf.Write([]byte{tag, byte(len(data) + 4),
+			byte(identifier >> 24), byte(identifier >> 16), byte(identifier >> 8), byte(identifier)})
+		buf.Write(data)
+		return nil
+	}
+	for _, d := range s.AvailDescriptors {
+		id := d.ProviderAvailID
+		if err := add(AvailDescriptorTag, cueIdentifier,
+			[]byte{byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id)}); err != nil {
+			return nil, err
+		}
+	}
+	for i := range s.SegmentationDescriptors {
+		data, err := s.SegmentationDescriptors[i].encode()
+		if err != nil {
+			return nil, fmt.Errorf("segmentation descriptor %d: %w", i, err)
+		}
+		if err := add(SegmentationDescriptorTag, cueIdentifier, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range s.OtherDescriptors {
+		if err := add(d.Tag, d.Identifier, d.Data); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encode returns the segmentation_descriptor after the identifier.
+func (d *SegmentationDescriptor) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	w := bits.NewWriter(&buf)
+	w.Write(uint(d.EventID), 32)
+	writeFlag(w, d.Cancel)
+	w.Write(0x7f, 7) // segmentation_event_id_compliance_indicator and reserved
+	if d.Cancel {
+		return buf.Bytes(), w.AccError()
+	}
+	programSegmentation := len(d.Components) == 0
+	writeFlag(w, programSegmentation)
+	writeFlag(w, d.Duration != nil)
+	writeFlag(w, d.DeliveryRestrictions == nil)
+	if r := d.DeliveryRestrictions; r != nil {
+		writeFlag(w, r.WebDeliveryAllowed)
+		writeFlag(w, r.NoRegionalBlackout)
+		writeFlag(w, r.ArchiveAllowed)
+		w.Write(uint(r.DeviceRestrictions), 2)
+	} else {
+		w.Write(0x1f, 5)
+	}
+	if !programSegmentation {
+		if len(d.Components) > 255 {
+			return nil, fmt.Errorf("too many components: %d", len(d.Components))
+		}
+		w.Write(uint(len(d.Components)), 8)
+		for _, c := range d.Components {
+			w.Write(uint(c.Tag), 8)
+			w.Write(0x7f, 7)
+			w.Write(uint(c.PTSOffset&ptsMask), 33)
+		}
+	}
+	if d.Duration != nil {
+		if *d.Duration >= 1<<40 {
+			return nil, fmt.Errorf("segmentation duration too large: %d", *d.Duration)
+		}
+		w.Write(uint(*d.Duration), 40)
+	}
+	if len(d.UPID) > 255 {
+		return nil, fmt.Errorf("upid too long: %d", len(d.UPID))
+	}
+	w.Write(uint(d.UPIDType), 8)
+	w.Write(uint(len(d.UPID)), 8)
+	if err := w.AccError(); err != nil {
+		return nil, err
+	}
+	buf.Write(d.UPID)
+	buf.Write([]byte{d.TypeID, d.SegmentNum, d.SegmentsExpected})
+	if hasSubSegments(d.TypeID) {
+		buf.Write([]byte{d.SubSegmentNum, d.SubSegmentsExpected})
+	}
+	return buf.Bytes(), nil
+}
+
+// hasSubSegments returns true if segmentation type has sub_segment_num and sub_segments_expected.
+func hasSubSegments(typeID uint8) bool {
+	switch typeID {
+	case SegTypeProviderPOStart, SegTypeDistributorPOStart, SegTypeProviderOverlayPOStart,
+		SegTypeDistributorOverlayPOStart, SegTypeProviderAdBlockStart, SegTypeDistributorAdBlockStart:
+		return true
+	default:
+		return false
+	}
+}
+
+func writeFlag(w *bits.Writer, f bool) {
+	if f {
+		w.Write(1, 1)
+	} else {
+		w.Write(0, 1)
+	}
+}
+
+func writeSpliceTime(w *bits.Writer, pts *uint64) {
+	if pts == nil {
+		w.Write(0x7f, 8) // time_specified_flag=0 and reserved
+		return
+	}
+	w.Write(1, 1)
+	w.Write(0x3f, 6)
+	w.Write(uint(*pts&ptsMask), 33)
+}
+
+// DecodeSpliceInfo decodes a splice_info_section and checks its CRC_32.
+func DecodeSpliceInfo(data []byte) (*SpliceInfo, error) {
+	if len(data) < sectionHdrLen+sectionFixedLen+2+crcLen {
+		return nil, fmt.Errorf("splice_info_section too short: %d bytes", len(data))
+	}
+	if data[0] != tableID {
+		return nil, fmt.Errorf("table_id 0x%02x is not 0xfc", data[0])
+	}
+	sectionLen := int(data[1]&0x0f)<<8 | int(data[2])
+	if sectionHdrLen+sectionLen > len(data) {
+		return nil, fmt.Errorf("section_length %d exceeds data", sectionLen)
+	}
+	data = data[:sectionHdrLen+sectionLen]
+	if crc32MPEG2(data) != 0 {
+		return nil, errors.New("CRC_32 mismatch")
+	}
+	r := bits.NewReader(bytes.NewReader(data[1:]))
+	var s SpliceInfo
+	r.Read(2) // section_syntax_indicator and private_indicator
+	s.SAPType = uint8(r.Read(2))
+	r.Read(12) // section_length
+	if v := r.Read(8); v != 0 {
+		return nil, fmt.Errorf("unsupported protocol_version %d", v)
+	}
+	if r.ReadFlag() {
+		return nil, errors.New("encrypted splice_info_section not supported")
+	}
+	r.Read(6)
+	s.PTSAdjustment = uint64(r.Read(33))
+	r.Read(8) // cw_index
+	s.Tier = uint16(r.Read(12))
+	cmdLen := int(r.Read(12))
+	cmdType := uint8(r.Read(8))
+	if err := r.AccError(); err != nil {
+		return nil, err
+	}
+	pos := sectionHdrLen + sectionFixedLen
+	end := len(data) - crcLen
+	if cmdLen == 0xfff { // legacy unspecified length, only for known commands
+		cmdLen = -1
+	} else if pos+cmdLen+2 > end {
+		return nil, fmt.Errorf("splice_command_length %d exceeds section", cmdLen)
+	}
+	n, err := s.decodeCommand(cmdType, data[pos:end], cmdLen)
+	if err != nil {
+		return nil, fmt.Errorf("splice command 0x%02x: %w", cmdType, err)
+	}
+	pos += n
+	if pos+2 > end {
+		return nil, errors.New("missing descriptor_loop_length")
+	}
+	loopLen := int(data[pos])<<8 | int(data[pos+1])
+	pos += 2
+	if pos+loopLen > end {
+		return nil, fmt.Errorf("descriptor_loop_length %d exceeds section", loopLen)
+	}
+	if err := s.decodeDescriptors(data[pos : pos+loopLen]); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// decodeCommand decodes the command in data and returns its length. With cmdLen -1,
+// the length is given by the command syntax.
+func (s *SpliceInfo) decodeCommand(cmdType uint8, data []byte, cmdLen int) (int, error) {
+	if cmdLen >= 0 {
+		data = data[:cmdLen]
+	}
+	rd := bytes.NewReader(data)
+	r := bits.NewReader(rd)
+	switch cmdType {
+	case SpliceNullType:
+	case SpliceInsertType:
+		var c SpliceInsert
+		c.EventID = uint32(r.Read(32))
+		c.Cancel = r.ReadFlag()
+		r.Read(7)
+		if !c.Cancel {
+			c.OutOfNetwork = r.ReadFlag()
+			programSplice := r.ReadFlag()
+			hasDuration := r.ReadFlag()
+			c.SpliceImmediate = r.ReadFlag()
+			r.Read(4)
+			if programSplice && !c.SpliceImmediate {
+				c.PTSTime = readSpliceTime(r)
+			}
+			if !programSplice {
+				n := int(r.Read(8))
+				for range n {
+					comp := SpliceComponent{Tag: uint8(r.Read(8))}
+					if !c.SpliceImmediate {
+						comp.PTSTime = readSpliceTime(r)
+					}
+					c.Components = append(c.Components, comp)
+				}
+			}
+			if hasDuration {
+				autoReturn := r.ReadFlag()
+				r.Read(6)
+				c.BreakDuration = &BreakDuration{AutoReturn: autoReturn, Duration: uint64(r.Read(33))}
+			}
+			c.UniqueProgramID = uint16(r.Read(16))
+			c.AvailNum = uint8(r.Read(8))
+			c.AvailsExpected = uint8(r.Read(8))
+		}
+		s.SpliceInsert = &c
+	case TimeSignalType:
+		s.TimeSignal = &TimeSignal{PTSTime: readSpliceTime(r)}
+	default:
+		if cmdLen < 0 {
+			return 0, errors.New("unknown command without length")
+		}
+		s.OtherCommand = &RawCommand{Type: cmdType, Data: bytes.Clone(data)}
+		return cmdLen, nil
+	}
+	if err := r.AccError(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	n := len(data) - rd.Len()
+	if cmdLen >= 0 && n != cmdLen {
+		return 0, fmt.Errorf("command is %d bytes, but splice_command_length is %d", n, cmdLen)
+	}
+	return n, nil
+}
+
+func readSpliceTime(r *bits.Reader) *uint64 {
+	if !r.ReadFlag() {
+		r.Read(7)
+		return nil
+	}
+	r.Read(6)
+	pts := uint64(r.Read(33))
+	return &pts
+}
+
+func (s *SpliceInfo) decodeDescriptors(data []byte) error {
+	for len(data) > 0 {
+		if len(data) < 2 || len(data) < 2+int(data[1]) {
+			return errors.New("truncated splice descriptor")
+		}
+		tag, body := data[0], data[2:2+int(data[1])]
+		data = data[2+int(data[1]):]
+		if len(body) < 4 {
+			return fmt.Errorf("splice descriptor with tag %d too short", tag)
+		}
+		identifier := uint32(body[0])<<24 | uint32(body[1])<<16 | uint32(body[2])<<8 | uint32(body[3])
+		body = body[4:]
+		switch {
+		case identifier == cueIdentifier && tag == AvailDescriptorTag:
+			if len(body) != 4 {
+				return fmt.Errorf("avail_descriptor length %d", len(body))
+			}
+			id := uint32(body[0])<<24 | uint32(body[1])<<16 | uint32(body[2])<<8 | uint32(body[3])
+			s.AvailDescriptors = append(s.AvailDescriptors, AvailDescriptor{ProviderAvailID: id})
+		case identifier == cueIdentifier && tag == SegmentationDescriptorTag:
+			d, err := decodeSegmentationDescriptor(body)
+			if err != nil {
+				return fmt.Errorf("segmentation_descriptor: %w", err)
+			}
+			s.SegmentationDescriptors = append(s.SegmentationDescriptors, *d)
+		default:
+			s.OtherDescriptors = append(s.OtherDescriptors,
+				RawDescriptor{Tag: tag, Identifier: identifier, Data: bytes.Clone(body)})
+		}
+	}
+	return nil
+}
+
+func decodeSegmentationDescriptor(data []byte) (*SegmentationDescriptor, error) {
+	rd := bytes.NewReader(data)
+	r := bits.NewReader(rd)
+	var d SegmentationDescriptor
+	d.EventID = uint32(r.Read(32))
+	d.Cancel = r.ReadFlag()
+	r.Read(7)
+	if !d.Cancel {
+		programSegmentation := r.ReadFlag()
+		hasDuration := r.ReadFlag()
+		if r.ReadFlag() { // delivery_not_restricted_flag
+			r.Read(5)
+		} else {
+			d.DeliveryRestrictions = &DeliveryRestrictions{
+				WebDeliveryAllowed: r.ReadFlag(),
+				NoRegionalBlackout: r.ReadFlag(),
+				ArchiveAllowed:     r.ReadFlag(),
+				DeviceRestrictions: uint8(r.Read(2)),
+			}
+		}
+		if !programSegmentation {
+			n := int(r.Read(8))
+			for range n {
+				tag := uint8(r.Read(8))
+				r.Read(7)
+				d.Components = append(d.Components, SegmentationComponent{Tag: tag, PTSOffset: uint64(r.Read(33))})
+			}
+		}
+		if hasDuration {
+			dur := uint64(r.Read(40))
+			d.Duration = &dur
+		}
+		d.UPIDType = uint8(r.Read(8))
+		upidLen := int(r.Read(8))
+		if upidLen > 0 {
+			d.UPID = make([]byte, upidLen)
+			for i := range d.UPID {
+				d.UPID[i] = byte(r.Read(8))
+			}
+		}
+		d.TypeID = uint8(r.Read(8))
+		d.SegmentNum = uint8(r.Read(8))
+		d.SegmentsExpected = uint8(r.Read(8))
+		if hasSubSegments(d.TypeID) && rd.Len() >= 2 { // optional before SCTE-35 2016
+			d.SubSegmentNum = uint8(r.Read(8))
+			d.SubSegmentsExpected = uint8(r.Read(8))
+		}
+	}
+	if err := r.AccError(); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return &d, nil
+}
+
+// DecodeEmsg decodes the SCTE-35 message of an emsg box with the SCTE 214-1 binary scheme.
+func DecodeEmsg(e *mp4.EmsgBox) (*SpliceInfo, error) {
+	if e.SchemeIDURI != SchemeIDURI {
+		return nil, fmt.Errorf("emsg scheme %q is not %s", e.SchemeIDURI, SchemeIDURI)
+	}
+	return DecodeSpliceInfo(e.MessageData)
+}
+
+// DecodeBase64 decodes a base64-encoded splice_info_section.
+func DecodeBase64(b64 string) (*SpliceInfo, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+	if err != nil {
+		return nil, fmt.Errorf("base64: %w", err)
+	}
+	return DecodeSpliceInfo(data)
+}
+
+// DecodeXMLBinary decodes the first Binary element of an SCTE 35 XML fragment, such
+// as the Signal of an MPD Event with scheme urn:scte:scte35:2014:xml+bin.
+// Namespace prefixes are ignored.
+func DecodeXMLBinary(data []byte) (*SpliceInfo, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("no Binary element")
+			}
+			return nil, fmt.Errorf("xml: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "Binary" {
+			var b64 string
+			if err := d.DecodeElement(&b64, &se); err != nil {
+				return nil, fmt.Errorf("xml: %w", err)
+			}
+			return DecodeBase64(b64)
+		}
+	}
+}
+
+// crc32MPEG2 returns the CRC_32 of MPEG-2 sections, which is 0 over a section including its CRC.
+func crc32MPEG2(data []byte) uint32 {
+	crc := uint32(0xffffffff)
+	for _, b := range data {
+		crc ^= uint32(b) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}